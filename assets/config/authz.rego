@@ -14,7 +14,9 @@ allow = response {
     pathstart == ["api", "v0"]
 
     response := {
-        "tenants": token.payload.tenants
+        "tenants": token.payload.tenants,
+        "user": object.get(token.payload, "sub", ""),
+        "admin": object.get(token.payload, "admin", false)
     }
 }
 
@@ -2,7 +2,11 @@ package main
 
 import (
 	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	"github.com/diwise/iot-device-mgmt/internal/application/battery"
 	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/application/linkquality"
+	"github.com/diwise/iot-device-mgmt/internal/application/retention"
+	"github.com/diwise/iot-device-mgmt/internal/application/uptime"
 	"github.com/diwise/iot-device-mgmt/internal/application/watchdog"
 	"github.com/diwise/service-chassis/pkg/infrastructure/servicerunner"
 )
@@ -37,6 +41,10 @@ type appConfig struct {
 	AlarmServiceConfig     alarms.Config           `yaml:"alarmservice"`
 	DeviceManagementConfig devices.Config          `yaml:"devicemanagement"`
 	WatchdogConfig         watchdog.WatchdogConfig `yaml:"watchdog"`
+	RetentionConfig        retention.Config        `yaml:"retention"`
+	BatteryConfig          battery.Config          `yaml:"battery"`
+	LinkQualityConfig      linkquality.Config      `yaml:"linkquality"`
+	UptimeConfig           uptime.Config           `yaml:"uptime"`
 	/*
 	   messenger              messaging.MsgContext
 	   db                     storage.Store
@@ -14,8 +14,15 @@ import (
 
 	"github.com/diwise/iot-device-mgmt/internal/application"
 	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	"github.com/diwise/iot-device-mgmt/internal/application/battery"
 	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/application/linkquality"
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	"github.com/diwise/iot-device-mgmt/internal/application/retention"
+	"github.com/diwise/iot-device-mgmt/internal/application/search"
 	"github.com/diwise/iot-device-mgmt/internal/application/sensors"
+	"github.com/diwise/iot-device-mgmt/internal/application/uptime"
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
 	"github.com/diwise/iot-device-mgmt/internal/application/watchdog"
 	"github.com/diwise/iot-device-mgmt/internal/infrastructure/storage"
 	"github.com/diwise/iot-device-mgmt/internal/presentation/api"
@@ -102,8 +109,15 @@ func initialize(ctx context.Context, flags flagMap, cfg *appConfig, policiesFile
 	var deviceAPI devices.DeviceAPIService
 	var sensorAPI sensors.SensorAPIService
 	var alarmsAPI alarms.AlarmAPIService
+	var quarantineAPI quarantine.QuarantineService
+	var viewsAPI views.ViewService
+	var searchAPI search.SearchService
 	var wd watchdog.Watchdog
-	var deviceStatusHandler devices.DeviceStatusHandler
+	var ingestion devices.Ingestion
+	var rt retention.Retention
+	var bf battery.Forecaster
+	var lq linkquality.LinkQuality
+	var up uptime.Generator
 
 	var app application.Management
 
@@ -120,14 +134,21 @@ func initialize(ctx context.Context, flags flagMap, cfg *appConfig, policiesFile
 		oninit(func(ctx context.Context, ac *appConfig) error {
 			log.Debug("initializing servicerunner")
 
-			svc := devices.New(s, s, s, s, messenger, &ac.DeviceManagementConfig)
+			alarmsAPI = alarms.New(s, messenger, &ac.AlarmServiceConfig)
+			svc := devices.New(s, s, s, s, messenger, &ac.DeviceManagementConfig).WithAlarms(alarmsAPI)
 			deviceAPI = svc
-			deviceStatusHandler = svc
 			sensorAPI = sensors.New(s, s)
-			alarmsAPI = alarms.New(s, messenger, &ac.AlarmServiceConfig)
 			wd = watchdog.New(alarmsAPI, &ac.WatchdogConfig)
+			rt = retention.New(s, &ac.RetentionConfig)
+			bf = battery.New(s, &ac.BatteryConfig)
+			lq = linkquality.New(s, &ac.LinkQualityConfig)
+			up = uptime.New(svc, &ac.UptimeConfig)
+			quarantineAPI = quarantine.New(s, svc, devices.QuarantineReason)
+			ingestion = devices.NewIngestion(svc, quarantineAPI, &ac.DeviceManagementConfig.Ingestion)
+			viewsAPI = views.New(s)
+			searchAPI = search.New(s)
 
-			app = application.New(deviceAPI, sensorAPI, alarmsAPI, seedExistingDevices)
+			app = application.New(deviceAPI, sensorAPI, alarmsAPI, quarantineAPI, viewsAPI, searchAPI, seedExistingDevices)
 
 			return nil
 		}),
@@ -149,9 +170,10 @@ func initialize(ctx context.Context, flags flagMap, cfg *appConfig, policiesFile
 				return
 			}
 
+			ingestion.Start(ctx)
 			messenger.Start()
 
-			err = devices.RegisterTopicMessageHandler(ctx, deviceStatusHandler, messenger)
+			err = devices.RegisterTopicMessageHandler(ctx, ingestion, quarantineAPI, messenger)
 			if err != nil {
 				return
 			}
@@ -162,6 +184,10 @@ func initialize(ctx context.Context, flags flagMap, cfg *appConfig, policiesFile
 			}
 
 			wd.Start(ctx)
+			rt.Start(ctx)
+			bf.Start(ctx)
+			lq.Start(ctx)
+			up.Start(ctx)
 
 			return nil
 		}),
@@ -169,7 +195,12 @@ func initialize(ctx context.Context, flags flagMap, cfg *appConfig, policiesFile
 			log.Debug("shutdown servicerunner")
 
 			wd.Stop(ctx)
+			rt.Stop(ctx)
+			bf.Stop(ctx)
+			lq.Stop(ctx)
+			up.Stop(ctx)
 			messenger.Close()
+			ingestion.Stop(ctx)
 			s.Close()
 
 			return nil
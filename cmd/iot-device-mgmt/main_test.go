@@ -13,7 +13,10 @@ import (
 	"github.com/diwise/iot-device-mgmt/internal/application"
 	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	"github.com/diwise/iot-device-mgmt/internal/application/search"
 	"github.com/diwise/iot-device-mgmt/internal/application/sensors"
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
 
 	"github.com/diwise/iot-device-mgmt/internal/infrastructure/storage"
 
@@ -143,7 +146,11 @@ func setupTest(t *testing.T) (*http.ServeMux, *is.I) {
 	sm := sensors.New(p, p)
 	as := alarms.New(p, &msgCtx, &cfg.AlarmServiceConfig)
 
-	app := application.New(dm, sm, as, exisitingDeviceUpdateFlag)
+	qs := quarantine.New(p, dm, devices.QuarantineReason)
+	vs := views.New(p)
+	ss := search.New(p)
+
+	app := application.New(dm, sm, as, qs, vs, ss, exisitingDeviceUpdateFlag)
 
 	err = app.SeedLwm2mTypes(ctx, cfg.DeviceManagementConfig.Types)
 	is.NoErr(err)
@@ -3,6 +3,7 @@ package alarms
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -14,15 +15,26 @@ import (
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 
 	"github.com/diwise/messaging-golang/pkg/messaging"
 )
 
 var tracer = otel.Tracer("iot-device-mgmt/alarms")
 
+// rejectedStatus counts the status messages that are older than the current state of
+// their device, or that repeat a stored alarm, and so can not change its alarms.
+var rejectedStatus, _ = otel.Meter("iot-device-mgmt/alarms").Int64Counter(
+	"alarm_status_rejected",
+	metric.WithDescription("Number of out of order or duplicated device status messages ignored by alarms"),
+)
+
 var ErrAlarmNotFound = fmt.Errorf("alarm not found")
+var ErrAlarmNotNewer = fmt.Errorf("alarm is not newer than the stored alarm")
 
 const AlarmDeviceNotObserved string = "device_not_observed"
+const AlarmOutsideGeofence string = "outside_geofence"
 
 //go:generate moq -rm -out alarmstorage_mock.go . AlarmStorage
 type AlarmStorage interface {
@@ -30,6 +42,7 @@ type AlarmStorage interface {
 	Remove(ctx context.Context, deviceID string, alarmType string) error
 	Stale(ctx context.Context) (types.Collection[types.Device], error)
 	Alarms(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error)
+	DeviceObservedAt(ctx context.Context, deviceID string) (time.Time, error)
 }
 
 type svc struct {
@@ -44,6 +57,7 @@ type AlarmAPIService interface {
 	Remove(ctx context.Context, deviceID string, alarmType string) error
 	Stale(ctx context.Context) (types.Collection[types.Device], error)
 	Alarms(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error)
+	Handle(ctx context.Context, status types.StatusMessage) error
 }
 
 type Config struct {
@@ -73,11 +87,44 @@ func (svc *svc) Add(ctx context.Context, deviceID string, alarm types.AlarmDetai
 		alarm.ObservedAt = time.Now().UTC()
 	}
 
-	return svc.storage.Add(ctx, deviceID, alarm)
+	err := svc.storage.Add(ctx, deviceID, alarm)
+	if errors.Is(err, ErrAlarmNotNewer) {
+		rejectedStatus.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", "duplicate")))
+		log.Warn("rejected alarm", "device_id", deviceID, "reason", "duplicate", "alarm_type", alarmType, "observed_at", alarm.ObservedAt)
+		return nil
+	}
+
+	return err
 }
 
 func (svc *svc) Stale(ctx context.Context) (types.Collection[types.Device], error) {
-	return svc.storage.Stale(ctx)
+	result, err := svc.storage.Stale(ctx)
+	if err != nil {
+		return types.Collection[types.Device]{}, err
+	}
+
+	now := time.Now().UTC()
+	stale := make([]types.Device, 0, len(result.Data))
+
+	for _, d := range result.Data {
+		// only silence within the reporting calendar counts towards the interval
+		if d.Calendar != nil {
+			silence := d.Calendar.ExpectedDuration(d.DeviceState.ObservedAt, now)
+			if silence <= time.Duration(d.Interval)*time.Second {
+				continue
+			}
+		}
+
+		stale = append(stale, d)
+	}
+
+	return types.Collection[types.Device]{
+		Data:       stale,
+		Count:      uint64(len(stale)),
+		TotalCount: uint64(len(stale)),
+		Offset:     0,
+		Limit:      uint64(len(stale)),
+	}, nil
 }
 
 func (svc *svc) Remove(ctx context.Context, deviceID string, alarmType string) error {
@@ -92,6 +139,49 @@ func (svc *svc) Alarms(ctx context.Context, query alarmquery.Alarms) (types.Coll
 	return svc.storage.Alarms(ctx, query)
 }
 
+// Handle adds or removes alarms for a device status message. Messages older than
+// the current device state are rejected so that replayed messages can not re-add alarms.
+func (svc *svc) Handle(ctx context.Context, m types.StatusMessage) error {
+	log := logging.GetFromContext(ctx)
+
+	observedAt, err := svc.storage.DeviceObservedAt(ctx, m.DeviceID)
+	if err != nil {
+		return err
+	}
+
+	// the device status handler may already have stored this message, so only
+	// older messages are rejected here. Duplicated alarms are rejected by Add.
+	if m.Timestamp.Before(observedAt) {
+		rejectedStatus.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", "out_of_order")))
+		log.Warn("rejected device status", "device_id", m.DeviceID, "reason", "out_of_order", "timestamp", m.Timestamp, "observed_at", observedAt)
+		return nil
+	}
+
+	if m.Code == nil && len(m.Messages) == 0 {
+		log.Debug("received device status with no error code, will remove any device not observed alarms", "device_id", m.DeviceID)
+		return svc.Remove(ctx, m.DeviceID, AlarmDeviceNotObserved)
+	}
+
+	if m.Code != nil && *m.Code != "" {
+		err = svc.Add(ctx, m.DeviceID, types.AlarmDetails{
+			DeviceID:    m.DeviceID,
+			AlarmType:   *m.Code,
+			Description: strings.Join(m.Messages, ", "),
+			ObservedAt:  m.Timestamp,
+		})
+	} else if len(m.Messages) > 0 {
+		for _, msg := range m.Messages {
+			err = svc.Add(ctx, m.DeviceID, types.AlarmDetails{
+				DeviceID:   m.DeviceID,
+				AlarmType:  msg,
+				ObservedAt: m.Timestamp,
+			})
+		}
+	}
+
+	return err
+}
+
 func New(s AlarmStorage, m messaging.MsgContext, cfg *Config) AlarmAPIService {
 	svc := &svc{
 		storage:   s,
@@ -125,33 +215,7 @@ func newDeviceStatusHandler(svc AlarmAPIService) messaging.TopicMessageHandler {
 			return
 		}
 
-		if m.Code == nil && len(m.Messages) == 0 {
-			log.Debug("received device status with no error code, will remove any device not observed alarms", "device_id", m.DeviceID)
-			err = svc.Remove(ctx, m.DeviceID, AlarmDeviceNotObserved)
-			if err != nil {
-				log.Debug("could not remove device not observed alarms", "device_id", m.DeviceID, "handler", "Alarms.DeviceStatusHandler", "err", err.Error())
-				return
-			}
-			return
-		}
-
-		if m.Code != nil && *m.Code != "" {
-			err = svc.Add(ctx, m.DeviceID, types.AlarmDetails{
-				DeviceID:    m.DeviceID,
-				AlarmType:   *m.Code,
-				Description: strings.Join(m.Messages, ", "),
-				ObservedAt:  m.Timestamp,
-			})
-		} else if len(m.Messages) > 0 {
-			for _, msg := range m.Messages {
-				err = svc.Add(ctx, m.DeviceID, types.AlarmDetails{
-					DeviceID:   m.DeviceID,
-					AlarmType:  msg,
-					ObservedAt: m.Timestamp,
-				})
-			}
-		}
-
+		err = svc.Handle(ctx, m)
 		if err != nil {
 			log.Error("could not add or update alarm for device", "device_id", m.DeviceID, "handler", "Alarms.DeviceStatusHandler", "err", err.Error())
 		}
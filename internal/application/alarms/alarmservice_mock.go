@@ -26,6 +26,9 @@ var _ AlarmAPIService = &AlarmAPIServiceMock{}
 //			AlarmsFunc: func(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error) {
 //				panic("mock out the Alarms method")
 //			},
+//			HandleFunc: func(ctx context.Context, status types.StatusMessage) error {
+//				panic("mock out the Handle method")
+//			},
 //			RemoveFunc: func(ctx context.Context, deviceID string, alarmType string) error {
 //				panic("mock out the Remove method")
 //			},
@@ -45,6 +48,9 @@ type AlarmAPIServiceMock struct {
 	// AlarmsFunc mocks the Alarms method.
 	AlarmsFunc func(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error)
 
+	// HandleFunc mocks the Handle method.
+	HandleFunc func(ctx context.Context, status types.StatusMessage) error
+
 	// RemoveFunc mocks the Remove method.
 	RemoveFunc func(ctx context.Context, deviceID string, alarmType string) error
 
@@ -69,6 +75,13 @@ type AlarmAPIServiceMock struct {
 			// Query is the query argument value.
 			Query alarmquery.Alarms
 		}
+		// Handle holds details about calls to the Handle method.
+		Handle []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Status is the status argument value.
+			Status types.StatusMessage
+		}
 		// Remove holds details about calls to the Remove method.
 		Remove []struct {
 			// Ctx is the ctx argument value.
@@ -86,6 +99,7 @@ type AlarmAPIServiceMock struct {
 	}
 	lockAdd    sync.RWMutex
 	lockAlarms sync.RWMutex
+	lockHandle sync.RWMutex
 	lockRemove sync.RWMutex
 	lockStale  sync.RWMutex
 }
@@ -166,6 +180,42 @@ func (mock *AlarmAPIServiceMock) AlarmsCalls() []struct {
 	return calls
 }
 
+// Handle calls HandleFunc.
+func (mock *AlarmAPIServiceMock) Handle(ctx context.Context, status types.StatusMessage) error {
+	if mock.HandleFunc == nil {
+		panic("AlarmAPIServiceMock.HandleFunc: method is nil but AlarmAPIService.Handle was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Status types.StatusMessage
+	}{
+		Ctx:    ctx,
+		Status: status,
+	}
+	mock.lockHandle.Lock()
+	mock.calls.Handle = append(mock.calls.Handle, callInfo)
+	mock.lockHandle.Unlock()
+	return mock.HandleFunc(ctx, status)
+}
+
+// HandleCalls gets all the calls that were made to Handle.
+// Check the length with:
+//
+//	len(mockedAlarmAPIService.HandleCalls())
+func (mock *AlarmAPIServiceMock) HandleCalls() []struct {
+	Ctx    context.Context
+	Status types.StatusMessage
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Status types.StatusMessage
+	}
+	mock.lockHandle.RLock()
+	calls = mock.calls.Handle
+	mock.lockHandle.RUnlock()
+	return calls
+}
+
 // Remove calls RemoveFunc.
 func (mock *AlarmAPIServiceMock) Remove(ctx context.Context, deviceID string, alarmType string) error {
 	if mock.RemoveFunc == nil {
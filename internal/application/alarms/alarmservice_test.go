@@ -22,6 +22,9 @@ func TestDeviceStatusHandler(t *testing.T) {
 		AddFunc: func(ctx context.Context, deviceID string, a types.AlarmDetails) error {
 			return nil
 		},
+		DeviceObservedAtFunc: func(ctx context.Context, deviceID string) (time.Time, error) {
+			return time.Time{}, nil
+		},
 	}
 	m := &messaging.MsgContextMock{}
 
@@ -63,6 +66,9 @@ func TestDeviceStatusHandlerWithMessages(t *testing.T) {
 		AddFunc: func(ctx context.Context, deviceID string, a types.AlarmDetails) error {
 			return nil
 		},
+		DeviceObservedAtFunc: func(ctx context.Context, deviceID string) (time.Time, error) {
+			return time.Time{}, nil
+		},
 	}
 	m := &messaging.MsgContextMock{}
 
@@ -100,6 +106,83 @@ func TestDeviceStatusHandlerWithMessages(t *testing.T) {
 	is.Equal("message2", s.AddCalls()[1].A.AlarmType)
 }
 
+func TestDeviceStatusHandlerRejectsOutOfOrderMessages(t *testing.T) {
+	is := is.New(t)
+	log := slog.Default()
+	ctx := context.Background()
+
+	observedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s := &AlarmStorageMock{
+		AddFunc: func(ctx context.Context, deviceID string, a types.AlarmDetails) error {
+			return nil
+		},
+		RemoveFunc: func(ctx context.Context, deviceID string, alarmType string) error {
+			return nil
+		},
+		DeviceObservedAtFunc: func(ctx context.Context, deviceID string) (time.Time, error) {
+			return observedAt, nil
+		},
+	}
+
+	svc := New(s, &messaging.MsgContextMock{}, &Config{
+		AlarmTypes: []types.AlarmType{
+			{
+				Name:    "leak",
+				Enabled: true,
+			},
+		},
+	})
+
+	message := func(code *string, ts time.Time) messaging.IncomingTopicMessage {
+		return &messaging.IncomingTopicMessageMock{
+			BodyFunc: func() []byte {
+				b, _ := json.Marshal(types.StatusMessage{DeviceID: "device", Code: code, Timestamp: ts})
+				return b
+			},
+		}
+	}
+
+	leak := "leak"
+	handler := newDeviceStatusHandler(svc)
+
+	handler(ctx, message(&leak, observedAt.Add(-time.Hour)), log)
+	handler(ctx, message(nil, observedAt.Add(-time.Hour)), log)
+	is.Equal(0, len(s.AddCalls()))
+	is.Equal(0, len(s.RemoveCalls()))
+
+	handler(ctx, message(&leak, observedAt), log)
+	is.Equal(1, len(s.AddCalls()))
+}
+
+func TestDeviceStatusHandlerRejectsRepeatedAlarms(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	s := &AlarmStorageMock{
+		AddFunc: func(ctx context.Context, deviceID string, a types.AlarmDetails) error {
+			return ErrAlarmNotNewer
+		},
+		DeviceObservedAtFunc: func(ctx context.Context, deviceID string) (time.Time, error) {
+			return time.Time{}, nil
+		},
+	}
+
+	svc := New(s, &messaging.MsgContextMock{}, &Config{
+		AlarmTypes: []types.AlarmType{
+			{
+				Name:    "leak",
+				Enabled: true,
+			},
+		},
+	})
+
+	leak := "leak"
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "device", Code: &leak, Timestamp: time.Now().UTC()})
+	is.NoErr(err)
+	is.Equal(1, len(s.AddCalls()))
+}
+
 func TestAlarmsQuery(t *testing.T) {
 	is := is.New(t)
 	ctx := context.Background()
@@ -125,3 +208,37 @@ func TestAlarmsQuery(t *testing.T) {
 	is.Equal("battery_low", storage.AlarmsCalls()[0].Query.AlarmType)
 	is.Equal([]string{"tenant-a"}, storage.AlarmsCalls()[0].Query.AllowedTenants)
 }
+
+func TestStaleOnlyCountsSilenceWithinReportingCalendar(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	lastObserved := time.Now().UTC().Add(-3 * time.Hour)
+
+	s := &AlarmStorageMock{
+		StaleFunc: func(ctx context.Context) (types.Collection[types.Device], error) {
+			return types.Collection[types.Device]{
+				Data: []types.Device{
+					{DeviceID: "always", Interval: 3600, DeviceState: types.DeviceState{ObservedAt: lastObserved}},
+					{DeviceID: "in-season", Interval: 3600, Calendar: &types.ReportingCalendar{Timezone: "UTC"}, DeviceState: types.DeviceState{ObservedAt: lastObserved}},
+					{DeviceID: "off-season", Interval: 3600, Calendar: &types.ReportingCalendar{Seasons: []types.DateRange{{From: offSeason(), To: offSeason()}}}, DeviceState: types.DeviceState{ObservedAt: lastObserved}},
+				},
+				Count:      3,
+				TotalCount: 3,
+			}, nil
+		},
+	}
+
+	svc := New(s, &messaging.MsgContextMock{}, &Config{})
+
+	result, err := svc.Stale(ctx)
+	is.NoErr(err)
+	is.Equal(result.TotalCount, uint64(2))
+	is.Equal(result.Data[0].DeviceID, "always")
+	is.Equal(result.Data[1].DeviceID, "in-season")
+}
+
+// offSeason returns a date far from today so that a season starting and ending on it is never active
+func offSeason() string {
+	return time.Now().AddDate(0, 6, 0).Format("01-02")
+}
@@ -8,6 +8,7 @@ import (
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"sync"
+	"time"
 )
 
 // Ensure, that AlarmStorageMock does implement AlarmStorage.
@@ -26,6 +27,9 @@ var _ AlarmStorage = &AlarmStorageMock{}
 //			AlarmsFunc: func(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error) {
 //				panic("mock out the Alarms method")
 //			},
+//			DeviceObservedAtFunc: func(ctx context.Context, deviceID string) (time.Time, error) {
+//				panic("mock out the DeviceObservedAt method")
+//			},
 //			RemoveFunc: func(ctx context.Context, deviceID string, alarmType string) error {
 //				panic("mock out the Remove method")
 //			},
@@ -45,6 +49,9 @@ type AlarmStorageMock struct {
 	// AlarmsFunc mocks the Alarms method.
 	AlarmsFunc func(ctx context.Context, query alarmquery.Alarms) (types.Collection[types.Alarms], error)
 
+	// DeviceObservedAtFunc mocks the DeviceObservedAt method.
+	DeviceObservedAtFunc func(ctx context.Context, deviceID string) (time.Time, error)
+
 	// RemoveFunc mocks the Remove method.
 	RemoveFunc func(ctx context.Context, deviceID string, alarmType string) error
 
@@ -69,6 +76,13 @@ type AlarmStorageMock struct {
 			// Query is the query argument value.
 			Query alarmquery.Alarms
 		}
+		// DeviceObservedAt holds details about calls to the DeviceObservedAt method.
+		DeviceObservedAt []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+		}
 		// Remove holds details about calls to the Remove method.
 		Remove []struct {
 			// Ctx is the ctx argument value.
@@ -84,10 +98,11 @@ type AlarmStorageMock struct {
 			Ctx context.Context
 		}
 	}
-	lockAdd    sync.RWMutex
-	lockAlarms sync.RWMutex
-	lockRemove sync.RWMutex
-	lockStale  sync.RWMutex
+	lockAdd              sync.RWMutex
+	lockAlarms           sync.RWMutex
+	lockDeviceObservedAt sync.RWMutex
+	lockRemove           sync.RWMutex
+	lockStale            sync.RWMutex
 }
 
 // Add calls AddFunc.
@@ -166,6 +181,42 @@ func (mock *AlarmStorageMock) AlarmsCalls() []struct {
 	return calls
 }
 
+// DeviceObservedAt calls DeviceObservedAtFunc.
+func (mock *AlarmStorageMock) DeviceObservedAt(ctx context.Context, deviceID string) (time.Time, error) {
+	if mock.DeviceObservedAtFunc == nil {
+		panic("AlarmStorageMock.DeviceObservedAtFunc: method is nil but AlarmStorage.DeviceObservedAt was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		DeviceID string
+	}{
+		Ctx:      ctx,
+		DeviceID: deviceID,
+	}
+	mock.lockDeviceObservedAt.Lock()
+	mock.calls.DeviceObservedAt = append(mock.calls.DeviceObservedAt, callInfo)
+	mock.lockDeviceObservedAt.Unlock()
+	return mock.DeviceObservedAtFunc(ctx, deviceID)
+}
+
+// DeviceObservedAtCalls gets all the calls that were made to DeviceObservedAt.
+// Check the length with:
+//
+//	len(mockedAlarmStorage.DeviceObservedAtCalls())
+func (mock *AlarmStorageMock) DeviceObservedAtCalls() []struct {
+	Ctx      context.Context
+	DeviceID string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		DeviceID string
+	}
+	mock.lockDeviceObservedAt.RLock()
+	calls = mock.calls.DeviceObservedAt
+	mock.lockDeviceObservedAt.RUnlock()
+	return calls
+}
+
 // Remove calls RemoveFunc.
 func (mock *AlarmStorageMock) Remove(ctx context.Context, deviceID string, alarmType string) error {
 	if mock.RemoveFunc == nil {
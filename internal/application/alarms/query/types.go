@@ -1,9 +1,13 @@
 package query
 
+import "github.com/diwise/iot-device-mgmt/pkg/types"
+
 type Alarms struct {
 	AlarmType      string
 	AllowedTenants []string
 	ActiveOnly     bool
 	Offset         *int
 	Limit          *int
+	// Cursor pages by the time of the latest alarm and device id instead of by offset.
+	Cursor *types.Cursor
 }
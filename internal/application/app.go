@@ -13,33 +13,50 @@ import (
 
 	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	"github.com/diwise/iot-device-mgmt/internal/application/search"
 	"github.com/diwise/iot-device-mgmt/internal/application/sensors"
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/google/uuid"
 )
 
 type Management interface {
 	DeviceService() devices.DeviceAPIService
 	SensorService() sensors.SensorAPIService
 	AlarmService() alarms.AlarmAPIService
+	QuarantineService() quarantine.QuarantineService
+	ViewService() views.ViewService
+	SearchService() search.SearchService
 
 	SeedLwm2mTypes(ctx context.Context, lwm2m []types.Lwm2mType) error
 	SeedSensorProfiles(ctx context.Context, profiles []types.SensorProfile) error
 	SeedSensorsAndDevices(ctx context.Context, input io.ReadCloser, validTenants []string) error
+
+	PromoteSensor(ctx context.Context, sensorID string, device types.Device, tenants []string) (types.Device, error)
 }
 
+var ErrTenantNotAllowed = errors.New("tenant not allowed")
+
 type app struct {
 	devices      devices.DeviceAPIService
 	sensors      sensors.SensorAPIService
 	alarms       alarms.AlarmAPIService
+	quarantine   quarantine.QuarantineService
+	views        views.ViewService
+	search       search.SearchService
 	shouldUpdate bool
 }
 
-func New(devices devices.DeviceAPIService, sensors sensors.SensorAPIService, alarms alarms.AlarmAPIService, shouldUpdate bool) Management {
+func New(devices devices.DeviceAPIService, sensors sensors.SensorAPIService, alarms alarms.AlarmAPIService, quarantine quarantine.QuarantineService, views views.ViewService, search search.SearchService, shouldUpdate bool) Management {
 	return &app{
 		devices:      devices,
 		sensors:      sensors,
 		alarms:       alarms,
+		quarantine:   quarantine,
+		views:        views,
+		search:       search,
 		shouldUpdate: shouldUpdate,
 	}
 }
@@ -56,6 +73,18 @@ func (a *app) AlarmService() alarms.AlarmAPIService {
 	return a.alarms
 }
 
+func (a *app) QuarantineService() quarantine.QuarantineService {
+	return a.quarantine
+}
+
+func (a *app) ViewService() views.ViewService {
+	return a.views
+}
+
+func (a *app) SearchService() search.SearchService {
+	return a.search
+}
+
 func (a *app) SeedLwm2mTypes(ctx context.Context, lwm2m []types.Lwm2mType) error {
 	return a.devices.SeedLwm2mTypes(ctx, lwm2m)
 }
@@ -69,6 +98,8 @@ func (a *app) SeedSensorsAndDevices(ctx context.Context, input io.ReadCloser, va
 	log := logging.GetFromContext(ctx)
 	defer input.Close()
 
+	ctx = devices.WithLocationSource(ctx, types.LocationSourceImport)
+
 	r := csv.NewReader(input)
 	r.Comma = ';'
 
@@ -148,6 +179,68 @@ func (a *app) SeedSensorsAndDevices(ctx context.Context, input io.ReadCloser, va
 	return nil
 }
 
+// PromoteSensor creates a device for a sensor, typically one discovered from status
+// traffic, using the sensor's tenant, name, location and profile unless given in device.
+func (a *app) PromoteSensor(ctx context.Context, sensorID string, device types.Device, tenants []string) (types.Device, error) {
+	sensor, err := a.sensors.Sensor(ctx, sensorID)
+	if err != nil {
+		return types.Device{}, err
+	}
+
+	if sensor.DeviceID != nil {
+		return types.Device{}, devices.ErrSensorAlreadyAssigned
+	}
+
+	if device.Tenant == "" && sensor.Tenant != nil {
+		device.Tenant = *sensor.Tenant
+	}
+
+	if !slices.Contains(tenants, device.Tenant) {
+		return types.Device{}, ErrTenantNotAllowed
+	}
+
+	if strings.TrimSpace(device.DeviceID) == "" {
+		device.DeviceID = uuid.NewString()
+	}
+
+	if device.Name == "" {
+		device.Name = sensorID
+		if sensor.Name != nil {
+			device.Name = *sensor.Name
+		}
+	}
+
+	if device.Location == (types.Location{}) && sensor.Location != nil {
+		device.Location = *sensor.Location
+	}
+
+	if device.SensorProfile.Decoder == "" {
+		if sensor.SensorProfile != nil {
+			device.SensorProfile = *sensor.SensorProfile
+		}
+	} else if sensor.SensorProfile == nil || !strings.EqualFold(sensor.SensorProfile.Decoder, device.SensorProfile.Decoder) {
+		err = a.sensors.Update(ctx, types.Sensor{
+			SensorID:      sensorID,
+			SensorProfile: &device.SensorProfile,
+			Name:          sensor.Name,
+			Location:      sensor.Location,
+		})
+		if err != nil {
+			return types.Device{}, err
+		}
+	}
+
+	device.SensorID = sensorID
+	device.DeviceState = types.DeviceState{State: types.DeviceStateUnknown}
+
+	err = a.devices.Create(ctx, device)
+	if err != nil {
+		return types.Device{}, err
+	}
+
+	return device, nil
+}
+
 func (a *app) existingSensor(ctx context.Context, sensorID string) (bool, error) {
 	_, err := a.sensors.Sensor(ctx, sensorID)
 	if err != nil {
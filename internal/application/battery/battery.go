@@ -0,0 +1,196 @@
+package battery
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/periodic"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+const (
+	DefaultInterval    = 360
+	DefaultThreshold   = 10
+	DefaultWindow      = 365
+	DefaultReplacement = 20
+
+	// MinSamples is the least number of daily readings, since the latest battery
+	// replacement, needed to fit a trend.
+	MinSamples = 3
+)
+
+// Config holds how often, in minutes, battery forecasts are computed, the battery level
+// a forecast predicts, how many days of history are used and how large an increase
+// of the battery level is taken as a replaced battery.
+type Config struct {
+	Interval    int     `yaml:"interval"`
+	Threshold   float64 `yaml:"threshold"`
+	Window      int     `yaml:"window"`
+	Replacement float64 `yaml:"replacement"`
+}
+
+// Reading is the average battery level of a sensor during a day.
+type Reading struct {
+	ObservedAt time.Time
+	Level      float64
+}
+
+type BatteryStorage interface {
+	GetBatteryLevels(ctx context.Context, since time.Time) (map[string][]Reading, error)
+	SetBatteryForecasts(ctx context.Context, forecasts []types.BatteryForecast) error
+}
+
+//go:generate moq -rm -out batterystorage_mock.go . BatteryStorage
+
+type Forecaster interface {
+	Start(context.Context)
+	Stop(context.Context)
+	Run(context.Context) error
+}
+
+type forecaster struct {
+	storage     BatteryStorage
+	interval    time.Duration
+	threshold   float64
+	window      int
+	replacement float64
+
+	job *periodic.Job
+}
+
+func New(s BatteryStorage, cfg *Config) Forecaster {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	f := &forecaster{
+		storage:     s,
+		interval:    time.Duration(DefaultInterval) * time.Minute,
+		threshold:   DefaultThreshold,
+		window:      DefaultWindow,
+		replacement: DefaultReplacement,
+	}
+
+	if cfg.Interval > 0 {
+		f.interval = time.Duration(cfg.Interval) * time.Minute
+	}
+	if cfg.Threshold > 0 {
+		f.threshold = cfg.Threshold
+	}
+	if cfg.Window > 0 {
+		f.window = cfg.Window
+	}
+	if cfg.Replacement > 0 {
+		f.replacement = cfg.Replacement
+	}
+
+	f.job = periodic.New("battery forecast", f.interval, f.Run)
+
+	return f
+}
+
+func (f *forecaster) Start(ctx context.Context) {
+	f.job.Start(ctx)
+}
+
+func (f *forecaster) Stop(ctx context.Context) {
+	f.job.Stop(ctx)
+}
+
+// Run fits the battery trend of every sensor with battery readings within the window
+// and replaces the stored forecasts.
+func (f *forecaster) Run(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	levels, err := f.storage.GetBatteryLevels(ctx, now.AddDate(0, 0, -f.window))
+	if err != nil {
+		return err
+	}
+
+	forecasts := make([]types.BatteryForecast, 0, len(levels))
+	for sensorID, readings := range levels {
+		forecast, ok := Fit(readings, f.threshold, f.replacement)
+		if !ok {
+			continue
+		}
+		forecast.SensorID = sensorID
+		forecast.ComputedAt = now
+		forecasts = append(forecasts, forecast)
+	}
+
+	logging.GetFromContext(ctx).Debug("forecasted battery depletion", "sensors", len(levels), "forecasts", len(forecasts))
+
+	return f.storage.SetBatteryForecasts(ctx, forecasts)
+}
+
+// Fit fits a line to the battery readings since the latest replacement, i.e. the latest
+// increase of at least replacement between two readings, and predicts when the line
+// reaches threshold. Batteries that are not depleting get no depletion time.
+func Fit(readings []Reading, threshold, replacement float64) (types.BatteryForecast, bool) {
+	readings = slices.SortedFunc(slices.Values(readings), func(a, b Reading) int {
+		return a.ObservedAt.Compare(b.ObservedAt)
+	})
+
+	var replacedAt *time.Time
+	for i := len(readings) - 1; i > 0; i-- {
+		if readings[i].Level-readings[i-1].Level >= replacement {
+			ts := readings[i].ObservedAt
+			replacedAt = &ts
+			readings = readings[i:]
+			break
+		}
+	}
+
+	if len(readings) < MinSamples {
+		return types.BatteryForecast{}, false
+	}
+
+	first := readings[0].ObservedAt
+	last := readings[len(readings)-1].ObservedAt
+
+	days := func(t time.Time) float64 {
+		return t.Sub(first).Hours() / 24
+	}
+
+	n := float64(len(readings))
+	var sumX, sumY, sumXY, sumXX float64
+	for _, r := range readings {
+		x := days(r.ObservedAt)
+		sumX += x
+		sumY += r.Level
+		sumXY += x * r.Level
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return types.BatteryForecast{}, false
+	}
+
+	rate := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - rate*sumX) / n
+	level := intercept + rate*days(last)
+
+	forecast := types.BatteryForecast{
+		Level:      round(level),
+		Rate:       round(rate),
+		Threshold:  threshold,
+		ReplacedAt: replacedAt,
+		Samples:    len(readings),
+	}
+
+	if rate < 0 {
+		remaining := (threshold - level) / rate
+		depletesAt := last.Add(time.Duration(remaining * 24 * float64(time.Hour))).UTC()
+		forecast.DepletesAt = &depletesAt
+	}
+
+	return forecast, true
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
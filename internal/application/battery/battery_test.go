@@ -0,0 +1,102 @@
+package battery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestFitPredictsDepletion(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// one percent per day, from 80 to 71
+	readings := []Reading{}
+	for i := range 10 {
+		readings = append(readings, Reading{ObservedAt: start.AddDate(0, 0, 9-i), Level: float64(71 + i)})
+	}
+
+	forecast, ok := Fit(readings, 10, 20)
+	is.True(ok)
+	is.Equal(forecast.Rate, -1.0)
+	is.Equal(forecast.Level, 71.0)
+	is.Equal(forecast.Samples, 10)
+	is.True(forecast.ReplacedAt == nil)
+	is.Equal(*forecast.DepletesAt, start.AddDate(0, 0, 9+61))
+}
+
+func TestFitStartsOverAfterReplacement(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	readings := []Reading{
+		{ObservedAt: start, Level: 30},
+		{ObservedAt: start.AddDate(0, 0, 1), Level: 20},
+		{ObservedAt: start.AddDate(0, 0, 2), Level: 100},
+		{ObservedAt: start.AddDate(0, 0, 3), Level: 98},
+		{ObservedAt: start.AddDate(0, 0, 4), Level: 96},
+	}
+
+	forecast, ok := Fit(readings, 10, 20)
+	is.True(ok)
+	is.Equal(*forecast.ReplacedAt, start.AddDate(0, 0, 2))
+	is.Equal(forecast.Samples, 3)
+	is.Equal(forecast.Rate, -2.0)
+	is.Equal(*forecast.DepletesAt, start.AddDate(0, 0, 4+43))
+
+	_, ok = Fit(readings[:4], 10, 20)
+	is.True(!ok) // too few readings since the replacement
+}
+
+func TestFitWithoutDepletion(t *testing.T) {
+	is := is.New(t)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	forecast, ok := Fit([]Reading{
+		{ObservedAt: start, Level: 90},
+		{ObservedAt: start.AddDate(0, 0, 1), Level: 90},
+		{ObservedAt: start.AddDate(0, 0, 2), Level: 90},
+	}, 10, 20)
+	is.True(ok)
+	is.Equal(forecast.Rate, 0.0)
+	is.True(forecast.DepletesAt == nil)
+}
+
+func TestRunStoresForecasts(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	s := &BatteryStorageMock{
+		GetBatteryLevelsFunc: func(ctx context.Context, since time.Time) (map[string][]Reading, error) {
+			return map[string][]Reading{
+				"sensor-1": {
+					{ObservedAt: now.AddDate(0, 0, -2), Level: 50},
+					{ObservedAt: now.AddDate(0, 0, -1), Level: 49},
+					{ObservedAt: now, Level: 48},
+				},
+				"sensor-2": {
+					{ObservedAt: now, Level: 48},
+				},
+			}, nil
+		},
+		SetBatteryForecastsFunc: func(ctx context.Context, forecasts []types.BatteryForecast) error {
+			return nil
+		},
+	}
+
+	err := New(s, &Config{Threshold: 20, Window: 30}).Run(ctx)
+	is.NoErr(err)
+
+	since := s.GetBatteryLevelsCalls()[0].Since
+	is.True(since.Before(now.AddDate(0, 0, -29)) && since.After(now.AddDate(0, 0, -31)))
+
+	forecasts := s.SetBatteryForecastsCalls()[0].Forecasts
+	is.Equal(len(forecasts), 1)
+	is.Equal(forecasts[0].SensorID, "sensor-1")
+	is.Equal(forecasts[0].Threshold, 20.0)
+	is.True(forecasts[0].DepletesAt.Sub(now.AddDate(0, 0, 28)).Abs() < time.Minute)
+}
@@ -0,0 +1,175 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+)
+
+// MaxAvailabilityDays is the longest period, in days, that availability is computed for.
+const MaxAvailabilityDays = 31
+
+var errInvalidAvailability = fmt.Errorf("availability requires from before to, at most %d days apart, and a gap of at least one interval", MaxAvailabilityDays)
+
+func (s service) Availability(ctx context.Context, deviceID string, query dmquery.AvailabilityFilters) (types.Availability, error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Availability{}, ErrMissingTenant
+	}
+
+	if err := validateAvailability(query); err != nil {
+		return types.Availability{}, err
+	}
+
+	device, err := s.Device(ctx, deviceID, query.AllowedTenants)
+	if err != nil {
+		return types.Availability{}, err
+	}
+
+	timestamps, err := s.reader.GetStatusTimestamps(ctx, sensorIDs([]types.Device{device}), query.From, query.To)
+	if err != nil {
+		return types.Availability{}, err
+	}
+
+	return computeAvailability(device, timestamps[device.SensorID], query.From, query.To, query.Gap), nil
+}
+
+// AvailabilityReport computes the availability of each device matching the filters.
+func (s service) AvailabilityReport(ctx context.Context, query dmquery.AvailabilityFilters) (types.Collection[types.Availability], error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.Availability]{}, ErrMissingTenant
+	}
+
+	if err := validateAvailability(query); err != nil {
+		return types.Collection[types.Availability]{}, err
+	}
+
+	devices, err := s.reader.Query(ctx, dmquery.DeviceFilters{Filters: query.Filters})
+	if err != nil {
+		return types.Collection[types.Availability]{}, err
+	}
+
+	timestamps, err := s.reader.GetStatusTimestamps(ctx, sensorIDs(devices.Data), query.From, query.To)
+	if err != nil {
+		return types.Collection[types.Availability]{}, err
+	}
+
+	result := make([]types.Availability, 0, len(devices.Data))
+	for _, d := range devices.Data {
+		result = append(result, computeAvailability(d, timestamps[d.SensorID], query.From, query.To, query.Gap))
+	}
+
+	return types.Collection[types.Availability]{
+		Data:       result,
+		Count:      devices.Count,
+		TotalCount: devices.TotalCount,
+		Offset:     devices.Offset,
+		Limit:      devices.Limit,
+	}, nil
+}
+
+func validateAvailability(query dmquery.AvailabilityFilters) error {
+	if !query.To.After(query.From) || query.To.Sub(query.From) > MaxAvailabilityDays*24*time.Hour || query.Gap < 1 {
+		return ErrInvalidAvailability
+	}
+	return nil
+}
+
+func sensorIDs(devices []types.Device) []string {
+	ids := []string{}
+	for _, d := range devices {
+		if d.SensorID != "" {
+			ids = append(ids, d.SensorID)
+		}
+	}
+	return ids
+}
+
+// computeAvailability counts the expected and received messages of a device per UTC day, and
+// lists the periods without messages that are longer than gap intervals. Only time within the
+// reporting calendar of the device, or of its profile, is expected to have messages.
+func computeAvailability(device types.Device, timestamps []time.Time, from, to time.Time, gap int) types.Availability {
+	from, to = from.UTC(), to.UTC()
+
+	a := types.Availability{
+		DeviceID: device.DeviceID,
+		Tenant:   device.Tenant,
+		Interval: device.SensorProfile.Interval,
+		From:     from,
+		To:       to,
+		Days:     []types.DailyAvailability{},
+		Gaps:     []types.Gap{},
+	}
+
+	calendar := device.Calendar
+	if calendar == nil {
+		calendar = device.SensorProfile.Calendar
+	}
+
+	expected := func(from, to time.Time) time.Duration {
+		if calendar != nil {
+			return calendar.ExpectedDuration(from, to)
+		}
+		return to.Sub(from)
+	}
+
+	interval := time.Duration(a.Interval) * time.Second
+
+	timestamps = slices.DeleteFunc(slices.Clone(timestamps), func(t time.Time) bool {
+		return t.Before(from) || !t.Before(to)
+	})
+	slices.SortFunc(timestamps, func(a, b time.Time) int { return a.Compare(b) })
+
+	for day := from.Truncate(24 * time.Hour); day.Before(to); day = day.AddDate(0, 0, 1) {
+		start, end := day, day.AddDate(0, 0, 1)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		d := types.DailyAvailability{Date: day}
+		if interval > 0 {
+			d.Expected = int(expected(start, end) / interval)
+		}
+		for _, t := range timestamps {
+			if !t.Before(start) && t.Before(end) {
+				d.Received++
+			}
+		}
+		d.DeliveryRatio = deliveryRatio(d.Expected, d.Received)
+
+		a.Expected += d.Expected
+		a.Received += d.Received
+		a.Days = append(a.Days, d)
+	}
+
+	a.DeliveryRatio = deliveryRatio(a.Expected, a.Received)
+
+	if interval == 0 {
+		return a
+	}
+
+	// the start and end of the period count as messages so that silence at the edges is reported
+	edges := append(append([]time.Time{from}, timestamps...), to)
+	for i := 1; i < len(edges); i++ {
+		silence := expected(edges[i-1], edges[i])
+		if silence > time.Duration(gap)*interval {
+			a.Gaps = append(a.Gaps, types.Gap{From: edges[i-1], To: edges[i], Intervals: int(silence / interval)})
+		}
+	}
+
+	return a
+}
+
+func deliveryRatio(expected, received int) *float64 {
+	if expected == 0 {
+		return nil
+	}
+	ratio := min(float64(received)/float64(expected), 1)
+	return &ratio
+}
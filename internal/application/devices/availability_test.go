@@ -0,0 +1,85 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestComputeAvailabilityPerDayAndGaps(t *testing.T) {
+	is := is.New(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	// hourly messages, except between 10:00 and 16:00 on the first day and all of the second
+	timestamps := []time.Time{}
+	for h := range 24 {
+		if h <= 10 || h >= 16 {
+			timestamps = append(timestamps, from.Add(time.Duration(h)*time.Hour))
+		}
+	}
+
+	device := types.Device{DeviceID: "device-1", SensorProfile: types.SensorProfile{Interval: 3600}}
+
+	a := computeAvailability(device, timestamps, from, to, 3)
+	is.Equal(a.Expected, 48)
+	is.Equal(a.Received, 19)
+	is.Equal(len(a.Days), 2)
+	is.Equal(a.Days[0].Received, 19)
+	is.Equal(*a.Days[1].DeliveryRatio, 0.0)
+
+	is.Equal(len(a.Gaps), 2)
+	is.Equal(a.Gaps[0], types.Gap{From: from.Add(10 * time.Hour), To: from.Add(16 * time.Hour), Intervals: 6})
+	is.Equal(a.Gaps[1], types.Gap{From: from.Add(23 * time.Hour), To: to, Intervals: 25})
+}
+
+func TestComputeAvailabilityWithinCalendar(t *testing.T) {
+	is := is.New(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	timestamps := []time.Time{}
+	for h := 6; h < 18; h++ {
+		timestamps = append(timestamps, from.Add(time.Duration(h)*time.Hour))
+	}
+
+	device := types.Device{
+		DeviceID: "device-1",
+		SensorProfile: types.SensorProfile{
+			Interval: 3600,
+			Calendar: &types.ReportingCalendar{Timezone: "UTC", Hours: &types.TimeOfDayRange{From: "06:00", To: "18:00"}},
+		},
+	}
+
+	a := computeAvailability(device, timestamps, from, to, 1)
+	is.Equal(a.Expected, 12)
+	is.Equal(*a.DeliveryRatio, 1.0)
+	is.Equal(len(a.Gaps), 0) // silence outside of the calendar is not a gap
+
+	device.Calendar = &types.ReportingCalendar{EventDriven: true}
+
+	a = computeAvailability(device, timestamps, from, to, 1)
+	is.Equal(a.Expected, 0)
+	is.True(a.DeliveryRatio == nil)
+}
+
+func TestAvailabilityRejectsInvalidPeriod(t *testing.T) {
+	is := is.New(t)
+
+	svc := New(&DeviceReaderMock{}, nil, nil, nil, nil, nil)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := svc.Availability(context.Background(), "device-1", dmquery.AvailabilityFilters{
+		Filters: dmquery.Filters{AllowedTenants: []string{"default"}},
+		From:    from,
+		To:      from.AddDate(0, 2, 0),
+		Gap:     3,
+	})
+	is.Equal(err, ErrInvalidAvailability)
+}
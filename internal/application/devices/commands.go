@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
@@ -17,6 +18,12 @@ var errSensorAlreadyAssigned = fmt.Errorf("sensor already assigned")
 var errSensorProfileRequired = fmt.Errorf("sensor profile required")
 
 func (s service) Create(ctx context.Context, device types.Device) error {
+	if device.Calendar != nil {
+		if err := device.Calendar.Validate(); err != nil {
+			return err
+		}
+	}
+
 	result, err := s.reader.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{DeviceID: device.DeviceID}})
 	if err != nil {
 		return err
@@ -38,6 +45,8 @@ func (s service) Create(ctx context.Context, device types.Device) error {
 		return err
 	}
 
+	s.recordLocation(ctx, device.DeviceID, device.Tenant, types.Location{}, device.Location, locationSource(ctx), time.Now())
+
 	if len(device.SensorProfile.Types) > 0 {
 		l := []types.Lwm2mType{}
 		for _, t := range device.SensorProfile.Types {
@@ -54,6 +63,12 @@ func (s service) Create(ctx context.Context, device types.Device) error {
 }
 
 func (s service) Update(ctx context.Context, device types.Device) error {
+	if device.Calendar != nil {
+		if err := device.Calendar.Validate(); err != nil {
+			return err
+		}
+	}
+
 	result, err := s.reader.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{DeviceID: device.DeviceID}})
 	if err != nil {
 		return err
@@ -75,6 +90,8 @@ func (s service) Update(ctx context.Context, device types.Device) error {
 		return err
 	}
 
+	s.recordLocation(ctx, device.DeviceID, device.Tenant, result.Data[0].Location, device.Location, locationSource(ctx), time.Now())
+
 	return nil
 }
 
@@ -187,6 +204,14 @@ func (s service) Merge(ctx context.Context, deviceID string, fields map[string]a
 		return err
 	}
 
+	if location != nil {
+		deviceTenant := result.Data[0].Tenant
+		if tenant != nil {
+			deviceTenant = *tenant
+		}
+		s.recordLocation(ctx, deviceID, deviceTenant, result.Data[0].Location, *location, locationSource(ctx), time.Now())
+	}
+
 	if len(lwm2m) > 0 {
 		l := []types.Lwm2mType{}
 		for _, t := range lwm2m {
@@ -246,5 +271,7 @@ func (s service) UpdateState(ctx context.Context, deviceID, tenant string, devic
 		return ErrDeviceNotFound
 	}
 
-	return s.statusWriter.SetDeviceState(ctx, deviceID, deviceState)
+	state := resolveState(result.Data[0].DeviceState, deviceState)
+
+	return s.statusWriter.SetDeviceState(ctx, deviceID, state)
 }
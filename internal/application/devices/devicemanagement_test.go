@@ -9,6 +9,7 @@ import (
 	"time"
 
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/messaging-golang/pkg/messaging"
 	"github.com/google/uuid"
@@ -35,6 +36,9 @@ func TestDeviceStatusHandler(t *testing.T) {
 		GetDeviceBySensorIDFunc: func(ctx context.Context, sensorID string) (types.Device, bool, error) {
 			return types.Device{}, false, nil
 		},
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return types.Device{}, false, nil
+		},
 	}
 	writer := &DeviceWriterMock{
 		CreateOrUpdateDeviceFunc: func(ctx context.Context, d types.Device) error {
@@ -103,8 +107,17 @@ func TestDeviceStatusHandler(t *testing.T) {
 	})
 	is.NoErr(err)
 
-	handler := newDeviceStatusHandler(svc)
+	q := &quarantine.QuarantineServiceMock{
+		AddFunc: func(ctx context.Context, body []byte, status types.StatusMessage, reason string, cause error) error {
+			return nil
+		},
+	}
+
+	handler := newDeviceStatusHandler(svc, q)
 	handler(ctx, statusMessage(sm), log)
+
+	is.Equal(len(q.AddCalls()), 1)
+	is.Equal(q.AddCalls()[0].Reason, quarantine.ReasonDeviceNotFound)
 }
 
 func TestCreateRequiresSensorProfileForAssignedSensor(t *testing.T) {
@@ -170,6 +183,33 @@ func TestDetachSensorCallsWriter(t *testing.T) {
 	is.True(called)
 }
 
+func TestHandleDiscoversSensorForAutoProvisionedTenant(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	reader := &DeviceReaderMock{
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return types.Device{}, false, nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		DiscoverSensorFunc: func(ctx context.Context, status types.StatusMessage) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, &DeviceWriterMock{}, statusWriter, &DeviceProfileStoreMock{}, nil, &Config{AutoProvision: []string{"default"}})
+
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "a81758fffe06bfa3", Tenant: "default", Timestamp: time.Now()})
+	is.NoErr(err)
+	is.Equal(len(statusWriter.DiscoverSensorCalls()), 1)
+	is.Equal(statusWriter.DiscoverSensorCalls()[0].Status.DeviceID, "a81758fffe06bfa3")
+
+	err = svc.Handle(ctx, types.StatusMessage{DeviceID: "a81758fffe06bfa4", Tenant: "other", Timestamp: time.Now()})
+	is.True(errors.Is(err, ErrDeviceNotFound))
+	is.Equal(len(statusWriter.DiscoverSensorCalls()), 1)
+}
+
 func statusMessage(s types.StatusMessage) messaging.IncomingTopicMessage {
 	return &messaging.IncomingTopicMessageMock{
 		BodyFunc: func() []byte {
@@ -6,6 +6,7 @@ package devices
 import (
 	"context"
 	"sync"
+	"time"
 
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
@@ -21,12 +22,42 @@ var _ DeviceReader = &DeviceReaderMock{}
 //
 //		// make and configure a mocked DeviceReader
 //		mockedDeviceReader := &DeviceReaderMock{
+//			GetBatteryForecastsFunc: func(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error) {
+//				panic("mock out the GetBatteryForecasts method")
+//			},
+//			GetCoverageFunc: func(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error) {
+//				panic("mock out the GetCoverage method")
+//			},
 //			GetDeviceAlarmsFunc: func(ctx context.Context, deviceID string) (types.Collection[types.AlarmDetails], error) {
 //				panic("mock out the GetDeviceAlarms method")
 //			},
 //			GetDeviceBySensorIDFunc: func(ctx context.Context, sensorID string) (types.Device, bool, error) {
 //				panic("mock out the GetDeviceBySensorID method")
 //			},
+//			GetDeviceClustersFunc: func(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+//				panic("mock out the GetDeviceClusters method")
+//			},
+//			GetDeviceHistoryFunc: func(ctx context.Context, tenant string, allowedTenants []string, from time.Time, to time.Time) ([]types.DeviceHistory, error) {
+//				panic("mock out the GetDeviceHistory method")
+//			},
+//			GetDeviceLocationsFunc: func(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error) {
+//				panic("mock out the GetDeviceLocations method")
+//			},
+//			GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+//				panic("mock out the GetDeviceState method")
+//			},
+//			GetDeviceStatesFunc: func(ctx context.Context, deviceIDs []string) (map[string]types.Device, error) {
+//				panic("mock out the GetDeviceStates method")
+//			},
+//			GetDeviceStatsFunc: func(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error) {
+//				panic("mock out the GetDeviceStats method")
+//			},
+//			GetDeviceStatusAggregatesFunc: func(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error) {
+//				panic("mock out the GetDeviceStatusAggregates method")
+//			},
+//			GetGeofencesFunc: func(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+//				panic("mock out the GetGeofences method")
+//			},
 //			GetSensorFunc: func(ctx context.Context, sensorID string) (types.Sensor, bool, error) {
 //				panic("mock out the GetSensor method")
 //			},
@@ -36,6 +67,9 @@ var _ DeviceReader = &DeviceReaderMock{}
 //			GetDeviceStatusFunc: func(ctx context.Context, deviceID string, query dmquery.Status) (types.Collection[types.SensorStatus], error) {
 //				panic("mock out the GetDeviceStatus method")
 //			},
+//			GetStatusTimestampsFunc: func(ctx context.Context, sensorIDs []string, from time.Time, to time.Time) (map[string][]time.Time, error) {
+//				panic("mock out the GetStatusTimestamps method")
+//			},
 //			GetTenantsFunc: func(ctx context.Context) (types.Collection[string], error) {
 //				panic("mock out the GetTenants method")
 //			},
@@ -49,12 +83,42 @@ var _ DeviceReader = &DeviceReaderMock{}
 //
 //	}
 type DeviceReaderMock struct {
+	// GetBatteryForecastsFunc mocks the GetBatteryForecasts method.
+	GetBatteryForecastsFunc func(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error)
+
+	// GetCoverageFunc mocks the GetCoverage method.
+	GetCoverageFunc func(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error)
+
 	// GetDeviceAlarmsFunc mocks the GetDeviceAlarms method.
 	GetDeviceAlarmsFunc func(ctx context.Context, deviceID string) (types.Collection[types.AlarmDetails], error)
 
 	// GetDeviceBySensorIDFunc mocks the GetDeviceBySensorID method.
 	GetDeviceBySensorIDFunc func(ctx context.Context, sensorID string) (types.Device, bool, error)
 
+	// GetDeviceClustersFunc mocks the GetDeviceClusters method.
+	GetDeviceClustersFunc func(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error)
+
+	// GetDeviceHistoryFunc mocks the GetDeviceHistory method.
+	GetDeviceHistoryFunc func(ctx context.Context, tenant string, allowedTenants []string, from time.Time, to time.Time) ([]types.DeviceHistory, error)
+
+	// GetDeviceLocationsFunc mocks the GetDeviceLocations method.
+	GetDeviceLocationsFunc func(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error)
+
+	// GetDeviceStateFunc mocks the GetDeviceState method.
+	GetDeviceStateFunc func(ctx context.Context, deviceID string) (types.Device, bool, error)
+
+	// GetDeviceStatesFunc mocks the GetDeviceStates method.
+	GetDeviceStatesFunc func(ctx context.Context, deviceIDs []string) (map[string]types.Device, error)
+
+	// GetDeviceStatsFunc mocks the GetDeviceStats method.
+	GetDeviceStatsFunc func(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error)
+
+	// GetDeviceStatusAggregatesFunc mocks the GetDeviceStatusAggregates method.
+	GetDeviceStatusAggregatesFunc func(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error)
+
+	// GetGeofencesFunc mocks the GetGeofences method.
+	GetGeofencesFunc func(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error)
+
 	// GetSensorFunc mocks the GetSensor method.
 	GetSensorFunc func(ctx context.Context, sensorID string) (types.Sensor, bool, error)
 
@@ -64,6 +128,9 @@ type DeviceReaderMock struct {
 	// GetDeviceStatusFunc mocks the GetDeviceStatus method.
 	GetDeviceStatusFunc func(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatus], error)
 
+	// GetStatusTimestampsFunc mocks the GetStatusTimestamps method.
+	GetStatusTimestampsFunc func(ctx context.Context, sensorIDs []string, from time.Time, to time.Time) (map[string][]time.Time, error)
+
 	// GetTenantsFunc mocks the GetTenants method.
 	GetTenantsFunc func(ctx context.Context) (types.Collection[string], error)
 
@@ -72,6 +139,20 @@ type DeviceReaderMock struct {
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// GetBatteryForecasts holds details about calls to the GetBatteryForecasts method.
+		GetBatteryForecasts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query dmquery.BatteryFilters
+		}
+		// GetCoverage holds details about calls to the GetCoverage method.
+		GetCoverage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query dmquery.CoverageFilters
+		}
 		// GetDeviceAlarms holds details about calls to the GetDeviceAlarms method.
 		GetDeviceAlarms []struct {
 			// Ctx is the ctx argument value.
@@ -86,6 +167,72 @@ type DeviceReaderMock struct {
 			// SensorID is the sensorID argument value.
 			SensorID string
 		}
+		// GetDeviceClusters holds details about calls to the GetDeviceClusters method.
+		GetDeviceClusters []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query dmquery.ClusterFilters
+		}
+		// GetDeviceHistory holds details about calls to the GetDeviceHistory method.
+		GetDeviceHistory []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Tenant is the tenant argument value.
+			Tenant string
+			// AllowedTenants is the allowedTenants argument value.
+			AllowedTenants []string
+			// From is the from argument value.
+			From time.Time
+			// To is the to argument value.
+			To time.Time
+		}
+		// GetDeviceLocations holds details about calls to the GetDeviceLocations method.
+		GetDeviceLocations []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+			// Query is the query argument value.
+			Query dmquery.LocationFilters
+		}
+		// GetDeviceState holds details about calls to the GetDeviceState method.
+		GetDeviceState []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+		}
+		// GetDeviceStates holds details about calls to the GetDeviceStates method.
+		GetDeviceStates []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceIDs is the deviceIDs argument value.
+			DeviceIDs []string
+		}
+		// GetDeviceStats holds details about calls to the GetDeviceStats method.
+		GetDeviceStats []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query dmquery.DeviceFilters
+		}
+		// GetDeviceStatusAggregates holds details about calls to the GetDeviceStatusAggregates method.
+		GetDeviceStatusAggregates []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+			// Query is the query argument value.
+			Query dmquery.StatusFilters
+		}
+		// GetGeofences holds details about calls to the GetGeofences method.
+		GetGeofences []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query dmquery.GeofenceFilters
+		}
 		// GetSensor holds details about calls to the GetSensor method.
 		GetSensor []struct {
 			// Ctx is the ctx argument value.
@@ -111,6 +258,17 @@ type DeviceReaderMock struct {
 			// Query is the query argument value.
 			Query dmquery.StatusFilters
 		}
+		// GetStatusTimestamps holds details about calls to the GetStatusTimestamps method.
+		GetStatusTimestamps []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// SensorIDs is the sensorIDs argument value.
+			SensorIDs []string
+			// From is the from argument value.
+			From time.Time
+			// To is the to argument value.
+			To time.Time
+		}
 		// GetTenants holds details about calls to the GetTenants method.
 		GetTenants []struct {
 			// Ctx is the ctx argument value.
@@ -124,13 +282,96 @@ type DeviceReaderMock struct {
 			Query dmquery.DeviceFilters
 		}
 	}
-	lockGetDeviceAlarms       sync.RWMutex
-	lockGetDeviceBySensorID   sync.RWMutex
-	lockGetSensor             sync.RWMutex
-	lockGetDeviceMeasurements sync.RWMutex
-	lockGetDeviceStatus       sync.RWMutex
-	lockGetTenants            sync.RWMutex
-	lockQuery                 sync.RWMutex
+	lockGetBatteryForecasts       sync.RWMutex
+	lockGetCoverage               sync.RWMutex
+	lockGetDeviceAlarms           sync.RWMutex
+	lockGetDeviceBySensorID       sync.RWMutex
+	lockGetDeviceClusters         sync.RWMutex
+	lockGetDeviceHistory          sync.RWMutex
+	lockGetDeviceLocations        sync.RWMutex
+	lockGetDeviceMeasurements     sync.RWMutex
+	lockGetDeviceState            sync.RWMutex
+	lockGetDeviceStates           sync.RWMutex
+	lockGetDeviceStats            sync.RWMutex
+	lockGetDeviceStatus           sync.RWMutex
+	lockGetDeviceStatusAggregates sync.RWMutex
+	lockGetGeofences              sync.RWMutex
+	lockGetSensor                 sync.RWMutex
+	lockGetStatusTimestamps       sync.RWMutex
+	lockGetTenants                sync.RWMutex
+	lockQuery                     sync.RWMutex
+}
+
+// GetBatteryForecasts calls GetBatteryForecastsFunc.
+func (mock *DeviceReaderMock) GetBatteryForecasts(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error) {
+	if mock.GetBatteryForecastsFunc == nil {
+		panic("DeviceReaderMock.GetBatteryForecastsFunc: method is nil but DeviceReader.GetBatteryForecasts was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query dmquery.BatteryFilters
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockGetBatteryForecasts.Lock()
+	mock.calls.GetBatteryForecasts = append(mock.calls.GetBatteryForecasts, callInfo)
+	mock.lockGetBatteryForecasts.Unlock()
+	return mock.GetBatteryForecastsFunc(ctx, query)
+}
+
+// GetBatteryForecastsCalls gets all the calls that were made to GetBatteryForecasts.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetBatteryForecastsCalls())
+func (mock *DeviceReaderMock) GetBatteryForecastsCalls() []struct {
+	Ctx   context.Context
+	Query dmquery.BatteryFilters
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query dmquery.BatteryFilters
+	}
+	mock.lockGetBatteryForecasts.RLock()
+	calls = mock.calls.GetBatteryForecasts
+	mock.lockGetBatteryForecasts.RUnlock()
+	return calls
+}
+
+// GetCoverage calls GetCoverageFunc.
+func (mock *DeviceReaderMock) GetCoverage(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error) {
+	if mock.GetCoverageFunc == nil {
+		panic("DeviceReaderMock.GetCoverageFunc: method is nil but DeviceReader.GetCoverage was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query dmquery.CoverageFilters
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockGetCoverage.Lock()
+	mock.calls.GetCoverage = append(mock.calls.GetCoverage, callInfo)
+	mock.lockGetCoverage.Unlock()
+	return mock.GetCoverageFunc(ctx, query)
+}
+
+// GetCoverageCalls gets all the calls that were made to GetCoverage.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetCoverageCalls())
+func (mock *DeviceReaderMock) GetCoverageCalls() []struct {
+	Ctx   context.Context
+	Query dmquery.CoverageFilters
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query dmquery.CoverageFilters
+	}
+	mock.lockGetCoverage.RLock()
+	calls = mock.calls.GetCoverage
+	mock.lockGetCoverage.RUnlock()
+	return calls
 }
 
 // GetDeviceAlarms calls GetDeviceAlarmsFunc.
@@ -205,6 +446,314 @@ func (mock *DeviceReaderMock) GetDeviceBySensorIDCalls() []struct {
 	return calls
 }
 
+// GetDeviceClusters calls GetDeviceClustersFunc.
+func (mock *DeviceReaderMock) GetDeviceClusters(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+	if mock.GetDeviceClustersFunc == nil {
+		panic("DeviceReaderMock.GetDeviceClustersFunc: method is nil but DeviceReader.GetDeviceClusters was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query dmquery.ClusterFilters
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockGetDeviceClusters.Lock()
+	mock.calls.GetDeviceClusters = append(mock.calls.GetDeviceClusters, callInfo)
+	mock.lockGetDeviceClusters.Unlock()
+	return mock.GetDeviceClustersFunc(ctx, query)
+}
+
+// GetDeviceClustersCalls gets all the calls that were made to GetDeviceClusters.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceClustersCalls())
+func (mock *DeviceReaderMock) GetDeviceClustersCalls() []struct {
+	Ctx   context.Context
+	Query dmquery.ClusterFilters
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query dmquery.ClusterFilters
+	}
+	mock.lockGetDeviceClusters.RLock()
+	calls = mock.calls.GetDeviceClusters
+	mock.lockGetDeviceClusters.RUnlock()
+	return calls
+}
+
+// GetDeviceHistory calls GetDeviceHistoryFunc.
+func (mock *DeviceReaderMock) GetDeviceHistory(ctx context.Context, tenant string, allowedTenants []string, from time.Time, to time.Time) ([]types.DeviceHistory, error) {
+	if mock.GetDeviceHistoryFunc == nil {
+		panic("DeviceReaderMock.GetDeviceHistoryFunc: method is nil but DeviceReader.GetDeviceHistory was just called")
+	}
+	callInfo := struct {
+		Ctx            context.Context
+		Tenant         string
+		AllowedTenants []string
+		From           time.Time
+		To             time.Time
+	}{
+		Ctx:            ctx,
+		Tenant:         tenant,
+		AllowedTenants: allowedTenants,
+		From:           from,
+		To:             to,
+	}
+	mock.lockGetDeviceHistory.Lock()
+	mock.calls.GetDeviceHistory = append(mock.calls.GetDeviceHistory, callInfo)
+	mock.lockGetDeviceHistory.Unlock()
+	return mock.GetDeviceHistoryFunc(ctx, tenant, allowedTenants, from, to)
+}
+
+// GetDeviceHistoryCalls gets all the calls that were made to GetDeviceHistory.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceHistoryCalls())
+func (mock *DeviceReaderMock) GetDeviceHistoryCalls() []struct {
+	Ctx            context.Context
+	Tenant         string
+	AllowedTenants []string
+	From           time.Time
+	To             time.Time
+} {
+	var calls []struct {
+		Ctx            context.Context
+		Tenant         string
+		AllowedTenants []string
+		From           time.Time
+		To             time.Time
+	}
+	mock.lockGetDeviceHistory.RLock()
+	calls = mock.calls.GetDeviceHistory
+	mock.lockGetDeviceHistory.RUnlock()
+	return calls
+}
+
+// GetDeviceLocations calls GetDeviceLocationsFunc.
+func (mock *DeviceReaderMock) GetDeviceLocations(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error) {
+	if mock.GetDeviceLocationsFunc == nil {
+		panic("DeviceReaderMock.GetDeviceLocationsFunc: method is nil but DeviceReader.GetDeviceLocations was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		DeviceID string
+		Query    dmquery.LocationFilters
+	}{
+		Ctx:      ctx,
+		DeviceID: deviceID,
+		Query:    query,
+	}
+	mock.lockGetDeviceLocations.Lock()
+	mock.calls.GetDeviceLocations = append(mock.calls.GetDeviceLocations, callInfo)
+	mock.lockGetDeviceLocations.Unlock()
+	return mock.GetDeviceLocationsFunc(ctx, deviceID, query)
+}
+
+// GetDeviceLocationsCalls gets all the calls that were made to GetDeviceLocations.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceLocationsCalls())
+func (mock *DeviceReaderMock) GetDeviceLocationsCalls() []struct {
+	Ctx      context.Context
+	DeviceID string
+	Query    dmquery.LocationFilters
+} {
+	var calls []struct {
+		Ctx      context.Context
+		DeviceID string
+		Query    dmquery.LocationFilters
+	}
+	mock.lockGetDeviceLocations.RLock()
+	calls = mock.calls.GetDeviceLocations
+	mock.lockGetDeviceLocations.RUnlock()
+	return calls
+}
+
+// GetDeviceState calls GetDeviceStateFunc.
+func (mock *DeviceReaderMock) GetDeviceState(ctx context.Context, deviceID string) (types.Device, bool, error) {
+	if mock.GetDeviceStateFunc == nil {
+		panic("DeviceReaderMock.GetDeviceStateFunc: method is nil but DeviceReader.GetDeviceState was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		DeviceID string
+	}{
+		Ctx:      ctx,
+		DeviceID: deviceID,
+	}
+	mock.lockGetDeviceState.Lock()
+	mock.calls.GetDeviceState = append(mock.calls.GetDeviceState, callInfo)
+	mock.lockGetDeviceState.Unlock()
+	return mock.GetDeviceStateFunc(ctx, deviceID)
+}
+
+// GetDeviceStateCalls gets all the calls that were made to GetDeviceState.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceStateCalls())
+func (mock *DeviceReaderMock) GetDeviceStateCalls() []struct {
+	Ctx      context.Context
+	DeviceID string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		DeviceID string
+	}
+	mock.lockGetDeviceState.RLock()
+	calls = mock.calls.GetDeviceState
+	mock.lockGetDeviceState.RUnlock()
+	return calls
+}
+
+// GetDeviceStates calls GetDeviceStatesFunc.
+func (mock *DeviceReaderMock) GetDeviceStates(ctx context.Context, deviceIDs []string) (map[string]types.Device, error) {
+	if mock.GetDeviceStatesFunc == nil {
+		panic("DeviceReaderMock.GetDeviceStatesFunc: method is nil but DeviceReader.GetDeviceStates was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		DeviceIDs []string
+	}{
+		Ctx:       ctx,
+		DeviceIDs: deviceIDs,
+	}
+	mock.lockGetDeviceStates.Lock()
+	mock.calls.GetDeviceStates = append(mock.calls.GetDeviceStates, callInfo)
+	mock.lockGetDeviceStates.Unlock()
+	return mock.GetDeviceStatesFunc(ctx, deviceIDs)
+}
+
+// GetDeviceStatesCalls gets all the calls that were made to GetDeviceStates.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceStatesCalls())
+func (mock *DeviceReaderMock) GetDeviceStatesCalls() []struct {
+	Ctx       context.Context
+	DeviceIDs []string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		DeviceIDs []string
+	}
+	mock.lockGetDeviceStates.RLock()
+	calls = mock.calls.GetDeviceStates
+	mock.lockGetDeviceStates.RUnlock()
+	return calls
+}
+
+// GetDeviceStats calls GetDeviceStatsFunc.
+func (mock *DeviceReaderMock) GetDeviceStats(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error) {
+	if mock.GetDeviceStatsFunc == nil {
+		panic("DeviceReaderMock.GetDeviceStatsFunc: method is nil but DeviceReader.GetDeviceStats was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query dmquery.DeviceFilters
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockGetDeviceStats.Lock()
+	mock.calls.GetDeviceStats = append(mock.calls.GetDeviceStats, callInfo)
+	mock.lockGetDeviceStats.Unlock()
+	return mock.GetDeviceStatsFunc(ctx, query)
+}
+
+// GetDeviceStatsCalls gets all the calls that were made to GetDeviceStats.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceStatsCalls())
+func (mock *DeviceReaderMock) GetDeviceStatsCalls() []struct {
+	Ctx   context.Context
+	Query dmquery.DeviceFilters
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query dmquery.DeviceFilters
+	}
+	mock.lockGetDeviceStats.RLock()
+	calls = mock.calls.GetDeviceStats
+	mock.lockGetDeviceStats.RUnlock()
+	return calls
+}
+
+// GetDeviceStatusAggregates calls GetDeviceStatusAggregatesFunc.
+func (mock *DeviceReaderMock) GetDeviceStatusAggregates(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error) {
+	if mock.GetDeviceStatusAggregatesFunc == nil {
+		panic("DeviceReaderMock.GetDeviceStatusAggregatesFunc: method is nil but DeviceReader.GetDeviceStatusAggregates was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		DeviceID string
+		Query    dmquery.StatusFilters
+	}{
+		Ctx:      ctx,
+		DeviceID: deviceID,
+		Query:    query,
+	}
+	mock.lockGetDeviceStatusAggregates.Lock()
+	mock.calls.GetDeviceStatusAggregates = append(mock.calls.GetDeviceStatusAggregates, callInfo)
+	mock.lockGetDeviceStatusAggregates.Unlock()
+	return mock.GetDeviceStatusAggregatesFunc(ctx, deviceID, query)
+}
+
+// GetDeviceStatusAggregatesCalls gets all the calls that were made to GetDeviceStatusAggregates.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetDeviceStatusAggregatesCalls())
+func (mock *DeviceReaderMock) GetDeviceStatusAggregatesCalls() []struct {
+	Ctx      context.Context
+	DeviceID string
+	Query    dmquery.StatusFilters
+} {
+	var calls []struct {
+		Ctx      context.Context
+		DeviceID string
+		Query    dmquery.StatusFilters
+	}
+	mock.lockGetDeviceStatusAggregates.RLock()
+	calls = mock.calls.GetDeviceStatusAggregates
+	mock.lockGetDeviceStatusAggregates.RUnlock()
+	return calls
+}
+
+// GetGeofences calls GetGeofencesFunc.
+func (mock *DeviceReaderMock) GetGeofences(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+	if mock.GetGeofencesFunc == nil {
+		panic("DeviceReaderMock.GetGeofencesFunc: method is nil but DeviceReader.GetGeofences was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query dmquery.GeofenceFilters
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockGetGeofences.Lock()
+	mock.calls.GetGeofences = append(mock.calls.GetGeofences, callInfo)
+	mock.lockGetGeofences.Unlock()
+	return mock.GetGeofencesFunc(ctx, query)
+}
+
+// GetGeofencesCalls gets all the calls that were made to GetGeofences.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetGeofencesCalls())
+func (mock *DeviceReaderMock) GetGeofencesCalls() []struct {
+	Ctx   context.Context
+	Query dmquery.GeofenceFilters
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query dmquery.GeofenceFilters
+	}
+	mock.lockGetGeofences.RLock()
+	calls = mock.calls.GetGeofences
+	mock.lockGetGeofences.RUnlock()
+	return calls
+}
+
 // GetSensor calls GetSensorFunc.
 func (mock *DeviceReaderMock) GetSensor(ctx context.Context, sensorID string) (types.Sensor, bool, error) {
 	if mock.GetSensorFunc == nil {
@@ -321,6 +870,50 @@ func (mock *DeviceReaderMock) GetDeviceStatusCalls() []struct {
 	return calls
 }
 
+// GetStatusTimestamps calls GetStatusTimestampsFunc.
+func (mock *DeviceReaderMock) GetStatusTimestamps(ctx context.Context, sensorIDs []string, from time.Time, to time.Time) (map[string][]time.Time, error) {
+	if mock.GetStatusTimestampsFunc == nil {
+		panic("DeviceReaderMock.GetStatusTimestampsFunc: method is nil but DeviceReader.GetStatusTimestamps was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		SensorIDs []string
+		From      time.Time
+		To        time.Time
+	}{
+		Ctx:       ctx,
+		SensorIDs: sensorIDs,
+		From:      from,
+		To:        to,
+	}
+	mock.lockGetStatusTimestamps.Lock()
+	mock.calls.GetStatusTimestamps = append(mock.calls.GetStatusTimestamps, callInfo)
+	mock.lockGetStatusTimestamps.Unlock()
+	return mock.GetStatusTimestampsFunc(ctx, sensorIDs, from, to)
+}
+
+// GetStatusTimestampsCalls gets all the calls that were made to GetStatusTimestamps.
+// Check the length with:
+//
+//	len(mockedDeviceReader.GetStatusTimestampsCalls())
+func (mock *DeviceReaderMock) GetStatusTimestampsCalls() []struct {
+	Ctx       context.Context
+	SensorIDs []string
+	From      time.Time
+	To        time.Time
+} {
+	var calls []struct {
+		Ctx       context.Context
+		SensorIDs []string
+		From      time.Time
+		To        time.Time
+	}
+	mock.lockGetStatusTimestamps.RLock()
+	calls = mock.calls.GetStatusTimestamps
+	mock.lockGetStatusTimestamps.RUnlock()
+	return calls
+}
+
 // GetTenants calls GetTenantsFunc.
 func (mock *DeviceReaderMock) GetTenants(ctx context.Context) (types.Collection[string], error) {
 	if mock.GetTenantsFunc == nil {
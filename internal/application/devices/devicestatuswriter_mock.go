@@ -23,9 +23,18 @@ var _ DeviceStatusWriter = &DeviceStatusWriterMock{}
 //			AddDeviceStatusFunc: func(ctx context.Context, status types.StatusMessage) error {
 //				panic("mock out the AddDeviceStatus method")
 //			},
+//			AddDeviceStatusesFunc: func(ctx context.Context, statuses []types.StatusMessage) error {
+//				panic("mock out the AddDeviceStatuses method")
+//			},
+//			DiscoverSensorFunc: func(ctx context.Context, status types.StatusMessage) error {
+//				panic("mock out the DiscoverSensor method")
+//			},
 //			SetDeviceStateFunc: func(ctx context.Context, deviceID string, state types.DeviceState) error {
 //				panic("mock out the SetDeviceState method")
 //			},
+//			SetDeviceStatesFunc: func(ctx context.Context, states map[string]types.DeviceState) error {
+//				panic("mock out the SetDeviceStates method")
+//			},
 //		}
 //
 //		// use mockedDeviceStatusWriter in code that requires DeviceStatusWriter
@@ -36,9 +45,18 @@ type DeviceStatusWriterMock struct {
 	// AddDeviceStatusFunc mocks the AddDeviceStatus method.
 	AddDeviceStatusFunc func(ctx context.Context, status types.StatusMessage) error
 
+	// AddDeviceStatusesFunc mocks the AddDeviceStatuses method.
+	AddDeviceStatusesFunc func(ctx context.Context, statuses []types.StatusMessage) error
+
+	// DiscoverSensorFunc mocks the DiscoverSensor method.
+	DiscoverSensorFunc func(ctx context.Context, status types.StatusMessage) error
+
 	// SetDeviceStateFunc mocks the SetDeviceState method.
 	SetDeviceStateFunc func(ctx context.Context, deviceID string, state types.DeviceState) error
 
+	// SetDeviceStatesFunc mocks the SetDeviceStates method.
+	SetDeviceStatesFunc func(ctx context.Context, states map[string]types.DeviceState) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// AddDeviceStatus holds details about calls to the AddDeviceStatus method.
@@ -48,6 +66,20 @@ type DeviceStatusWriterMock struct {
 			// Status is the status argument value.
 			Status types.StatusMessage
 		}
+		// AddDeviceStatuses holds details about calls to the AddDeviceStatuses method.
+		AddDeviceStatuses []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Statuses is the statuses argument value.
+			Statuses []types.StatusMessage
+		}
+		// DiscoverSensor holds details about calls to the DiscoverSensor method.
+		DiscoverSensor []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Status is the status argument value.
+			Status types.StatusMessage
+		}
 		// SetDeviceState holds details about calls to the SetDeviceState method.
 		SetDeviceState []struct {
 			// Ctx is the ctx argument value.
@@ -57,9 +89,19 @@ type DeviceStatusWriterMock struct {
 			// State is the state argument value.
 			State types.DeviceState
 		}
+		// SetDeviceStates holds details about calls to the SetDeviceStates method.
+		SetDeviceStates []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// States is the states argument value.
+			States map[string]types.DeviceState
+		}
 	}
-	lockAddDeviceStatus sync.RWMutex
-	lockSetDeviceState  sync.RWMutex
+	lockAddDeviceStatus   sync.RWMutex
+	lockAddDeviceStatuses sync.RWMutex
+	lockDiscoverSensor    sync.RWMutex
+	lockSetDeviceState    sync.RWMutex
+	lockSetDeviceStates   sync.RWMutex
 }
 
 // AddDeviceStatus calls AddDeviceStatusFunc.
@@ -98,6 +140,78 @@ func (mock *DeviceStatusWriterMock) AddDeviceStatusCalls() []struct {
 	return calls
 }
 
+// AddDeviceStatuses calls AddDeviceStatusesFunc.
+func (mock *DeviceStatusWriterMock) AddDeviceStatuses(ctx context.Context, statuses []types.StatusMessage) error {
+	if mock.AddDeviceStatusesFunc == nil {
+		panic("DeviceStatusWriterMock.AddDeviceStatusesFunc: method is nil but DeviceStatusWriter.AddDeviceStatuses was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Statuses []types.StatusMessage
+	}{
+		Ctx:      ctx,
+		Statuses: statuses,
+	}
+	mock.lockAddDeviceStatuses.Lock()
+	mock.calls.AddDeviceStatuses = append(mock.calls.AddDeviceStatuses, callInfo)
+	mock.lockAddDeviceStatuses.Unlock()
+	return mock.AddDeviceStatusesFunc(ctx, statuses)
+}
+
+// AddDeviceStatusesCalls gets all the calls that were made to AddDeviceStatuses.
+// Check the length with:
+//
+//	len(mockedDeviceStatusWriter.AddDeviceStatusesCalls())
+func (mock *DeviceStatusWriterMock) AddDeviceStatusesCalls() []struct {
+	Ctx      context.Context
+	Statuses []types.StatusMessage
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Statuses []types.StatusMessage
+	}
+	mock.lockAddDeviceStatuses.RLock()
+	calls = mock.calls.AddDeviceStatuses
+	mock.lockAddDeviceStatuses.RUnlock()
+	return calls
+}
+
+// DiscoverSensor calls DiscoverSensorFunc.
+func (mock *DeviceStatusWriterMock) DiscoverSensor(ctx context.Context, status types.StatusMessage) error {
+	if mock.DiscoverSensorFunc == nil {
+		panic("DeviceStatusWriterMock.DiscoverSensorFunc: method is nil but DeviceStatusWriter.DiscoverSensor was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Status types.StatusMessage
+	}{
+		Ctx:    ctx,
+		Status: status,
+	}
+	mock.lockDiscoverSensor.Lock()
+	mock.calls.DiscoverSensor = append(mock.calls.DiscoverSensor, callInfo)
+	mock.lockDiscoverSensor.Unlock()
+	return mock.DiscoverSensorFunc(ctx, status)
+}
+
+// DiscoverSensorCalls gets all the calls that were made to DiscoverSensor.
+// Check the length with:
+//
+//	len(mockedDeviceStatusWriter.DiscoverSensorCalls())
+func (mock *DeviceStatusWriterMock) DiscoverSensorCalls() []struct {
+	Ctx    context.Context
+	Status types.StatusMessage
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Status types.StatusMessage
+	}
+	mock.lockDiscoverSensor.RLock()
+	calls = mock.calls.DiscoverSensor
+	mock.lockDiscoverSensor.RUnlock()
+	return calls
+}
+
 // SetDeviceState calls SetDeviceStateFunc.
 func (mock *DeviceStatusWriterMock) SetDeviceState(ctx context.Context, deviceID string, state types.DeviceState) error {
 	if mock.SetDeviceStateFunc == nil {
@@ -137,3 +251,39 @@ func (mock *DeviceStatusWriterMock) SetDeviceStateCalls() []struct {
 	mock.lockSetDeviceState.RUnlock()
 	return calls
 }
+
+// SetDeviceStates calls SetDeviceStatesFunc.
+func (mock *DeviceStatusWriterMock) SetDeviceStates(ctx context.Context, states map[string]types.DeviceState) error {
+	if mock.SetDeviceStatesFunc == nil {
+		panic("DeviceStatusWriterMock.SetDeviceStatesFunc: method is nil but DeviceStatusWriter.SetDeviceStates was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		States map[string]types.DeviceState
+	}{
+		Ctx:    ctx,
+		States: states,
+	}
+	mock.lockSetDeviceStates.Lock()
+	mock.calls.SetDeviceStates = append(mock.calls.SetDeviceStates, callInfo)
+	mock.lockSetDeviceStates.Unlock()
+	return mock.SetDeviceStatesFunc(ctx, states)
+}
+
+// SetDeviceStatesCalls gets all the calls that were made to SetDeviceStates.
+// Check the length with:
+//
+//	len(mockedDeviceStatusWriter.SetDeviceStatesCalls())
+func (mock *DeviceStatusWriterMock) SetDeviceStatesCalls() []struct {
+	Ctx    context.Context
+	States map[string]types.DeviceState
+} {
+	var calls []struct {
+		Ctx    context.Context
+		States map[string]types.DeviceState
+	}
+	mock.lockSetDeviceStates.RLock()
+	calls = mock.calls.SetDeviceStates
+	mock.lockSetDeviceStates.RUnlock()
+	return calls
+}
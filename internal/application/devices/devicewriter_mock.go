@@ -6,6 +6,7 @@ package devices
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 )
@@ -20,12 +21,21 @@ var _ DeviceWriter = &DeviceWriterMock{}
 //
 //		// make and configure a mocked DeviceWriter
 //		mockedDeviceWriter := &DeviceWriterMock{
+//			AddDeviceLocationFunc: func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) (error) {
+//				panic("mock out the AddDeviceLocation method")
+//			},
 //			AssignSensorFunc: func(ctx context.Context, deviceID string, sensorID string) error {
 //				panic("mock out the AssignSensor method")
 //			},
+//			CreateGeofenceFunc: func(ctx context.Context, g types.Geofence) (error) {
+//				panic("mock out the CreateGeofence method")
+//			},
 //			CreateOrUpdateDeviceFunc: func(ctx context.Context, d types.Device) error {
 //				panic("mock out the CreateOrUpdateDevice method")
 //			},
+//			DeleteGeofenceFunc: func(ctx context.Context, geofenceID string) (error) {
+//				panic("mock out the DeleteGeofence method")
+//			},
 //			SetDeviceProfileTypesFunc: func(ctx context.Context, deviceID string, typesMoqParam []types.Lwm2mType) error {
 //				panic("mock out the SetDeviceProfileTypes method")
 //			},
@@ -35,6 +45,9 @@ var _ DeviceWriter = &DeviceWriterMock{}
 //			UpdateDeviceFunc: func(ctx context.Context, deviceID string, active *bool, name *string, description *string, environment *string, source *string, tenant *string, location *types.Location, interval *int) error {
 //				panic("mock out the UpdateDevice method")
 //			},
+//			UpdateGeofenceFunc: func(ctx context.Context, g types.Geofence) (error) {
+//				panic("mock out the UpdateGeofence method")
+//			},
 //		}
 //
 //		// use mockedDeviceWriter in code that requires DeviceWriter
@@ -42,12 +55,21 @@ var _ DeviceWriter = &DeviceWriterMock{}
 //
 //	}
 type DeviceWriterMock struct {
+	// AddDeviceLocationFunc mocks the AddDeviceLocation method.
+	AddDeviceLocationFunc func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error
+
 	// AssignSensorFunc mocks the AssignSensor method.
 	AssignSensorFunc func(ctx context.Context, deviceID string, sensorID string) error
 
+	// CreateGeofenceFunc mocks the CreateGeofence method.
+	CreateGeofenceFunc func(ctx context.Context, g types.Geofence) error
+
 	// CreateOrUpdateDeviceFunc mocks the CreateOrUpdateDevice method.
 	CreateOrUpdateDeviceFunc func(ctx context.Context, d types.Device) error
 
+	// DeleteGeofenceFunc mocks the DeleteGeofence method.
+	DeleteGeofenceFunc func(ctx context.Context, geofenceID string) error
+
 	// SetDeviceProfileTypesFunc mocks the SetDeviceProfileTypes method.
 	SetDeviceProfileTypesFunc func(ctx context.Context, deviceID string, typesMoqParam []types.Lwm2mType) error
 
@@ -57,8 +79,24 @@ type DeviceWriterMock struct {
 	// UpdateDeviceFunc mocks the UpdateDevice method.
 	UpdateDeviceFunc func(ctx context.Context, deviceID string, active *bool, name *string, description *string, environment *string, source *string, tenant *string, location *types.Location, interval *int) error
 
+	// UpdateGeofenceFunc mocks the UpdateGeofence method.
+	UpdateGeofenceFunc func(ctx context.Context, g types.Geofence) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddDeviceLocation holds details about calls to the AddDeviceLocation method.
+		AddDeviceLocation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+			// Location is the location argument value.
+			Location types.Location
+			// Source is the source argument value.
+			Source string
+			// ObservedAt is the observedAt argument value.
+			ObservedAt time.Time
+		}
 		// AssignSensor holds details about calls to the AssignSensor method.
 		AssignSensor []struct {
 			// Ctx is the ctx argument value.
@@ -68,6 +106,13 @@ type DeviceWriterMock struct {
 			// SensorID is the sensorID argument value.
 			SensorID string
 		}
+		// CreateGeofence holds details about calls to the CreateGeofence method.
+		CreateGeofence []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// G is the g argument value.
+			G types.Geofence
+		}
 		// CreateOrUpdateDevice holds details about calls to the CreateOrUpdateDevice method.
 		CreateOrUpdateDevice []struct {
 			// Ctx is the ctx argument value.
@@ -75,6 +120,13 @@ type DeviceWriterMock struct {
 			// D is the d argument value.
 			D types.Device
 		}
+		// DeleteGeofence holds details about calls to the DeleteGeofence method.
+		DeleteGeofence []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// GeofenceID is the geofenceID argument value.
+			GeofenceID string
+		}
 		// SetDeviceProfileTypes holds details about calls to the SetDeviceProfileTypes method.
 		SetDeviceProfileTypes []struct {
 			// Ctx is the ctx argument value.
@@ -114,12 +166,71 @@ type DeviceWriterMock struct {
 			// Interval is the interval argument value.
 			Interval *int
 		}
+		// UpdateGeofence holds details about calls to the UpdateGeofence method.
+		UpdateGeofence []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// G is the g argument value.
+			G types.Geofence
+		}
 	}
+	lockAddDeviceLocation     sync.RWMutex
 	lockAssignSensor          sync.RWMutex
+	lockCreateGeofence        sync.RWMutex
 	lockCreateOrUpdateDevice  sync.RWMutex
+	lockDeleteGeofence        sync.RWMutex
 	lockSetDeviceProfileTypes sync.RWMutex
 	lockUnassignSensor        sync.RWMutex
 	lockUpdateDevice          sync.RWMutex
+	lockUpdateGeofence        sync.RWMutex
+}
+
+// AddDeviceLocation calls AddDeviceLocationFunc.
+func (mock *DeviceWriterMock) AddDeviceLocation(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+	if mock.AddDeviceLocationFunc == nil {
+		panic("DeviceWriterMock.AddDeviceLocationFunc: method is nil but DeviceWriter.AddDeviceLocation was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		DeviceID   string
+		Location   types.Location
+		Source     string
+		ObservedAt time.Time
+	}{
+		Ctx:        ctx,
+		DeviceID:   deviceID,
+		Location:   location,
+		Source:     source,
+		ObservedAt: observedAt,
+	}
+	mock.lockAddDeviceLocation.Lock()
+	mock.calls.AddDeviceLocation = append(mock.calls.AddDeviceLocation, callInfo)
+	mock.lockAddDeviceLocation.Unlock()
+	return mock.AddDeviceLocationFunc(ctx, deviceID, location, source, observedAt)
+}
+
+// AddDeviceLocationCalls gets all the calls that were made to AddDeviceLocation.
+// Check the length with:
+//
+//	len(mockedDeviceWriter.AddDeviceLocationCalls())
+func (mock *DeviceWriterMock) AddDeviceLocationCalls() []struct {
+	Ctx        context.Context
+	DeviceID   string
+	Location   types.Location
+	Source     string
+	ObservedAt time.Time
+} {
+	var calls []struct {
+		Ctx        context.Context
+		DeviceID   string
+		Location   types.Location
+		Source     string
+		ObservedAt time.Time
+	}
+	mock.lockAddDeviceLocation.RLock()
+	calls = mock.calls.AddDeviceLocation
+	mock.lockAddDeviceLocation.RUnlock()
+	return calls
 }
 
 // AssignSensor calls AssignSensorFunc.
@@ -162,6 +273,42 @@ func (mock *DeviceWriterMock) AssignSensorCalls() []struct {
 	return calls
 }
 
+// CreateGeofence calls CreateGeofenceFunc.
+func (mock *DeviceWriterMock) CreateGeofence(ctx context.Context, g types.Geofence) error {
+	if mock.CreateGeofenceFunc == nil {
+		panic("DeviceWriterMock.CreateGeofenceFunc: method is nil but DeviceWriter.CreateGeofence was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		G   types.Geofence
+	}{
+		Ctx: ctx,
+		G:   g,
+	}
+	mock.lockCreateGeofence.Lock()
+	mock.calls.CreateGeofence = append(mock.calls.CreateGeofence, callInfo)
+	mock.lockCreateGeofence.Unlock()
+	return mock.CreateGeofenceFunc(ctx, g)
+}
+
+// CreateGeofenceCalls gets all the calls that were made to CreateGeofence.
+// Check the length with:
+//
+//	len(mockedDeviceWriter.CreateGeofenceCalls())
+func (mock *DeviceWriterMock) CreateGeofenceCalls() []struct {
+	Ctx context.Context
+	G   types.Geofence
+} {
+	var calls []struct {
+		Ctx context.Context
+		G   types.Geofence
+	}
+	mock.lockCreateGeofence.RLock()
+	calls = mock.calls.CreateGeofence
+	mock.lockCreateGeofence.RUnlock()
+	return calls
+}
+
 // CreateOrUpdateDevice calls CreateOrUpdateDeviceFunc.
 func (mock *DeviceWriterMock) CreateOrUpdateDevice(ctx context.Context, d types.Device) error {
 	if mock.CreateOrUpdateDeviceFunc == nil {
@@ -198,6 +345,42 @@ func (mock *DeviceWriterMock) CreateOrUpdateDeviceCalls() []struct {
 	return calls
 }
 
+// DeleteGeofence calls DeleteGeofenceFunc.
+func (mock *DeviceWriterMock) DeleteGeofence(ctx context.Context, geofenceID string) error {
+	if mock.DeleteGeofenceFunc == nil {
+		panic("DeviceWriterMock.DeleteGeofenceFunc: method is nil but DeviceWriter.DeleteGeofence was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		GeofenceID string
+	}{
+		Ctx:        ctx,
+		GeofenceID: geofenceID,
+	}
+	mock.lockDeleteGeofence.Lock()
+	mock.calls.DeleteGeofence = append(mock.calls.DeleteGeofence, callInfo)
+	mock.lockDeleteGeofence.Unlock()
+	return mock.DeleteGeofenceFunc(ctx, geofenceID)
+}
+
+// DeleteGeofenceCalls gets all the calls that were made to DeleteGeofence.
+// Check the length with:
+//
+//	len(mockedDeviceWriter.DeleteGeofenceCalls())
+func (mock *DeviceWriterMock) DeleteGeofenceCalls() []struct {
+	Ctx        context.Context
+	GeofenceID string
+} {
+	var calls []struct {
+		Ctx        context.Context
+		GeofenceID string
+	}
+	mock.lockDeleteGeofence.RLock()
+	calls = mock.calls.DeleteGeofence
+	mock.lockDeleteGeofence.RUnlock()
+	return calls
+}
+
 // SetDeviceProfileTypes calls SetDeviceProfileTypesFunc.
 func (mock *DeviceWriterMock) SetDeviceProfileTypes(ctx context.Context, deviceID string, typesMoqParam []types.Lwm2mType) error {
 	if mock.SetDeviceProfileTypesFunc == nil {
@@ -341,3 +524,39 @@ func (mock *DeviceWriterMock) UpdateDeviceCalls() []struct {
 	mock.lockUpdateDevice.RUnlock()
 	return calls
 }
+
+// UpdateGeofence calls UpdateGeofenceFunc.
+func (mock *DeviceWriterMock) UpdateGeofence(ctx context.Context, g types.Geofence) error {
+	if mock.UpdateGeofenceFunc == nil {
+		panic("DeviceWriterMock.UpdateGeofenceFunc: method is nil but DeviceWriter.UpdateGeofence was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		G   types.Geofence
+	}{
+		Ctx: ctx,
+		G:   g,
+	}
+	mock.lockUpdateGeofence.Lock()
+	mock.calls.UpdateGeofence = append(mock.calls.UpdateGeofence, callInfo)
+	mock.lockUpdateGeofence.Unlock()
+	return mock.UpdateGeofenceFunc(ctx, g)
+}
+
+// UpdateGeofenceCalls gets all the calls that were made to UpdateGeofence.
+// Check the length with:
+//
+//	len(mockedDeviceWriter.UpdateGeofenceCalls())
+func (mock *DeviceWriterMock) UpdateGeofenceCalls() []struct {
+	Ctx context.Context
+	G   types.Geofence
+} {
+	var calls []struct {
+		Ctx context.Context
+		G   types.Geofence
+	}
+	mock.lockUpdateGeofence.RLock()
+	calls = mock.calls.UpdateGeofence
+	mock.lockUpdateGeofence.RUnlock()
+	return calls
+}
@@ -0,0 +1,157 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/google/uuid"
+)
+
+var errGeofenceNotFound = fmt.Errorf("geofence not found")
+var errGeofenceAlreadyExist = fmt.Errorf("geofence already exists")
+
+func (s service) Geofences(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.Geofence]{}, ErrMissingTenant
+	}
+
+	return s.reader.GetGeofences(ctx, query)
+}
+
+func (s service) Geofence(ctx context.Context, geofenceID string, tenants []string) (types.Geofence, error) {
+	if geofenceID == "" || len(tenants) == 0 {
+		return types.Geofence{}, ErrGeofenceNotFound
+	}
+
+	result, err := s.reader.GetGeofences(ctx, dmquery.GeofenceFilters{
+		Filters: dmquery.Filters{AllowedTenants: tenants},
+		ID:      geofenceID,
+	})
+	if err != nil {
+		return types.Geofence{}, err
+	}
+
+	if result.Count != 1 {
+		return types.Geofence{}, ErrGeofenceNotFound
+	}
+
+	return result.Data[0], nil
+}
+
+// CreateGeofence stores a new geofence and returns it with its ID. A radius around a device
+// without a center is centered on where the device is installed. An ID that is taken by any
+// geofence, in any tenant, returns ErrGeofenceAlreadyExist.
+func (s service) CreateGeofence(ctx context.Context, g types.Geofence) (types.Geofence, error) {
+	if g.Tenant == "" {
+		return types.Geofence{}, ErrMissingTenant
+	}
+
+	if g.ID == "" {
+		g.ID = uuid.NewString()
+	}
+
+	g, err := s.prepareGeofence(ctx, g)
+	if err != nil {
+		return types.Geofence{}, err
+	}
+
+	err = s.writer.CreateGeofence(ctx, g)
+	if err != nil {
+		return types.Geofence{}, err
+	}
+
+	return g, nil
+}
+
+func (s service) UpdateGeofence(ctx context.Context, g types.Geofence, tenants []string) error {
+	_, err := s.Geofence(ctx, g.ID, tenants)
+	if err != nil {
+		return err
+	}
+
+	g, err = s.prepareGeofence(ctx, g)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.UpdateGeofence(ctx, g)
+}
+
+func (s service) DeleteGeofence(ctx context.Context, geofenceID string, tenants []string) error {
+	_, err := s.Geofence(ctx, geofenceID, tenants)
+	if err != nil {
+		return err
+	}
+
+	return s.writer.DeleteGeofence(ctx, geofenceID)
+}
+
+func (s service) prepareGeofence(ctx context.Context, g types.Geofence) (types.Geofence, error) {
+	if g.DeviceID != "" {
+		device, err := s.Device(ctx, g.DeviceID, []string{g.Tenant})
+		if err != nil {
+			return types.Geofence{}, err
+		}
+
+		if g.Radius > 0 && g.Center == nil && device.Location != (types.Location{}) {
+			g.Center = &device.Location
+		}
+	}
+
+	return g, g.Validate()
+}
+
+// checkGeofences raises an alarm when a device has moved outside all of its geofences and clears
+// it when the device is inside one of them again. Devices without geofences never have the alarm.
+// Only fences in the tenant of the device apply, as tags with the same name are shared by tenants.
+func (s service) checkGeofences(ctx context.Context, deviceID, tenant string, location types.Location, observedAt time.Time) {
+	if s.alarms == nil || tenant == "" {
+		return
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	result, err := s.reader.GetGeofences(ctx, dmquery.GeofenceFilters{
+		Filters: dmquery.Filters{DeviceID: deviceID, Tenant: tenant},
+	})
+	if err != nil {
+		log.Error("could not get geofences", "device_id", deviceID, "err", err.Error())
+		return
+	}
+
+	outside := []string{}
+	for _, g := range result.Data {
+		if g.Contains(location) {
+			outside = nil
+			break
+		}
+		outside = append(outside, geofenceName(g))
+	}
+
+	if len(outside) == 0 {
+		err = s.alarms.Remove(ctx, deviceID, alarms.AlarmOutsideGeofence)
+	} else {
+		err = s.alarms.Add(ctx, deviceID, types.AlarmDetails{
+			DeviceID:    deviceID,
+			AlarmType:   alarms.AlarmOutsideGeofence,
+			Description: fmt.Sprintf("%f,%f is outside %s", location.Latitude, location.Longitude, strings.Join(outside, ", ")),
+			ObservedAt:  observedAt.UTC(),
+		})
+	}
+	if err != nil {
+		log.Error("could not update geofence alarm", "device_id", deviceID, "err", err.Error())
+	}
+}
+
+func geofenceName(g types.Geofence) string {
+	if g.Name != "" {
+		return g.Name
+	}
+	return g.ID
+}
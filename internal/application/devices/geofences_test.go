@@ -0,0 +1,109 @@
+package devices
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestDeviceOutsideGeofenceRaisesAndClearsAlarm(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	device := types.Device{DeviceID: "lifebuoy-1", Tenant: "default", Location: types.Location{Latitude: 62.39, Longitude: 17.30}}
+	fence := types.Geofence{ID: "pier", Tenant: "default", Tag: "lifebuoy", Center: &types.Location{Latitude: 62.39, Longitude: 17.30}, Radius: 100}
+
+	reader := &DeviceReaderMock{
+		QueryFunc: func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+			return types.Collection[types.Device]{Count: 1, Data: []types.Device{device}}, nil
+		},
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return device, true, nil
+		},
+		GetGeofencesFunc: func(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+			return types.Collection[types.Geofence]{Count: 1, Data: []types.Geofence{fence}}, nil
+		},
+	}
+	writer := &DeviceWriterMock{
+		UpdateDeviceFunc: func(ctx context.Context, deviceID string, active *bool, name, description, environment, source, tenant *string, location *types.Location, interval *int) error {
+			device.Location = *location
+			return nil
+		},
+		AddDeviceLocationFunc: func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+			return nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		SetDeviceStateFunc: func(ctx context.Context, deviceID string, state types.DeviceState) error {
+			return nil
+		},
+	}
+	alarmService := &alarms.AlarmAPIServiceMock{
+		AddFunc: func(ctx context.Context, deviceID string, alarm types.AlarmDetails) error {
+			return nil
+		},
+		RemoveFunc: func(ctx context.Context, deviceID string, alarmType string) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, writer, statusWriter, &DeviceProfileStoreMock{}, nil, &Config{}).WithAlarms(alarmService)
+	now := time.Now().UTC()
+
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "lifebuoy-1", Tenant: "default", Location: &types.Location{Latitude: 62.40, Longitude: 17.30}, Timestamp: now})
+	is.NoErr(err)
+	is.Equal(len(alarmService.AddCalls()), 1)
+	is.Equal(alarmService.AddCalls()[0].Alarm.AlarmType, alarms.AlarmOutsideGeofence)
+	is.Equal(alarmService.AddCalls()[0].Alarm.ObservedAt, now)
+
+	err = svc.Merge(ctx, "lifebuoy-1", map[string]any{"latitude": 62.3901, "longitude": 17.30}, []string{"default"})
+	is.NoErr(err)
+	is.Equal(len(alarmService.AddCalls()), 1)
+	is.Equal(len(alarmService.RemoveCalls()), 1)
+	is.Equal(alarmService.RemoveCalls()[0].AlarmType, alarms.AlarmOutsideGeofence)
+
+	for _, call := range reader.GetGeofencesCalls() {
+		is.Equal(call.Query.Tenant, "default") // only fences in the tenant of the device apply
+	}
+}
+
+func TestCreateGeofenceCentersRadiusOnDevice(t *testing.T) {
+	is := is.New(t)
+
+	device := types.Device{DeviceID: "lifebuoy-1", Tenant: "default", Location: types.Location{Latitude: 62.39, Longitude: 17.30}}
+
+	reader := &DeviceReaderMock{
+		QueryFunc: func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+			return types.Collection[types.Device]{Count: 1, Data: []types.Device{device}}, nil
+		},
+	}
+	writer := &DeviceWriterMock{
+		CreateGeofenceFunc: func(ctx context.Context, g types.Geofence) error {
+			if g.ID == "taken" {
+				return ErrGeofenceAlreadyExist
+			}
+			return nil
+		},
+	}
+
+	svc := New(reader, writer, &DeviceStatusWriterMock{}, &DeviceProfileStoreMock{}, nil, &Config{})
+
+	g, err := svc.CreateGeofence(context.Background(), types.Geofence{Tenant: "default", DeviceID: "lifebuoy-1", Radius: 50})
+	is.NoErr(err)
+	is.True(g.ID != "")
+	is.Equal(*g.Center, device.Location)
+
+	_, err = svc.CreateGeofence(context.Background(), types.Geofence{Tenant: "default", Tag: "lifebuoy", Radius: 50})
+	is.True(err != nil) // a tag has no location to center on
+	is.Equal(len(writer.CreateGeofenceCalls()), 1)
+
+	_, err = svc.CreateGeofence(context.Background(), types.Geofence{ID: "taken", Tenant: "default", DeviceID: "lifebuoy-1", Radius: 50})
+	is.True(errors.Is(err, ErrGeofenceAlreadyExist)) // the id of a fence in another tenant is not reused
+	is.Equal(len(reader.GetGeofencesCalls()), 0)     // a new id is not looked up among the fences of the tenant
+}
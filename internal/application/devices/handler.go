@@ -3,46 +3,210 @@ package devices
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
+	"slices"
+	"time"
 
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/messaging-golang/pkg/messaging"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 var tracer = otel.Tracer("iot-device-mgmt/device")
 
-func RegisterTopicMessageHandler(ctx context.Context, svc DeviceStatusHandler, messenger messaging.MsgContext) error {
-	return messenger.RegisterTopicMessageHandler("device-status", newDeviceStatusHandler(svc))
+// rejectedStatus counts the status messages that are older than, or as old as, the
+// current state of their device, by reason.
+var rejectedStatus, _ = otel.Meter("iot-device-mgmt/device").Int64Counter(
+	"device_status_rejected",
+	metric.WithDescription("Number of out of order or duplicated device status messages"),
+)
+
+func RegisterTopicMessageHandler(ctx context.Context, svc DeviceStatusHandler, q quarantine.QuarantineService, messenger messaging.MsgContext) error {
+	return messenger.RegisterTopicMessageHandler("device-status", newDeviceStatusHandler(svc, q))
+}
+
+// QuarantineReason classifies why a device status message could not be handled.
+func QuarantineReason(err error) string {
+	if errors.Is(err, ErrDeviceNotFound) {
+		return quarantine.ReasonDeviceNotFound
+	}
+	return quarantine.ReasonHandlerFailed
 }
 
 func (s service) Handle(ctx context.Context, status types.StatusMessage) error {
-	state := types.DeviceState{
-		Online:     true,
-		State:      types.DeviceStateOK,
-		ObservedAt: status.Timestamp,
+	device, found, err := s.reader.GetDeviceState(ctx, status.DeviceID)
+	if err != nil {
+		return err
 	}
 
-	if status.Code != nil {
-		state.State = types.DeviceStateWarning
+	if !found {
+		if s.autoProvision(status.Tenant) {
+			logging.GetFromContext(ctx).Debug("discovered sensor from status message", "sensor_id", status.DeviceID)
+			return s.statusWriter.DiscoverSensor(ctx, status)
+		}
+		return ErrDeviceNotFound
+	}
+
+	state, ok := s.nextState(ctx, device, status)
+	if ok {
+		err = s.statusWriter.SetDeviceState(ctx, status.DeviceID, state)
+		if err != nil {
+			return err
+		}
+
+		if status.Location != nil {
+			err = s.moveDevice(ctx, device, status)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !hasRadioValues(status) {
+		return nil
+	}
+
+	return s.statusWriter.AddDeviceStatus(ctx, status)
+}
+
+// HandleBatch handles several status messages at once. State changes are coalesced
+// to a single update per device and the status history is written as one batch.
+// The index of each message that can not be handled on its own is passed to failed,
+// while an error is returned if the batch could not be written.
+func (s service) HandleBatch(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+	log := logging.GetFromContext(ctx)
+
+	byDevice := map[string][]int{}
+	deviceIDs := []string{}
+
+	for idx, status := range statuses {
+		if _, ok := byDevice[status.DeviceID]; !ok {
+			deviceIDs = append(deviceIDs, status.DeviceID)
+		}
+		byDevice[status.DeviceID] = append(byDevice[status.DeviceID], idx)
+	}
+
+	devices, err := s.reader.GetDeviceStates(ctx, deviceIDs)
+	if err != nil {
+		return err
+	}
+
+	states := map[string]types.DeviceState{}
+	history := []types.StatusMessage{}
+
+	for _, deviceID := range deviceIDs {
+		messages := byDevice[deviceID]
+		slices.SortStableFunc(messages, func(a, b int) int {
+			return statuses[a].Timestamp.Compare(statuses[b].Timestamp)
+		})
+
+		device, ok := devices[deviceID]
+		if !ok {
+			for _, idx := range messages {
+				status := statuses[idx]
+				if !s.autoProvision(status.Tenant) {
+					failed(idx, ErrDeviceNotFound)
+					continue
+				}
+				log.Debug("discovered sensor from status message", "sensor_id", status.DeviceID)
+				if err := s.statusWriter.DiscoverSensor(ctx, status); err != nil {
+					failed(idx, err)
+				}
+			}
+			continue
+		}
+
+		current := device
+		changed := false
+		located := -1
+
+		for _, idx := range messages {
+			status := statuses[idx]
+			if state, ok := s.nextState(ctx, device, status); ok {
+				device.DeviceState = state
+				changed = true
+				if status.Location != nil {
+					located = idx
+				}
+			}
+			if hasRadioValues(status) {
+				history = append(history, status)
+			}
+		}
+
+		if changed {
+			states[deviceID] = device.DeviceState
+		}
+
+		if located >= 0 {
+			if err := s.moveDevice(ctx, current, statuses[located]); err != nil {
+				failed(located, err)
+			}
+		}
 	}
 
-	err := s.statusWriter.SetDeviceState(ctx, status.DeviceID, state)
+	err = s.statusWriter.SetDeviceStates(ctx, states)
 	if err != nil {
 		return err
 	}
 
-	if status.BatteryLevel == nil && status.DR == nil && status.Frequency == nil && status.LoRaSNR == nil && status.RSSI == nil && status.SpreadingFactor == nil {
+	return s.statusWriter.AddDeviceStatuses(ctx, history)
+}
+
+// nextState returns the state a device gets from a status message, or false if
+// the message is older than, or as old as, the current state of the device.
+func (s service) nextState(ctx context.Context, device types.Device, status types.StatusMessage) (types.DeviceState, bool) {
+	current := device.DeviceState
+
+	if !current.ObservedAt.IsZero() && !status.Timestamp.After(current.ObservedAt) {
+		// older and duplicated messages must not overwrite the current state, but
+		// are still kept as history since sensor_status ignores duplicates.
+		s.reject(ctx, status, current.ObservedAt)
+		return current, false
+	}
+
+	return resolveState(current, stateFromStatus(s.stateMappings(), device.SensorProfile.Decoder, status)), true
+}
+
+// moveDevice updates the location of a device from a status message that reports one.
+func (s service) moveDevice(ctx context.Context, device types.Device, status types.StatusMessage) error {
+	if *status.Location == device.Location {
 		return nil
 	}
 
-	return s.statusWriter.AddDeviceStatus(ctx, status)
+	err := s.writer.UpdateDevice(ctx, device.DeviceID, nil, nil, nil, nil, nil, nil, status.Location, nil)
+	if err != nil {
+		return err
+	}
+
+	s.recordLocation(ctx, device.DeviceID, device.Tenant, device.Location, *status.Location, types.LocationSourceStatus, status.Timestamp)
+
+	return nil
+}
+
+func hasRadioValues(status types.StatusMessage) bool {
+	return status.BatteryLevel != nil || status.DR != nil || status.Frequency != nil || status.LoRaSNR != nil || status.RSSI != nil || status.SpreadingFactor != nil
+}
+
+func (s service) reject(ctx context.Context, status types.StatusMessage, observedAt time.Time) {
+	reason := "out_of_order"
+	if status.Timestamp.Equal(observedAt) {
+		reason = "duplicate"
+	}
+
+	rejectedStatus.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+
+	logging.GetFromContext(ctx).Warn("rejected device status", "device_id", status.DeviceID, "reason", reason, "timestamp", status.Timestamp, "observed_at", observedAt)
 }
 
-func newDeviceStatusHandler(svc DeviceStatusHandler) messaging.TopicMessageHandler {
+func newDeviceStatusHandler(svc DeviceStatusHandler, q quarantine.QuarantineService) messaging.TopicMessageHandler {
 	return func(ctx context.Context, itm messaging.IncomingTopicMessage, l *slog.Logger) {
 		var err error
 
@@ -54,15 +218,28 @@ func newDeviceStatusHandler(svc DeviceStatusHandler) messaging.TopicMessageHandl
 		err = json.Unmarshal(itm.Body(), &m)
 		if err != nil {
 			log.Error("failed to unmarshal message", "err", err.Error())
+			quarantineMessage(ctx, q, itm.Body(), m, quarantine.ReasonInvalidMessage, err)
 			return
 		}
 
 		ctx = logging.NewContextWithLogger(ctx, log, slog.String("device_id", m.DeviceID), slog.String("tenant", m.Tenant))
 
-		err = svc.Handle(ctx, m)
+		if h, ok := svc.(DeviceStatusMessageHandler); ok {
+			err = h.HandleMessage(ctx, itm.Body(), m)
+		} else {
+			err = svc.Handle(ctx, m)
+		}
 		if err != nil {
 			log.Error("could not add device status", "err", err.Error())
+			quarantineMessage(ctx, q, itm.Body(), m, QuarantineReason(err), err)
 			return
 		}
 	}
 }
+
+func quarantineMessage(ctx context.Context, q quarantine.QuarantineService, body []byte, m types.StatusMessage, reason string, cause error) {
+	err := q.Add(ctx, body, m, reason, cause)
+	if err != nil {
+		logging.GetFromContext(ctx).Error("could not quarantine device status", "reason", reason, "err", err.Error())
+	}
+}
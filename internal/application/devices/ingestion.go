@@ -0,0 +1,261 @@
+package devices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+var ErrIngestionStopped = errors.New("status ingestion stopped")
+
+const (
+	DefaultFlushSize     = 500
+	DefaultFlushInterval = 1000
+	DefaultBufferSize    = 5000
+)
+
+type IngestionConfig struct {
+	// FlushSize is the number of buffered messages that triggers a write.
+	FlushSize int `yaml:"flushsize"`
+	// FlushInterval is the longest time, in milliseconds, a message is buffered.
+	FlushInterval int `yaml:"flushinterval"`
+	// BufferSize is the number of messages that can be buffered before new
+	// messages are blocked until the buffer has been written.
+	BufferSize int `yaml:"buffersize"`
+}
+
+type DeviceStatusBatchHandler interface {
+	HandleBatch(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error
+}
+
+// Ingestion buffers device status messages and hands them over to a batch handler
+// when the buffer reaches the flush size, or when the flush interval has passed.
+type Ingestion interface {
+	DeviceStatusHandler
+	DeviceStatusMessageHandler
+	Start(context.Context)
+	Stop(context.Context)
+}
+
+// bufferedStatus keeps the body of a status message, if any, so that a message that
+// fails is quarantined as it was received.
+type bufferedStatus struct {
+	status types.StatusMessage
+	body   []byte
+}
+
+type ingestion struct {
+	handler    DeviceStatusBatchHandler
+	quarantine quarantine.QuarantineService
+
+	flushSize     int
+	flushInterval time.Duration
+
+	queue    chan bufferedStatus
+	mu       sync.RWMutex
+	stopped  bool
+	stopping chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+func NewIngestion(handler DeviceStatusBatchHandler, q quarantine.QuarantineService, cfg *IngestionConfig) Ingestion {
+	flushSize, flushInterval, bufferSize := DefaultFlushSize, DefaultFlushInterval, DefaultBufferSize
+	if cfg != nil {
+		if cfg.FlushSize > 0 {
+			flushSize = cfg.FlushSize
+		}
+		if cfg.FlushInterval > 0 {
+			flushInterval = cfg.FlushInterval
+		}
+		if cfg.BufferSize > 0 {
+			bufferSize = cfg.BufferSize
+		}
+	}
+
+	return &ingestion{
+		handler:       handler,
+		quarantine:    q,
+		flushSize:     flushSize,
+		flushInterval: time.Duration(flushInterval) * time.Millisecond,
+		queue:         make(chan bufferedStatus, max(bufferSize, flushSize)),
+		stopping:      make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Handle adds a status message to the buffer. If the buffer is full Handle blocks
+// until there is room for the message, or the context is cancelled.
+func (i *ingestion) Handle(ctx context.Context, status types.StatusMessage) error {
+	return i.enqueue(ctx, bufferedStatus{status: status})
+}
+
+// HandleMessage adds a status message to the buffer as Handle does, keeping the body
+// it was decoded from to quarantine if the message fails.
+func (i *ingestion) HandleMessage(ctx context.Context, body []byte, status types.StatusMessage) error {
+	return i.enqueue(ctx, bufferedStatus{status: status, body: body})
+}
+
+func (i *ingestion) enqueue(ctx context.Context, b bufferedStatus) error {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	if i.stopped {
+		return ErrIngestionStopped
+	}
+
+	select {
+	case i.queue <- b:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (i *ingestion) Start(ctx context.Context) {
+	// buffered messages are written during shutdown, after ctx has been cancelled,
+	// and are only abandoned when Stop gives up on them
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	i.mu.Lock()
+	i.cancel = cancel
+	i.mu.Unlock()
+
+	go i.run(ctx)
+}
+
+// Stop stops accepting new messages and waits until the buffered messages have
+// been written. If the context is cancelled first, the write in progress is aborted
+// and the remaining messages are dropped, but Stop still waits for the writing to
+// end so that nothing is written once Stop has returned.
+func (i *ingestion) Stop(ctx context.Context) {
+	i.mu.Lock()
+	if i.stopped {
+		i.mu.Unlock()
+		return
+	}
+	i.stopped = true
+	cancel := i.cancel
+	i.mu.Unlock()
+
+	close(i.stopping)
+
+	if cancel == nil {
+		return
+	}
+
+	select {
+	case <-i.done:
+		return
+	case <-ctx.Done():
+	}
+
+	logging.GetFromContext(ctx).Warn("device status ingestion did not finish in time, dropping buffered messages")
+
+	cancel()
+	<-i.done
+}
+
+func (i *ingestion) run(ctx context.Context) {
+	defer close(i.done)
+
+	ticker := time.NewTicker(i.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]bufferedStatus, 0, i.flushSize)
+
+	flush := func() {
+		if len(batch) > 0 {
+			i.flush(ctx, batch)
+			batch = make([]bufferedStatus, 0, i.flushSize)
+		}
+	}
+
+	for {
+		select {
+		case b := <-i.queue:
+			batch = append(batch, b)
+			if len(batch) >= i.flushSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-i.stopping:
+			// no new messages are added once stopping is closed
+			for {
+				select {
+				case b := <-i.queue:
+					batch = append(batch, b)
+					if len(batch) >= i.flushSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (i *ingestion) flush(ctx context.Context, batch []bufferedStatus) {
+	log := logging.GetFromContext(ctx)
+
+	if ctx.Err() != nil {
+		log.Warn("dropped device status batch", "count", len(batch))
+		return
+	}
+
+	statuses := make([]types.StatusMessage, len(batch))
+	for idx, b := range batch {
+		statuses[idx] = b.status
+	}
+
+	// messages that failed on their own are already quarantined if the batch fails
+	quarantined := make([]bool, len(batch))
+
+	err := i.handler.HandleBatch(ctx, statuses, func(idx int, err error) {
+		quarantined[idx] = true
+		i.failed(ctx, batch[idx], err)
+	})
+	if err != nil && ctx.Err() != nil {
+		// the write was aborted by Stop, the messages can not be quarantined either
+		log.Warn("dropped device status batch", "count", len(batch), "err", err.Error())
+		return
+	}
+	if err != nil {
+		log.Error("could not write device status batch", "count", len(batch), "err", err.Error())
+		for idx, b := range batch {
+			if !quarantined[idx] {
+				i.failed(ctx, b, err)
+			}
+		}
+		return
+	}
+
+	log.Debug("wrote device status batch", "count", len(batch))
+}
+
+func (i *ingestion) failed(ctx context.Context, b bufferedStatus, cause error) {
+	if i.quarantine == nil {
+		return
+	}
+
+	body := b.body
+	if body == nil {
+		var err error
+		body, err = json.Marshal(b.status)
+		if err != nil {
+			logging.GetFromContext(ctx).Error("could not marshal device status", "device_id", b.status.DeviceID, "err", err.Error())
+			return
+		}
+	}
+
+	quarantineMessage(ctx, i.quarantine, body, b.status, QuarantineReason(cause), cause)
+}
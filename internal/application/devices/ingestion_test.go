@@ -0,0 +1,207 @@
+package devices
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestHandleBatchCoalescesStatePerDevice(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	reader := &DeviceReaderMock{
+		GetDeviceStatesFunc: func(ctx context.Context, deviceIDs []string) (map[string]types.Device, error) {
+			devices := map[string]types.Device{}
+			for _, id := range deviceIDs {
+				if id != "missing" {
+					devices[id] = types.Device{DeviceID: id, DeviceState: types.DeviceState{ObservedAt: ts}}
+				}
+			}
+			return devices, nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		SetDeviceStatesFunc: func(ctx context.Context, states map[string]types.DeviceState) error {
+			return nil
+		},
+		AddDeviceStatusesFunc: func(ctx context.Context, statuses []types.StatusMessage) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, &DeviceWriterMock{}, statusWriter, &DeviceProfileStoreMock{}, nil, &Config{StateMappings: testStateMappings})
+
+	code := "leak"
+	rssi := -80.0
+
+	failed := []int{}
+	err := svc.HandleBatch(ctx, []types.StatusMessage{
+		{DeviceID: "a", Timestamp: ts.Add(2 * time.Minute), RSSI: &rssi},
+		{DeviceID: "a", Code: &code, Timestamp: ts.Add(time.Minute)},
+		{DeviceID: "a", Timestamp: ts.Add(-time.Minute), RSSI: &rssi},
+		{DeviceID: "b", Timestamp: ts.Add(time.Minute)},
+		{DeviceID: "missing", Timestamp: ts},
+	}, func(idx int, err error) {
+		failed = append(failed, idx)
+		is.Equal(err, ErrDeviceNotFound)
+	})
+	is.NoErr(err)
+
+	is.Equal(len(reader.GetDeviceStatesCalls()), 1) // all devices of the batch are read at once
+	is.Equal(len(reader.GetDeviceStatesCalls()[0].DeviceIDs), 3)
+	is.Equal(failed, []int{4}) // the message of the missing device
+
+	is.Equal(len(statusWriter.SetDeviceStatesCalls()), 1)
+	states := statusWriter.SetDeviceStatesCalls()[0].States
+	is.Equal(len(states), 2)
+	is.Equal(states["a"].ObservedAt, ts.Add(2*time.Minute))
+	// the leak persists even though the latest message carries no code
+	is.Equal(states["a"].State, types.DeviceStateError)
+	is.Equal(states["b"].State, types.DeviceStateOK)
+
+	is.Equal(len(statusWriter.AddDeviceStatusesCalls()), 1)
+	is.Equal(len(statusWriter.AddDeviceStatusesCalls()[0].Statuses), 2)
+}
+
+func TestIngestionFlushesBySizeAndDrainsOnStop(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	batches := [][]types.StatusMessage{}
+
+	handler := &batchHandler{
+		handle: func(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+			mu.Lock()
+			defer mu.Unlock()
+			batches = append(batches, statuses)
+			return nil
+		},
+	}
+
+	i := NewIngestion(handler, &quarantine.QuarantineServiceMock{}, &IngestionConfig{FlushSize: 2, FlushInterval: 60000})
+	i.Start(ctx)
+
+	for _, id := range []string{"a", "b", "c"} {
+		is.NoErr(i.Handle(ctx, types.StatusMessage{DeviceID: id, Timestamp: time.Now()}))
+	}
+
+	i.Stop(ctx)
+
+	is.Equal(i.Handle(ctx, types.StatusMessage{DeviceID: "d"}), ErrIngestionStopped)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	is.Equal(len(batches), 2)
+	is.Equal(len(batches[0]), 2)
+	is.Equal(batches[1][0].DeviceID, "c")
+}
+
+func TestIngestionQuarantinesFailedBatch(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	handler := &batchHandler{
+		handle: func(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+			return context.DeadlineExceeded
+		},
+	}
+	q := &quarantine.QuarantineServiceMock{
+		AddFunc: func(ctx context.Context, body []byte, status types.StatusMessage, reason string, cause error) error {
+			return nil
+		},
+	}
+
+	i := NewIngestion(handler, q, &IngestionConfig{FlushSize: 10, FlushInterval: 10})
+	i.Start(ctx)
+
+	is.NoErr(i.Handle(ctx, types.StatusMessage{DeviceID: "a", Timestamp: time.Now()}))
+	i.Stop(ctx)
+
+	is.Equal(len(q.AddCalls()), 1)
+	is.Equal(q.AddCalls()[0].Reason, quarantine.ReasonHandlerFailed)
+	is.Equal(q.AddCalls()[0].Status.DeviceID, "a")
+}
+
+func TestIngestionQuarantinesReceivedBodyOnce(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	handler := &batchHandler{
+		handle: func(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+			failed(0, ErrDeviceNotFound)
+			return context.DeadlineExceeded
+		},
+	}
+	q := &quarantine.QuarantineServiceMock{
+		AddFunc: func(ctx context.Context, body []byte, status types.StatusMessage, reason string, cause error) error {
+			return nil
+		},
+	}
+
+	i := NewIngestion(handler, q, &IngestionConfig{FlushSize: 2, FlushInterval: 60000})
+	i.Start(ctx)
+
+	body := []byte(`{"deviceID":"a","timestamp":"2025-01-01T12:00:00Z","unknown":true}`)
+	is.NoErr(i.HandleMessage(ctx, body, types.StatusMessage{DeviceID: "a"}))
+	is.NoErr(i.Handle(ctx, types.StatusMessage{DeviceID: "b"}))
+	i.Stop(ctx)
+
+	is.Equal(len(q.AddCalls()), 2) // the failed message is not quarantined again with the batch
+	is.Equal(q.AddCalls()[0].Reason, quarantine.ReasonDeviceNotFound)
+	is.Equal(string(q.AddCalls()[0].Body), string(body))
+	is.Equal(q.AddCalls()[1].Status.DeviceID, "b")
+	is.Equal(q.AddCalls()[1].Reason, quarantine.ReasonHandlerFailed)
+}
+
+func TestIngestionStopWaitsForAbortedFlush(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	finished := false
+
+	handler := &batchHandler{
+		handle: func(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			finished = true
+			return ctx.Err()
+		},
+	}
+	q := &quarantine.QuarantineServiceMock{}
+
+	i := NewIngestion(handler, q, &IngestionConfig{FlushSize: 1, FlushInterval: 60000})
+	i.Start(ctx)
+
+	is.NoErr(i.Handle(ctx, types.StatusMessage{DeviceID: "a", Timestamp: time.Now()}))
+	is.NoErr(i.Handle(ctx, types.StatusMessage{DeviceID: "b", Timestamp: time.Now()}))
+
+	stopCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	i.Stop(stopCtx)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	is.True(finished)              // nothing is written once Stop has returned
+	is.Equal(len(q.AddCalls()), 0) // aborted messages are dropped, not quarantined
+}
+
+type batchHandler struct {
+	handle func(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error
+}
+
+func (h *batchHandler) HandleBatch(ctx context.Context, statuses []types.StatusMessage, failed func(int, error)) error {
+	return h.handle(ctx, statuses, failed)
+}
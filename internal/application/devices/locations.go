@@ -0,0 +1,51 @@
+package devices
+
+import (
+	"context"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+type locationSourceKey struct{}
+
+// WithLocationSource marks location changes made with the returned context as coming from
+// source, one of the types.LocationSource values. Changes are made through the API by default.
+func WithLocationSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, locationSourceKey{}, source)
+}
+
+func locationSource(ctx context.Context) string {
+	if source, ok := ctx.Value(locationSourceKey{}).(string); ok {
+		return source
+	}
+	return types.LocationSourceAPI
+}
+
+func (s service) Locations(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error) {
+	_, err := s.Device(ctx, deviceID, query.AllowedTenants)
+	if err != nil {
+		return types.Collection[types.DeviceLocation]{}, err
+	}
+
+	return s.reader.GetDeviceLocations(ctx, deviceID, query)
+}
+
+// recordLocation adds a location to the history of a device unless the device has not moved,
+// and checks the new location against the geofences of the device in its tenant. Devices without a location
+// are stored at 0,0 which is not recorded. The device itself has already been updated, so a
+// failure is logged rather than returned.
+func (s service) recordLocation(ctx context.Context, deviceID, tenant string, previous, location types.Location, source string, observedAt time.Time) {
+	if location == previous || location == (types.Location{}) {
+		return
+	}
+
+	err := s.writer.AddDeviceLocation(ctx, deviceID, location, source, observedAt)
+	if err != nil {
+		logging.GetFromContext(ctx).Error("could not record device location", "device_id", deviceID, "err", err.Error())
+	}
+
+	s.checkGeofences(ctx, deviceID, tenant, location, observedAt)
+}
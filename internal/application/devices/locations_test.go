@@ -0,0 +1,87 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestHandleRecordsLocationFromStatus(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	device := types.Device{DeviceID: "lifebuoy-1", Location: types.Location{Latitude: 62.39, Longitude: 17.30}}
+	moved := types.Location{Latitude: 62.40, Longitude: 17.31}
+	now := time.Now().UTC()
+
+	reader := &DeviceReaderMock{
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return device, true, nil
+		},
+	}
+	writer := &DeviceWriterMock{
+		UpdateDeviceFunc: func(ctx context.Context, deviceID string, active *bool, name, description, environment, source, tenant *string, location *types.Location, interval *int) error {
+			return nil
+		},
+		AddDeviceLocationFunc: func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+			return nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		SetDeviceStateFunc: func(ctx context.Context, deviceID string, state types.DeviceState) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, writer, statusWriter, &DeviceProfileStoreMock{}, nil, &Config{})
+
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "lifebuoy-1", Tenant: "default", Location: &moved, Timestamp: now})
+	is.NoErr(err)
+	is.Equal(len(writer.UpdateDeviceCalls()), 1)
+	is.Equal(*writer.UpdateDeviceCalls()[0].Location, moved)
+	is.Equal(len(writer.AddDeviceLocationCalls()), 1)
+	is.Equal(writer.AddDeviceLocationCalls()[0].Source, types.LocationSourceStatus)
+	is.Equal(writer.AddDeviceLocationCalls()[0].ObservedAt, now)
+
+	// a device that reports where it already is has not moved
+	err = svc.Handle(ctx, types.StatusMessage{DeviceID: "lifebuoy-1", Tenant: "default", Location: &device.Location, Timestamp: now.Add(time.Minute)})
+	is.NoErr(err)
+	is.Equal(len(writer.AddDeviceLocationCalls()), 1)
+}
+
+func TestLocationSourceFromContext(t *testing.T) {
+	is := is.New(t)
+
+	reader := &DeviceReaderMock{
+		QueryFunc: func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+			return types.Collection[types.Device]{}, nil
+		},
+	}
+	writer := &DeviceWriterMock{
+		CreateOrUpdateDeviceFunc: func(ctx context.Context, d types.Device) error {
+			return nil
+		},
+		AddDeviceLocationFunc: func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, writer, &DeviceStatusWriterMock{}, &DeviceProfileStoreMock{}, nil, &Config{})
+
+	err := svc.Create(context.Background(), types.Device{DeviceID: "device-1"})
+	is.NoErr(err)
+	is.Equal(len(writer.AddDeviceLocationCalls()), 0) // devices without a location are not recorded
+
+	located := types.Device{DeviceID: "device-2", Location: types.Location{Latitude: 62.39, Longitude: 17.30}}
+
+	err = svc.Create(context.Background(), located)
+	is.NoErr(err)
+	err = svc.Create(WithLocationSource(context.Background(), types.LocationSourceImport), located)
+	is.NoErr(err)
+	is.Equal(writer.AddDeviceLocationCalls()[0].Source, types.LocationSourceAPI)
+	is.Equal(writer.AddDeviceLocationCalls()[1].Source, types.LocationSourceImport)
+}
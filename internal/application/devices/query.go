@@ -12,6 +12,16 @@ import (
 var errDeviceNotFound = fmt.Errorf("device not found")
 var errDeviceProfileNotFound = fmt.Errorf("device profile not found")
 var errMissingTenant = fmt.Errorf("missing tenant")
+var errInvalidBucket = fmt.Errorf("invalid bucket, must be %s or %s", types.BucketHour, types.BucketDay)
+var errInvalidAggregate = fmt.Errorf("invalid aggregate")
+var errInvalidCoverage = fmt.Errorf("coverage requires bounds and a grid size between 1 and %d", MaxCoverageSize)
+var errInvalidClusters = fmt.Errorf("clusters require bounds and a zoom level between 0 and %d", MaxClusterZoom)
+
+// MaxCoverageSize is the largest number of grid cells along each side of a coverage map.
+const MaxCoverageSize = 100
+
+// MaxClusterZoom is the highest web map zoom level that devices are clustered at.
+const MaxClusterZoom = 22
 
 func (s service) DeviceBySensor(ctx context.Context, sensorID string, tenants []string) (types.Device, error) {
 	d, found, err := s.reader.GetDeviceBySensorID(ctx, sensorID)
@@ -30,11 +40,14 @@ func (s service) DeviceBySensor(ctx context.Context, sensorID string, tenants []
 	return types.Device{}, ErrDeviceNotFound
 }
 
-func (s service) Device(ctx context.Context, deviceID string, tenants []string) (types.Device, error) {
-	result, err := s.reader.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{
-		DeviceID:       deviceID,
-		AllowedTenants: tenants,
-	}})
+func (s service) Device(ctx context.Context, deviceID string, tenants []string, include ...string) (types.Device, error) {
+	result, err := s.reader.Query(ctx, dmquery.DeviceFilters{
+		Filters: dmquery.Filters{
+			DeviceID:       deviceID,
+			AllowedTenants: tenants,
+		},
+		Include: include,
+	})
 	if err != nil {
 		return types.Device{}, err
 	}
@@ -58,6 +71,22 @@ func (s service) Status(ctx context.Context, deviceID string, query dmquery.Stat
 	return s.reader.GetDeviceStatus(ctx, deviceID, query)
 }
 
+func (s service) StatusAggregates(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error) {
+	if deviceID == "" {
+		return types.Collection[types.SensorStatusAggregate]{}, ErrDeviceNotFound
+	}
+
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.SensorStatusAggregate]{}, ErrMissingTenant
+	}
+
+	if query.Bucket != types.BucketHour && query.Bucket != types.BucketDay {
+		return types.Collection[types.SensorStatusAggregate]{}, ErrInvalidBucket
+	}
+
+	return s.reader.GetDeviceStatusAggregates(ctx, deviceID, query)
+}
+
 func (s service) Alarms(ctx context.Context, deviceID string, tenants []string) (types.Collection[types.AlarmDetails], error) {
 	_, err := s.Device(ctx, deviceID, tenants)
 	if err != nil {
@@ -71,6 +100,20 @@ func (s service) Query(ctx context.Context, query dmquery.DeviceFilters) (types.
 	return s.reader.Query(ctx, query)
 }
 
+// Stats counts the devices that match the query by each of the facets, or by all facets if
+// none are given.
+func (s service) Stats(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.DeviceStats{}, ErrMissingTenant
+	}
+
+	if len(query.Facets) == 0 {
+		query.Facets = dmquery.Facets
+	}
+
+	return s.reader.GetDeviceStats(ctx, query)
+}
+
 func (s service) Tenants(ctx context.Context) (types.Collection[string], error) {
 	return s.reader.GetTenants(ctx)
 }
@@ -160,5 +203,45 @@ func (s service) Measurements(ctx context.Context, deviceID string, query dmquer
 		return types.Collection[types.Measurement]{}, ErrMissingTenant
 	}
 
+	if query.Bucket != "" && query.Bucket != types.BucketHour && query.Bucket != types.BucketDay {
+		return types.Collection[types.Measurement]{}, ErrInvalidBucket
+	}
+
+	if query.Aggregate != "" && (query.Bucket == "" || !slices.Contains(dmquery.MeasurementAggregates, query.Aggregate)) {
+		return types.Collection[types.Measurement]{}, ErrInvalidAggregate
+	}
+
 	return s.reader.GetDeviceMeasurements(ctx, deviceID, query)
 }
+
+func (s service) BatteryForecasts(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.BatteryForecast]{}, ErrMissingTenant
+	}
+
+	return s.reader.GetBatteryForecasts(ctx, query)
+}
+
+func (s service) Coverage(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error) {
+	if len(query.AllowedTenants) == 0 {
+		return nil, ErrMissingTenant
+	}
+
+	if query.Bounds == nil || query.Size < 1 || query.Size > MaxCoverageSize {
+		return nil, ErrInvalidCoverage
+	}
+
+	return s.reader.GetCoverage(ctx, query)
+}
+
+func (s service) Clusters(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+	if len(query.AllowedTenants) == 0 {
+		return nil, ErrMissingTenant
+	}
+
+	if query.Bounds == nil || query.Zoom < 0 || query.Zoom > MaxClusterZoom {
+		return nil, ErrInvalidClusters
+	}
+
+	return s.reader.GetDeviceClusters(ctx, query)
+}
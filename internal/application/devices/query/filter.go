@@ -0,0 +1,469 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// FilterError is returned by ParseFilter with the position, counted in characters from 1,
+// where the expression could not be parsed or validated.
+type FilterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("%s: %s at position %d", ErrInvalidFilter.Error(), e.Msg, e.Pos)
+}
+
+func (e *FilterError) Unwrap() error {
+	return ErrInvalidFilter
+}
+
+type FieldKind int
+
+const (
+	FieldString FieldKind = iota
+	FieldNumber
+	FieldBool
+	FieldTime
+	// FieldSet is a string field with any number of values per device, such as tags.
+	FieldSet
+	// FieldMetadata is a metadata key, written as metadata.<key>, compared as a string,
+	// number or boolean depending on the value.
+	FieldMetadata
+)
+
+// FilterFields are the fields that can be used in a filter expression.
+var FilterFields = map[string]FieldKind{
+	"deviceID":     FieldString,
+	"sensorID":     FieldString,
+	"name":         FieldString,
+	"description":  FieldString,
+	"environment":  FieldString,
+	"source":       FieldString,
+	"tenant":       FieldString,
+	"profile":      FieldString,
+	"active":       FieldBool,
+	"online":       FieldBool,
+	"state":        FieldNumber,
+	"batteryLevel": FieldNumber,
+	"rssi":         FieldNumber,
+	"snr":          FieldNumber,
+	"lastSeen":     FieldTime,
+	"tag":          FieldSet,
+	"alarm":        FieldSet,
+	"metadata":     FieldMetadata,
+}
+
+const maxFilterLength = 2048
+const maxFilterDepth = 32
+
+// Expr is a parsed filter expression, one of And, Or, Not or Comparison.
+type Expr interface {
+	expr()
+}
+
+type And struct {
+	Left, Right Expr
+}
+
+type Or struct {
+	Left, Right Expr
+}
+
+type Not struct {
+	Expr Expr
+}
+
+// Comparison compares a field with one value, or with a list of values for the in operator.
+// Values are strings, float64, bool or time.Time for FieldTime.
+type Comparison struct {
+	Field  string
+	Kind   FieldKind
+	Key    string // the metadata key
+	Op     string
+	Values []any
+}
+
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+func (Comparison) expr() {}
+
+// ParseFilter parses a filter expression such as
+//
+//	(environment == "water" || tag in ["pilot"]) && batteryLevel < 20 && metadata.zone != "north"
+//
+// Comparisons are combined with &&, || and !, where && binds harder than ||.
+func ParseFilter(s string) (Expr, error) {
+	if len(s) > maxFilterLength {
+		return nil, &FilterError{Pos: maxFilterLength + 1, Msg: fmt.Sprintf("expression is longer than %d characters", maxFilterLength)}
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	e, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+
+	return e, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ","}
+
+func lex(s string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			j := i + 1
+			for ; j < len(runes) && runes[j] != '"'; j++ {
+				if runes[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(runes) {
+				return nil, &FilterError{Pos: pos, Msg: "unterminated string"}
+			}
+			text, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, &FilterError{Pos: pos, Msg: "invalid string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: text, pos: pos})
+			i = j + 1
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i + 1
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[i:j]), pos: pos})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || strings.ContainsRune("_.-", runes[j])) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(string(runes[i:]), o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &FilterError{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokenEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(op string) bool {
+	if t := p.peek(); t.kind == tokenOp && t.text == op {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &FilterError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) or(depth int) (Expr, error) {
+	left, err := p.and(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("||") {
+		right, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) and(depth int) (Expr, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.accept("&&") {
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) unary(depth int) (Expr, error) {
+	if depth >= maxFilterDepth {
+		return nil, p.errorf(p.peek(), "expression is nested deeper than %d levels", maxFilterDepth)
+	}
+
+	if p.accept("!") {
+		e, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: e}, nil
+	}
+
+	if t := p.peek(); p.accept("(") {
+		e, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf(p.peek(), "expected \")\" to close \"(\" at position %d, got %s", t.pos, p.peek())
+		}
+		return e, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *parser) comparison() (Expr, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return nil, p.errorf(t, "expected a field, got %s", t)
+	}
+
+	c, err := filterField(t)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	var positions []int
+
+	switch {
+	case op.kind == tokenIdent && op.text == "in":
+		c.Op = "in"
+		c.Values, positions, err = p.list()
+	case op.kind == tokenOp && slices.Contains([]string{"==", "!=", "<", "<=", ">", ">="}, op.text):
+		c.Op = op.text
+		var v any
+		var pos int
+		v, pos, err = p.value()
+		c.Values, positions = []any{v}, []int{pos}
+	default:
+		return nil, p.errorf(op, "expected an operator after %s, got %s", t, op)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return c, validateComparison(c, op.pos, positions)
+}
+
+// list parses the values of an in operator and returns them with their positions.
+func (p *parser) list() ([]any, []int, error) {
+	if t := p.next(); t.kind != tokenOp || t.text != "[" {
+		return nil, nil, p.errorf(t, "expected \"[\" after in, got %s", t)
+	}
+
+	values := []any{}
+	positions := []int{}
+	for {
+		v, pos, err := p.value()
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, v)
+		positions = append(positions, pos)
+
+		if p.accept("]") {
+			return values, positions, nil
+		}
+		if t := p.next(); t.kind != tokenOp || t.text != "," {
+			return nil, nil, p.errorf(t, "expected \",\" or \"]\" in list, got %s", t)
+		}
+	}
+}
+
+func (p *parser) value() (any, int, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString:
+		return t.text, t.pos, nil
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, 0, p.errorf(t, "invalid number %s", t)
+		}
+		return f, t.pos, nil
+	case tokenIdent:
+		if t.text == "true" || t.text == "false" {
+			return t.text == "true", t.pos, nil
+		}
+	}
+
+	return nil, 0, p.errorf(t, "expected a string, number or boolean, got %s", t)
+}
+
+// filterField looks up a field case insensitively. Metadata keys keep their case.
+func filterField(t token) (Comparison, error) {
+	name, key, _ := strings.Cut(t.text, ".")
+
+	for field, kind := range FilterFields {
+		if !strings.EqualFold(field, name) {
+			continue
+		}
+		if (kind == FieldMetadata) != (key != "") {
+			break
+		}
+		return Comparison{Field: field, Kind: kind, Key: key}, nil
+	}
+
+	return Comparison{}, &FilterError{Pos: t.pos, Msg: fmt.Sprintf("unknown field %s", t)}
+}
+
+// validateComparison checks the operator and the type of each value against the kind of field.
+// Operator errors are reported at the operator and value errors at the value.
+func validateComparison(c Comparison, opPos int, positions []int) error {
+	invalid := func(pos int, format string, args ...any) error {
+		return &FilterError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+	}
+
+	ordered := c.Op != "==" && c.Op != "!=" && c.Op != "in"
+
+	switch {
+	case (c.Kind == FieldString || c.Kind == FieldSet) && ordered:
+		return invalid(opPos, "%s can not be compared with %s", c.Field, c.Op)
+	case c.Kind == FieldBool && c.Op != "==" && c.Op != "!=":
+		return invalid(opPos, "%s can only be compared using == or !=", c.Field)
+	case c.Kind == FieldTime && c.Op == "in":
+		return invalid(opPos, "%s can not be compared with a list", c.Field)
+	}
+
+	for i, v := range c.Values {
+		pos := positions[i]
+
+		switch c.Kind {
+		case FieldString, FieldSet:
+			if _, ok := v.(string); !ok {
+				return invalid(pos, "%s must be compared with strings", c.Field)
+			}
+		case FieldNumber:
+			if _, ok := v.(float64); !ok {
+				return invalid(pos, "%s must be compared with numbers", c.Field)
+			}
+		case FieldBool:
+			if _, ok := v.(bool); !ok {
+				return invalid(pos, "%s must be compared with true or false", c.Field)
+			}
+		case FieldTime:
+			s, ok := v.(string)
+			if !ok {
+				return invalid(pos, "%s must be compared with a date or time", c.Field)
+			}
+			t, err := parseFilterTime(s)
+			if err != nil {
+				return invalid(pos, "invalid time %q for %s", s, c.Field)
+			}
+			c.Values[i] = t
+		case FieldMetadata:
+			if _, ok := v.(float64); !ok && ordered {
+				return invalid(pos, "metadata.%s can only be compared with %s using a number", c.Key, c.Op)
+			}
+			if _, ok := v.(bool); ok && c.Op == "in" {
+				return invalid(pos, "metadata.%s can not be compared with a list of booleans", c.Key)
+			}
+			if fmt.Sprintf("%T", v) != fmt.Sprintf("%T", c.Values[0]) {
+				return invalid(pos, "the values in a list must all be of the same type")
+			}
+		}
+	}
+
+	return nil
+}
+
+func parseFilterTime(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time")
+}
@@ -0,0 +1,79 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestParseFilter(t *testing.T) {
+	is := is.New(t)
+
+	e, err := ParseFilter(`(environment == "water" || tag in ["pilot", "beta"]) && batteryLevel < 20 && !(metadata.zone != "north")`)
+	is.NoErr(err)
+
+	and, ok := e.(And)
+	is.True(ok)
+
+	not, ok := and.Right.(Not)
+	is.True(ok)
+	is.Equal(not.Expr, Comparison{Field: "metadata", Kind: FieldMetadata, Key: "zone", Op: "!=", Values: []any{"north"}})
+
+	and, ok = and.Left.(And)
+	is.True(ok)
+	is.Equal(and.Right, Comparison{Field: "batteryLevel", Kind: FieldNumber, Op: "<", Values: []any{20.0}})
+
+	or, ok := and.Left.(Or)
+	is.True(ok)
+	is.Equal(or.Left, Comparison{Field: "environment", Kind: FieldString, Op: "==", Values: []any{"water"}})
+	is.Equal(or.Right, Comparison{Field: "tag", Kind: FieldSet, Op: "in", Values: []any{"pilot", "beta"}})
+}
+
+func TestParseFilterPrecedenceAndFieldCase(t *testing.T) {
+	is := is.New(t)
+
+	e, err := ParseFilter(`ACTIVE == true || online == false && lastSeen >= "2026-10-01"`)
+	is.NoErr(err)
+
+	or, ok := e.(Or)
+	is.True(ok)
+	is.Equal(or.Left, Comparison{Field: "active", Kind: FieldBool, Op: "==", Values: []any{true}})
+
+	and, ok := or.Right.(And)
+	is.True(ok)
+	is.Equal(and.Right, Comparison{Field: "lastSeen", Kind: FieldTime, Op: ">=", Values: []any{time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}})
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	is := is.New(t)
+
+	invalid := map[string]int{
+		`batteryLevel < `:               16,
+		`batteryLevel < 20 &&`:          21,
+		`(name == "a" || name == "b"`:   28,
+		`colour == "red"`:               1,
+		`metadata == "north"`:           1,
+		`name == "unterminated`:         9,
+		`name < "b"`:                    6,
+		`batteryLevel == "low"`:         17,
+		`active in [true]`:              8,
+		`tag in ["a", 1]`:               14,
+		`metadata.zone > "north"`:       17,
+		`metadata.zone in ["north", 1]`: 28,
+		`lastSeen > "yesterday"`:        12,
+		`name == "a" name == "b"`:       13,
+		`name == "a" # name == "b"`:     13,
+		`name = "a"`:                    6,
+	}
+
+	for expr, pos := range invalid {
+		_, err := ParseFilter(expr)
+
+		var filterErr *FilterError
+		is.True(errors.As(err, &filterErr)) // expected a FilterError
+		is.True(errors.Is(err, ErrInvalidFilter))
+		is.Equal(filterErr.Pos, pos) // position of the error
+	}
+}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"slices"
 	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
@@ -17,26 +18,148 @@ type Filters struct {
 	ProfileNames   []string
 	Metadata       map[string]string
 	LastSeen       *time.Time
-	Search         string
-	Bounds         *types.Bounds
-	Name           string
-	Urn            string
-	Export         bool
-	SortBy         string
-	SortDesc       bool
-	Offset         *int
-	Limit          *int
+	// BatteryDepletesBefore matches devices whose battery is expected to be depleted before the time.
+	BatteryDepletesBefore *time.Time
+	// LinkQuality matches devices whose link is classified as one of the classes.
+	LinkQuality []string
+	Search      string
+	// Filter is a parsed filter expression, see ParseFilter, matched in addition to the other filters.
+	Filter Expr
+	Bounds *types.Bounds
+	// Within matches locations inside any of the polygons.
+	Within []types.Polygon
+	// Near and Radius, in meters, match locations within the radius. Near also allows
+	// sorting by distance.
+	Near   *types.Location
+	Radius float64
+	// LocatedWithin matches devices that were inside any of the polygons at the time At.
+	LocatedWithin []types.Polygon
+	At            *time.Time
+	Name          string
+	Urn           string
+	Export        bool
+	SortBy        string
+	SortDesc      bool
+	Offset        *int
+	Limit         *int
+	// Cursor pages by the sort key and device id instead of by offset, starting after the cursor.
+	Cursor *types.Cursor
 }
 
 type DeviceFilters struct {
 	Filters
 	Urns []string
+	// Include lists related data, see Includes, that is embedded in each device.
+	Include []string
+	// Facets lists the fields, see Facets, by which matching devices are counted.
+	Facets []string
+	// Fields limits devices to the fields, see DeviceFields, when not empty.
+	Fields []string
+}
+
+const (
+	IncludeLatestStatus       = "latestStatus"
+	IncludeLatestMeasurements = "latestMeasurements"
+	IncludeAlarms             = "alarms"
+)
+
+var Includes = []string{IncludeLatestStatus, IncludeLatestMeasurements, IncludeAlarms}
+
+const (
+	FacetTenant      = "tenant"
+	FacetProfile     = "profile"
+	FacetEnvironment = "environment"
+	FacetOnline      = "online"
+	FacetState       = "state"
+	FacetActive      = "active"
+	FacetAlarm       = "alarm"
+)
+
+var Facets = []string{FacetTenant, FacetProfile, FacetEnvironment, FacetOnline, FacetState, FacetActive, FacetAlarm}
+
+// DeviceFields are the fields of a device, by their JSON names, that a query can be limited to.
+var DeviceFields = []string{
+	"deviceID", "sensorID", "active", "name", "description", "location", "environment", "source", "tenant",
+	"interval", "calendar", "types", "tags", "metadata", "deviceState", "sensorProfile", "sensorStatus",
+	"alarms", "batteryForecast", "linkQuality", "latestMeasurements", "alarmDetails",
+}
+
+// HasField reports whether the field is part of the devices, which is every field unless
+// the query is limited by Fields.
+func (f DeviceFilters) HasField(field string) bool {
+	return len(f.Fields) == 0 || slices.Contains(f.Fields, field)
 }
 
 type StatusFilters struct {
 	Filters
+	From *time.Time
+	To   *time.Time
+	// Bucket aggregates the statuses by hour (1h) or day (1d).
+	Bucket string
 }
 
 type MeasurementFilters struct {
 	Filters
+	// MeasurementID matches the full measurement id, or the part after the device id.
+	MeasurementID string
+	From          *time.Time
+	To            *time.Time
+	// Latest returns only the latest value of each measurement id.
+	Latest bool
+	// Bucket aggregates the values of each measurement id by hour (1h) or day (1d).
+	Bucket string
+	// Aggregate is one of MeasurementAggregates and defaults to avg.
+	Aggregate string
+}
+
+var MeasurementAggregates = []string{"avg", "min", "max", "sum", "count", "first", "last"}
+
+type BatteryFilters struct {
+	Filters
+	// DepletesBefore matches forecasts that are expected to reach the threshold before the time.
+	DepletesBefore *time.Time
+}
+
+type CoverageFilters struct {
+	Filters
+	// Size is the number of grid cells along each side of the bounds.
+	Size int
+}
+
+type LocationFilters struct {
+	Filters
+	From *time.Time
+	To   *time.Time
+}
+
+// GeofenceFilters matches geofences by ID or Tag. DeviceID matches the fences of the device
+// itself and those of its tags.
+type GeofenceFilters struct {
+	Filters
+	ID  string
+	Tag string
+}
+
+// ClusterCellsPerTile is the number of cluster grid cells along each side of a map tile.
+const ClusterCellsPerTile = 4
+
+type ClusterFilters struct {
+	Filters
+	// Zoom is the web map zoom level. The world is divided into 2^zoom tiles along each side,
+	// and each tile into ClusterCellsPerTile cells.
+	Zoom int
+}
+
+type AvailabilityFilters struct {
+	Filters
+	From time.Time
+	To   time.Time
+	// Gap is the number of intervals without messages that makes up a gap.
+	Gap int
+}
+
+type UptimeFilters struct {
+	Filters
+	// Month is any time within the calendar month, in UTC, that the report covers.
+	Month time.Time
 }
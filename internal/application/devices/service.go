@@ -2,7 +2,10 @@ package devices
 
 import (
 	"context"
+	"slices"
+	"time"
 
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/messaging-golang/pkg/messaging"
@@ -13,18 +16,39 @@ var ErrDeviceAlreadyExist = errDeviceAlreadyExist
 var ErrDeviceProfileNotFound = errDeviceProfileNotFound
 var ErrMissingTenant = errMissingTenant
 var ErrInvalidPatch = errInvalidPatch
+var ErrInvalidBucket = errInvalidBucket
+var ErrInvalidAggregate = errInvalidAggregate
+var ErrInvalidCoverage = errInvalidCoverage
+var ErrInvalidClusters = errInvalidClusters
+var ErrInvalidAvailability = errInvalidAvailability
+var ErrInvalidUptime = errInvalidUptime
 var ErrSensorNotFound = errSensorNotFound
 var ErrSensorAlreadyAssigned = errSensorAlreadyAssigned
 var ErrSensorProfileRequired = errSensorProfileRequired
+var ErrInvalidCalendar = types.ErrInvalidCalendar
+var ErrGeofenceNotFound = errGeofenceNotFound
+var ErrGeofenceAlreadyExist = errGeofenceAlreadyExist
+var ErrInvalidGeofence = types.ErrInvalidGeofence
 
 type DeviceReader interface {
 	Query(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error)
 	GetDeviceBySensorID(ctx context.Context, sensorID string) (types.Device, bool, error)
+	GetDeviceState(ctx context.Context, deviceID string) (types.Device, bool, error)
+	GetDeviceStates(ctx context.Context, deviceIDs []string) (map[string]types.Device, error)
 	GetSensor(ctx context.Context, sensorID string) (types.Sensor, bool, error)
 	GetTenants(ctx context.Context) (types.Collection[string], error)
 	GetDeviceAlarms(ctx context.Context, deviceID string) (types.Collection[types.AlarmDetails], error)
 	GetDeviceMeasurements(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error)
+	GetBatteryForecasts(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error)
+	GetCoverage(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error)
+	GetDeviceClusters(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error)
+	GetDeviceStats(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error)
+	GetStatusTimestamps(ctx context.Context, sensorIDs []string, from, to time.Time) (map[string][]time.Time, error)
+	GetDeviceLocations(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error)
+	GetGeofences(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error)
+	GetDeviceHistory(ctx context.Context, tenant string, allowedTenants []string, from, to time.Time) ([]types.DeviceHistory, error)
 	GetDeviceStatus(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatus], error)
+	GetDeviceStatusAggregates(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error)
 }
 
 type DeviceWriter interface {
@@ -33,11 +57,18 @@ type DeviceWriter interface {
 	SetDeviceProfileTypes(ctx context.Context, deviceID string, types []types.Lwm2mType) error
 	AssignSensor(ctx context.Context, deviceID, sensorID string) error
 	UnassignSensor(ctx context.Context, deviceID string) error
+	AddDeviceLocation(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error
+	CreateGeofence(ctx context.Context, g types.Geofence) error
+	UpdateGeofence(ctx context.Context, g types.Geofence) error
+	DeleteGeofence(ctx context.Context, geofenceID string) error
 }
 
 type DeviceStatusWriter interface {
 	SetDeviceState(ctx context.Context, deviceID string, state types.DeviceState) error
 	AddDeviceStatus(ctx context.Context, status types.StatusMessage) error
+	SetDeviceStates(ctx context.Context, states map[string]types.DeviceState) error
+	AddDeviceStatuses(ctx context.Context, statuses []types.StatusMessage) error
+	DiscoverSensor(ctx context.Context, status types.StatusMessage) error
 }
 
 type DeviceProfileStore interface {
@@ -49,11 +80,20 @@ type DeviceProfileStore interface {
 
 type DeviceQueryService interface {
 	DeviceBySensor(ctx context.Context, sensorID string, tenants []string) (types.Device, error)
-	Device(ctx context.Context, deviceID string, tenants []string) (types.Device, error)
+	Device(ctx context.Context, deviceID string, tenants []string, include ...string) (types.Device, error)
 	Status(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatus], error)
+	StatusAggregates(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error)
 	Alarms(ctx context.Context, deviceID string, tenants []string) (types.Collection[types.AlarmDetails], error)
 	Measurements(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error)
+	BatteryForecasts(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error)
+	Coverage(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error)
+	Clusters(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error)
+	Locations(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error)
+	Availability(ctx context.Context, deviceID string, query dmquery.AvailabilityFilters) (types.Availability, error)
+	AvailabilityReport(ctx context.Context, query dmquery.AvailabilityFilters) (types.Collection[types.Availability], error)
+	UptimeReport(ctx context.Context, query dmquery.UptimeFilters) (types.UptimeReport, error)
 	Query(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error)
+	Stats(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error)
 	Tenants(ctx context.Context) (types.Collection[string], error)
 	Lwm2mTypes(ctx context.Context, urn ...string) (types.Collection[types.Lwm2mType], error)
 	Profiles(ctx context.Context, name ...string) (types.Collection[types.SensorProfile], error)
@@ -68,6 +108,14 @@ type DeviceCommandService interface {
 	UpdateState(ctx context.Context, deviceID, tenant string, deviceState types.DeviceState) error
 }
 
+type DeviceGeofenceService interface {
+	Geofences(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error)
+	Geofence(ctx context.Context, geofenceID string, tenants []string) (types.Geofence, error)
+	CreateGeofence(ctx context.Context, g types.Geofence) (types.Geofence, error)
+	UpdateGeofence(ctx context.Context, g types.Geofence, tenants []string) error
+	DeleteGeofence(ctx context.Context, geofenceID string, tenants []string) error
+}
+
 type DeviceBootstrapService interface {
 	SeedLwm2mTypes(ctx context.Context, lwm2m []types.Lwm2mType) error
 	SeedSensorProfiles(ctx context.Context, profiles []types.SensorProfile) error
@@ -77,9 +125,16 @@ type DeviceStatusHandler interface {
 	Handle(ctx context.Context, status types.StatusMessage) error
 }
 
+// DeviceStatusMessageHandler is implemented by status handlers that may quarantine a
+// message later on, and so need the body it was decoded from.
+type DeviceStatusMessageHandler interface {
+	HandleMessage(ctx context.Context, body []byte, status types.StatusMessage) error
+}
+
 type DeviceAPIService interface {
 	DeviceQueryService
 	DeviceCommandService
+	DeviceGeofenceService
 	DeviceBootstrapService
 }
 
@@ -91,6 +146,10 @@ type DeviceAPIService interface {
 type Config struct {
 	DeviceProfiles []types.SensorProfile `yaml:"deviceprofiles"`
 	Types          []types.Lwm2mType     `yaml:"types"`
+	StateMappings  []StateMapping        `yaml:"statemappings"`
+	// AutoProvision lists tenants for which sensors are discovered from status messages of unknown devices.
+	AutoProvision []string        `yaml:"autoprovision"`
+	Ingestion     IngestionConfig `yaml:"ingestion"`
 }
 
 type service struct {
@@ -100,6 +159,7 @@ type service struct {
 	profiles     DeviceProfileStore
 	config       *Config
 	messenger    messaging.MsgContext
+	alarms       alarms.AlarmAPIService
 }
 
 func New(reader DeviceReader, writer DeviceWriter, statusWriter DeviceStatusWriter, profiles DeviceProfileStore, messenger messaging.MsgContext, config *Config) *service {
@@ -112,3 +172,16 @@ func New(reader DeviceReader, writer DeviceWriter, statusWriter DeviceStatusWrit
 		config:       config,
 	}
 }
+
+// WithAlarms lets the service raise and clear alarms, such as when a device leaves its geofence.
+func (s *service) WithAlarms(a alarms.AlarmAPIService) *service {
+	s.alarms = a
+	return s
+}
+
+func (s service) autoProvision(tenant string) bool {
+	if s.config == nil || tenant == "" {
+		return false
+	}
+	return slices.Contains(s.config.AutoProvision, tenant)
+}
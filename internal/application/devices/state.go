@@ -0,0 +1,111 @@
+package devices
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+)
+
+// StateMapping maps a status code, or a pattern such as "uplink_*", to a device state.
+type StateMapping struct {
+	Code string `yaml:"code"`
+	// Profile restricts the mapping to devices using a sensor profile (decoder).
+	Profile string `yaml:"profile"`
+	// State is one of ok, warning, error or unknown.
+	State string `yaml:"state"`
+	// Persist is the number of seconds a degraded state is kept when
+	// no new status code is received.
+	Persist int `yaml:"persist"`
+}
+
+var stateNames = map[string]int{
+	"unknown": types.DeviceStateUnknown,
+	"ok":      types.DeviceStateOK,
+	"warning": types.DeviceStateWarning,
+	"error":   types.DeviceStateError,
+}
+
+func (m StateMapping) matches(profile, code string) bool {
+	if m.Profile != "" && !strings.EqualFold(m.Profile, profile) {
+		return false
+	}
+
+	if strings.EqualFold(m.Code, code) {
+		return true
+	}
+
+	ok, err := path.Match(strings.ToLower(m.Code), strings.ToLower(code))
+	return err == nil && ok
+}
+
+func (m StateMapping) state() int {
+	if state, ok := stateNames[strings.ToLower(strings.TrimSpace(m.State))]; ok {
+		return state
+	}
+	return types.DeviceStateWarning
+}
+
+// stateFromStatus returns the state a status message indicates. Profile specific
+// mappings are preferred over general ones, and unmapped codes are treated as warnings.
+func stateFromStatus(mappings []StateMapping, profile string, status types.StatusMessage) types.DeviceState {
+	state := types.DeviceState{
+		Online:     true,
+		State:      types.DeviceStateOK,
+		ObservedAt: status.Timestamp,
+	}
+
+	if status.Code == nil || *status.Code == "" {
+		return state
+	}
+
+	state.State = types.DeviceStateWarning
+
+	var match *StateMapping
+	for i, m := range mappings {
+		if !m.matches(profile, *status.Code) {
+			continue
+		}
+		if match == nil || (match.Profile == "" && m.Profile != "") {
+			match = &mappings[i]
+		}
+	}
+
+	if match == nil {
+		return state
+	}
+
+	state.State = match.state()
+
+	if state.State > types.DeviceStateOK && match.Persist > 0 {
+		until := status.Timestamp.Add(time.Duration(match.Persist) * time.Second)
+		state.DegradedUntil = &until
+	}
+
+	return state
+}
+
+// resolveState keeps a degraded state that is still persisting unless the
+// next state is at least as severe.
+func resolveState(current, next types.DeviceState) types.DeviceState {
+	if current.DegradedUntil == nil || current.State <= next.State {
+		return next
+	}
+
+	if !next.ObservedAt.Before(*current.DegradedUntil) {
+		return next
+	}
+
+	next.State = current.State
+	next.DegradedUntil = current.DegradedUntil
+
+	return next
+}
+
+func (s service) stateMappings() []StateMapping {
+	if s.config == nil {
+		return nil
+	}
+	return s.config.StateMappings
+}
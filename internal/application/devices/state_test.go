@@ -0,0 +1,130 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+var testStateMappings = []StateMapping{
+	{Code: "leak", State: "error", Persist: 3600},
+	{Code: "uplink_*", State: "ok"},
+	{Code: "low_battery", State: "warning"},
+	{Code: "low_battery", Profile: "elsys", State: "error"},
+}
+
+func TestStateFromStatus(t *testing.T) {
+	is := is.New(t)
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	code := func(c string) *string { return &c }
+
+	state := stateFromStatus(testStateMappings, "", types.StatusMessage{Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateOK)
+	is.True(state.Online)
+
+	state = stateFromStatus(testStateMappings, "", types.StatusMessage{Code: code("leak"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateError)
+	is.Equal(*state.DegradedUntil, ts.Add(time.Hour))
+
+	state = stateFromStatus(testStateMappings, "", types.StatusMessage{Code: code("uplink_fcnt_retransmission"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateOK)
+	is.Equal(state.DegradedUntil, nil)
+
+	state = stateFromStatus(testStateMappings, "", types.StatusMessage{Code: code("low_battery"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateWarning)
+
+	state = stateFromStatus(testStateMappings, "elsys", types.StatusMessage{Code: code("low_battery"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateError)
+
+	state = stateFromStatus(testStateMappings, "", types.StatusMessage{Code: code("something_else"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateWarning)
+
+	state = stateFromStatus(nil, "", types.StatusMessage{Code: code("leak"), Timestamp: ts})
+	is.Equal(state.State, types.DeviceStateWarning)
+}
+
+func TestResolveStateKeepsPersistingState(t *testing.T) {
+	is := is.New(t)
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	until := ts.Add(time.Hour)
+
+	current := types.DeviceState{Online: true, State: types.DeviceStateError, ObservedAt: ts, DegradedUntil: &until}
+
+	state := resolveState(current, types.DeviceState{Online: true, State: types.DeviceStateOK, ObservedAt: ts.Add(30 * time.Minute)})
+	is.Equal(state.State, types.DeviceStateError)
+	is.Equal(state.ObservedAt, ts.Add(30*time.Minute))
+
+	state = resolveState(current, types.DeviceState{Online: true, State: types.DeviceStateOK, ObservedAt: ts.Add(2 * time.Hour)})
+	is.Equal(state.State, types.DeviceStateOK)
+	is.Equal(state.DegradedUntil, nil)
+
+	state = resolveState(types.DeviceState{State: types.DeviceStateWarning, ObservedAt: ts}, types.DeviceState{State: types.DeviceStateOK, ObservedAt: ts.Add(time.Second)})
+	is.Equal(state.State, types.DeviceStateOK)
+}
+
+func TestHandleAppliesStateMapping(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	reader := &DeviceReaderMock{
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return types.Device{DeviceID: deviceID, SensorProfile: types.SensorProfile{Decoder: "elsys"}}, true, nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		SetDeviceStateFunc: func(ctx context.Context, deviceID string, state types.DeviceState) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, &DeviceWriterMock{}, statusWriter, &DeviceProfileStoreMock{}, nil, &Config{StateMappings: testStateMappings})
+
+	code := "low_battery"
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "device", Code: &code, Timestamp: time.Now()})
+	is.NoErr(err)
+
+	is.Equal(len(statusWriter.SetDeviceStateCalls()), 1)
+	is.Equal(statusWriter.SetDeviceStateCalls()[0].State.State, types.DeviceStateError)
+}
+
+func TestHandleRejectsOutOfOrderStatus(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	ts := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	reader := &DeviceReaderMock{
+		GetDeviceStateFunc: func(ctx context.Context, deviceID string) (types.Device, bool, error) {
+			return types.Device{DeviceID: deviceID, DeviceState: types.DeviceState{Online: true, State: types.DeviceStateOK, ObservedAt: ts}}, true, nil
+		},
+	}
+	statusWriter := &DeviceStatusWriterMock{
+		SetDeviceStateFunc: func(ctx context.Context, deviceID string, state types.DeviceState) error {
+			return nil
+		},
+		AddDeviceStatusFunc: func(ctx context.Context, status types.StatusMessage) error {
+			return nil
+		},
+	}
+
+	svc := New(reader, &DeviceWriterMock{}, statusWriter, &DeviceProfileStoreMock{}, nil, nil)
+
+	code := "leak"
+	rssi := -80.0
+
+	err := svc.Handle(ctx, types.StatusMessage{DeviceID: "device", Code: &code, RSSI: &rssi, Timestamp: ts.Add(-time.Minute)})
+	is.NoErr(err)
+	err = svc.Handle(ctx, types.StatusMessage{DeviceID: "device", Code: &code, Timestamp: ts})
+	is.NoErr(err)
+
+	is.Equal(len(statusWriter.SetDeviceStateCalls()), 0)
+	// older messages are still kept as history
+	is.Equal(len(statusWriter.AddDeviceStatusCalls()), 1)
+
+	err = svc.Handle(ctx, types.StatusMessage{DeviceID: "device", Code: &code, Timestamp: ts.Add(time.Minute)})
+	is.NoErr(err)
+	is.Equal(len(statusWriter.SetDeviceStateCalls()), 1)
+}
@@ -0,0 +1,175 @@
+package devices
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+)
+
+var errInvalidUptime = fmt.Errorf("uptime reports require an allowed tenant and a month that has started")
+
+// UptimeReport computes how much of a calendar month each device of a tenant was online. A
+// device counts as offline while its state is offline and while the watchdog reports it as
+// not observed. The report of the current month ends now.
+func (s service) UptimeReport(ctx context.Context, query dmquery.UptimeFilters) (types.UptimeReport, error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.UptimeReport{}, ErrMissingTenant
+	}
+
+	now := time.Now().UTC()
+	from := time.Date(query.Month.Year(), query.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	if !slices.Contains(query.AllowedTenants, query.Tenant) || !from.Before(now) {
+		return types.UptimeReport{}, ErrInvalidUptime
+	}
+
+	if to.After(now) {
+		to = now
+	}
+
+	history, err := s.reader.GetDeviceHistory(ctx, query.Tenant, query.AllowedTenants, from, to)
+	if err != nil {
+		return types.UptimeReport{}, err
+	}
+
+	report := types.UptimeReport{
+		Tenant:      query.Tenant,
+		Month:       from.Format("2006-01"),
+		From:        from,
+		To:          to,
+		Devices:     make([]types.DeviceUptime, 0, len(history)),
+		GeneratedAt: now,
+	}
+
+	for _, h := range history {
+		u := computeUptime(h, from, to)
+		report.Monitored += u.Monitored
+		report.Online += u.Online
+		report.Devices = append(report.Devices, u)
+	}
+
+	report.Uptime = uptimePercent(report.Online, report.Monitored)
+
+	return report, nil
+}
+
+type span struct {
+	from, to time.Time
+}
+
+func computeUptime(h types.DeviceHistory, from, to time.Time) types.DeviceUptime {
+	u := types.DeviceUptime{DeviceID: h.DeviceID, Name: h.Name}
+
+	// time before the first known state is not monitored
+	var monitored, online []span
+	for i, sc := range h.States {
+		end := to
+		if i+1 < len(h.States) {
+			end = h.States[i+1].ObservedAt
+		}
+		s := clip(span{sc.ObservedAt, end}, from, to)
+		monitored = append(monitored, s)
+		if sc.Online {
+			online = append(online, s)
+		}
+	}
+
+	var alarmed, notObserved []span
+	for _, a := range h.Alarms {
+		end := to
+		if a.To != nil {
+			end = *a.To
+		}
+		s := clip(span{a.From, end}, from, to)
+		alarmed = append(alarmed, s)
+		if a.Type == alarms.AlarmDeviceNotObserved {
+			notObserved = append(notObserved, s)
+		}
+		if !a.From.Before(from) && a.From.Before(to) {
+			u.Alarms++
+		}
+	}
+
+	u.Monitored = int64(duration(union(monitored)).Seconds())
+	u.Online = int64(duration(subtract(union(online), union(notObserved))).Seconds())
+	u.AlarmTime = int64(duration(union(alarmed)).Seconds())
+	u.Uptime = uptimePercent(u.Online, u.Monitored)
+
+	return u
+}
+
+func uptimePercent(online, monitored int64) *float64 {
+	if monitored == 0 {
+		return nil
+	}
+	p := float64(online) / float64(monitored) * 100
+	return &p
+}
+
+func clip(s span, from, to time.Time) span {
+	if s.from.Before(from) {
+		s.from = from
+	}
+	if s.to.After(to) {
+		s.to = to
+	}
+	return s
+}
+
+// union merges overlapping spans and drops empty ones, the result is ordered.
+func union(spans []span) []span {
+	spans = slices.DeleteFunc(slices.Clone(spans), func(s span) bool { return !s.to.After(s.from) })
+	slices.SortFunc(spans, func(a, b span) int { return a.from.Compare(b.from) })
+
+	merged := []span{}
+	for _, s := range spans {
+		if n := len(merged); n > 0 && !s.from.After(merged[n-1].to) {
+			if s.to.After(merged[n-1].to) {
+				merged[n-1].to = s.to
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// subtract removes the time of the ordered spans b from the ordered spans a.
+func subtract(a, b []span) []span {
+	result := []span{}
+
+	for _, s := range a {
+		for _, r := range b {
+			if !r.to.After(s.from) || !r.from.Before(s.to) {
+				continue
+			}
+			if r.from.After(s.from) {
+				result = append(result, span{s.from, r.from})
+			}
+			s.from = r.to
+			if !s.to.After(s.from) {
+				break
+			}
+		}
+		if s.to.After(s.from) {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+func duration(spans []span) time.Duration {
+	var d time.Duration
+	for _, s := range spans {
+		d += s.to.Sub(s.from)
+	}
+	return d
+}
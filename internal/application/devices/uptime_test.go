@@ -0,0 +1,86 @@
+package devices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestComputeUptime(t *testing.T) {
+	is := is.New(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 10)
+	day := 24 * time.Hour
+	end := from.Add(4 * day)
+
+	h := types.DeviceHistory{
+		DeviceID: "device-1",
+		States: []types.StateChange{
+			{Online: true, ObservedAt: from.Add(-day)},
+			{Online: false, ObservedAt: from.Add(2 * day)},
+			{Online: true, ObservedAt: from.Add(3 * day)},
+		},
+		Alarms: []types.AlarmPeriod{
+			{Type: alarms.AlarmDeviceNotObserved, From: from.Add(5 * day), To: nil},
+			{Type: "battery_low", From: from.Add(-day), To: &end},
+		},
+	}
+
+	u := computeUptime(h, from, to)
+	is.Equal(u.Monitored, int64((10 * day).Seconds()))
+	is.Equal(u.Online, int64((4 * day).Seconds())) // day 0-2 and 3-5
+	is.Equal(*u.Uptime, 40.0)
+	is.Equal(u.Alarms, 1)                                   // the battery alarm started before the period
+	is.Equal(u.AlarmTime, int64((4*day + 5*day).Seconds())) // 0-4 and 5-10
+}
+
+func TestComputeUptimeWithoutKnownState(t *testing.T) {
+	is := is.New(t)
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	u := computeUptime(types.DeviceHistory{DeviceID: "device-1"}, from, from.AddDate(0, 1, 0))
+	is.Equal(u.Monitored, int64(0))
+	is.True(u.Uptime == nil)
+}
+
+func TestUptimeReportSumsDevices(t *testing.T) {
+	is := is.New(t)
+
+	month := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	reader := &DeviceReaderMock{
+		GetDeviceHistoryFunc: func(ctx context.Context, tenant string, allowedTenants []string, from, to time.Time) ([]types.DeviceHistory, error) {
+			return []types.DeviceHistory{
+				{DeviceID: "device-1", States: []types.StateChange{{Online: true, ObservedAt: from}}},
+				{DeviceID: "device-2", States: []types.StateChange{{Online: false, ObservedAt: from}}},
+			}, nil
+		},
+	}
+
+	svc := New(reader, nil, nil, nil, nil, nil)
+
+	report, err := svc.UptimeReport(context.Background(), dmquery.UptimeFilters{
+		Filters: dmquery.Filters{Tenant: "default", AllowedTenants: []string{"default"}},
+		Month:   month.AddDate(0, 0, 14),
+	})
+	is.NoErr(err)
+	is.Equal(report.Month, "2026-01")
+	is.Equal(report.To, month.AddDate(0, 1, 0))
+	is.Equal(*report.Uptime, 50.0)
+
+	call := reader.GetDeviceHistoryCalls()[0]
+	is.Equal(call.From, month)
+
+	_, err = svc.UptimeReport(context.Background(), dmquery.UptimeFilters{
+		Filters: dmquery.Filters{Tenant: "other", AllowedTenants: []string{"default"}},
+		Month:   month,
+	})
+	is.Equal(err, ErrInvalidUptime)
+}
@@ -0,0 +1,145 @@
+package linkquality
+
+import (
+	"context"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/periodic"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+const (
+	DefaultInterval = 60
+	DefaultWindow   = 7
+)
+
+var (
+	DefaultGood     = Threshold{RSSI: -100, SNR: -5}
+	DefaultMarginal = Threshold{RSSI: -115, SNR: -15}
+)
+
+// Config holds how often, in minutes, link quality is computed, how many days of status
+// rows are used, and the least median RSSI and SNR of a good and a marginal link.
+type Config struct {
+	Interval int       `yaml:"interval"`
+	Window   int       `yaml:"window"`
+	Good     Threshold `yaml:"good"`
+	Marginal Threshold `yaml:"marginal"`
+}
+
+type Threshold struct {
+	RSSI float64 `yaml:"rssi"`
+	SNR  float64 `yaml:"snr"`
+}
+
+type LinkQualityStorage interface {
+	GetLinkQualityStatistics(ctx context.Context, since time.Time) ([]types.LinkQuality, error)
+	SetLinkQuality(ctx context.Context, stats []types.LinkQuality) error
+}
+
+//go:generate moq -rm -out linkqualitystorage_mock.go . LinkQualityStorage
+
+type LinkQuality interface {
+	Start(context.Context)
+	Stop(context.Context)
+	Run(context.Context) error
+}
+
+type linkQuality struct {
+	storage  LinkQualityStorage
+	interval time.Duration
+	window   int
+	good     Threshold
+	marginal Threshold
+
+	job *periodic.Job
+}
+
+func New(s LinkQualityStorage, cfg *Config) LinkQuality {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	l := &linkQuality{
+		storage:  s,
+		interval: time.Duration(DefaultInterval) * time.Minute,
+		window:   DefaultWindow,
+		good:     DefaultGood,
+		marginal: DefaultMarginal,
+	}
+
+	if cfg.Interval > 0 {
+		l.interval = time.Duration(cfg.Interval) * time.Minute
+	}
+	if cfg.Window > 0 {
+		l.window = cfg.Window
+	}
+	if cfg.Good != (Threshold{}) {
+		l.good = cfg.Good
+	}
+	if cfg.Marginal != (Threshold{}) {
+		l.marginal = cfg.Marginal
+	}
+
+	l.job = periodic.New("link quality", l.interval, l.Run)
+
+	return l
+}
+
+func (l *linkQuality) Start(ctx context.Context) {
+	l.job.Start(ctx)
+}
+
+func (l *linkQuality) Stop(ctx context.Context) {
+	l.job.Stop(ctx)
+}
+
+// Run computes link statistics of every sensor with status rows within the window,
+// classifies them and replaces the stored link quality.
+func (l *linkQuality) Run(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	stats, err := l.storage.GetLinkQualityStatistics(ctx, now.AddDate(0, 0, -l.window))
+	if err != nil {
+		return err
+	}
+
+	result := make([]types.LinkQuality, 0, len(stats))
+	for _, s := range stats {
+		class, ok := Classify(s, l.good, l.marginal)
+		if !ok {
+			continue
+		}
+		s.Class = class
+		s.ComputedAt = now
+		result = append(result, s)
+	}
+
+	logging.GetFromContext(ctx).Debug("computed link quality", "sensors", len(result))
+
+	return l.storage.SetLinkQuality(ctx, result)
+}
+
+// Classify compares the median RSSI and SNR of a link with the thresholds of a good and
+// a marginal link. Values that are missing are not compared, and links without any
+// RSSI or SNR are not classified.
+func Classify(lq types.LinkQuality, good, marginal Threshold) (string, bool) {
+	rssi, snr := lq.RSSI.P50, lq.LoRaSNR.P50
+	if rssi == nil && snr == nil {
+		return "", false
+	}
+
+	meets := func(t Threshold) bool {
+		return (rssi == nil || *rssi >= t.RSSI) && (snr == nil || *snr >= t.SNR)
+	}
+
+	switch {
+	case meets(good):
+		return types.LinkQualityGood, true
+	case meets(marginal):
+		return types.LinkQualityMarginal, true
+	default:
+		return types.LinkQualityPoor, true
+	}
+}
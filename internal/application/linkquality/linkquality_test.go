@@ -0,0 +1,66 @@
+package linkquality
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestClassify(t *testing.T) {
+	is := is.New(t)
+
+	link := func(rssi, snr *float64) types.LinkQuality {
+		return types.LinkQuality{RSSI: types.Percentiles{P50: rssi}, LoRaSNR: types.Percentiles{P50: snr}}
+	}
+	f := func(v float64) *float64 { return &v }
+
+	for _, tc := range []struct {
+		rssi, snr *float64
+		class     string
+	}{
+		{f(-90), f(5), types.LinkQualityGood},
+		{f(-90), f(-10), types.LinkQualityMarginal},
+		{f(-110), nil, types.LinkQualityMarginal},
+		{nil, f(-20), types.LinkQualityPoor},
+		{f(-120), f(5), types.LinkQualityPoor},
+	} {
+		class, ok := Classify(link(tc.rssi, tc.snr), DefaultGood, DefaultMarginal)
+		is.True(ok)
+		is.Equal(class, tc.class)
+	}
+
+	_, ok := Classify(link(nil, nil), DefaultGood, DefaultMarginal)
+	is.True(!ok)
+}
+
+func TestRunStoresClassifiedLinks(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	rssi := -120.0
+	s := &LinkQualityStorageMock{
+		GetLinkQualityStatisticsFunc: func(ctx context.Context, since time.Time) ([]types.LinkQuality, error) {
+			return []types.LinkQuality{
+				{SensorID: "sensor-1", Samples: 10, RSSI: types.Percentiles{P50: &rssi}},
+				{SensorID: "sensor-2", Samples: 10},
+			}, nil
+		},
+		SetLinkQualityFunc: func(ctx context.Context, stats []types.LinkQuality) error {
+			return nil
+		},
+	}
+
+	err := New(s, &Config{Marginal: Threshold{RSSI: -125, SNR: -20}}).Run(ctx)
+	is.NoErr(err)
+
+	since := s.GetLinkQualityStatisticsCalls()[0].Since
+	is.True(time.Since(since) > 6*24*time.Hour)
+
+	stored := s.SetLinkQualityCalls()[0].Stats
+	is.Equal(len(stored), 1)
+	is.Equal(stored[0].SensorID, "sensor-1")
+	is.Equal(stored[0].Class, types.LinkQualityMarginal)
+}
@@ -0,0 +1,95 @@
+package periodic
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+// Job calls run when it is started and then at every interval, until it is stopped.
+type Job struct {
+	name     string
+	interval time.Duration
+	run      func(context.Context) error
+
+	mu      sync.Mutex
+	running atomic.Bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// New returns a job that calls run at every interval. The name identifies the job when
+// run fails.
+func New(name string, interval time.Duration, run func(context.Context) error) *Job {
+	return &Job{
+		name:     name,
+		interval: interval,
+		run:      run,
+	}
+}
+
+// Start runs the job in the background. A job that is already running is not started again.
+func (j *Job) Start(ctx context.Context) {
+	if !j.running.CompareAndSwap(false, true) {
+		return
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	j.mu.Lock()
+	j.cancel = cancel
+	j.done = done
+	j.mu.Unlock()
+
+	go func() {
+		defer j.running.Store(false)
+		defer close(done)
+
+		log := logging.GetFromContext(runCtx)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := j.run(runCtx); err != nil && runCtx.Err() == nil {
+				log.Error("failed to run periodic job", "job", j.name, "err", err.Error())
+			}
+
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop cancels the job and waits until the current run has returned, or ctx is cancelled.
+func (j *Job) Stop(ctx context.Context) {
+	j.mu.Lock()
+	cancel := j.cancel
+	done := j.done
+	j.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	j.mu.Lock()
+	if j.done == done {
+		j.done = nil
+		j.cancel = nil
+	}
+	j.mu.Unlock()
+}
@@ -0,0 +1,69 @@
+package periodic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestJobRunsUntilStopped(t *testing.T) {
+	is := is.New(t)
+
+	var runs atomic.Int32
+	started := make(chan struct{}, 10)
+
+	job := New("test", time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		started <- struct{}{}
+		return errors.New("failed runs are retried at the next interval")
+	})
+
+	job.Start(context.Background())
+	job.Start(context.Background()) // a running job is not started twice
+
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("job did not run")
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	job.Stop(stopCtx)
+
+	is.True(!job.running.Load())
+
+	stopped := runs.Load()
+	time.Sleep(10 * time.Millisecond)
+	is.Equal(runs.Load(), stopped) // no runs once stopped
+}
+
+func TestJobStopWaitsForRun(t *testing.T) {
+	is := is.New(t)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+
+	job := New("test", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	})
+
+	job.Start(context.Background())
+	<-started
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	job.Stop(stopCtx)
+
+	is.True(finished.Load())
+}
@@ -0,0 +1,137 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+const (
+	ReasonInvalidMessage = "invalid_message"
+	ReasonDeviceNotFound = "device_not_found"
+	ReasonHandlerFailed  = "handler_failed"
+)
+
+const replayBatchSize int = 100
+
+//go:generate moq -rm -out quarantinestorage_mock.go . QuarantineStorage
+type QuarantineStorage interface {
+	AddQuarantinedMessage(ctx context.Context, m types.QuarantinedMessage) error
+	QuarantinedMessages(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantinedMessage], error)
+	QuarantineGroups(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantineGroup], error)
+	UpdateQuarantinedMessage(ctx context.Context, id int64, reason, errorText string) error
+	DeleteQuarantinedMessage(ctx context.Context, id int64) error
+}
+
+// StatusHandler processes device status messages, i.e. devices.DeviceStatusHandler.
+type StatusHandler interface {
+	Handle(ctx context.Context, status types.StatusMessage) error
+}
+
+//go:generate moq -rm -out quarantineservice_mock.go . QuarantineService
+type QuarantineService interface {
+	Add(ctx context.Context, body []byte, status types.StatusMessage, reason string, cause error) error
+	Groups(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantineGroup], error)
+	Replay(ctx context.Context, query quarantinequery.Messages) (types.QuarantineReplay, error)
+}
+
+type svc struct {
+	storage QuarantineStorage
+	handler StatusHandler
+	reason  func(error) string
+}
+
+// New creates a quarantine service. Replayed messages are passed to handler and reason
+// is used to classify messages that fail again.
+func New(s QuarantineStorage, handler StatusHandler, reason func(error) string) QuarantineService {
+	if reason == nil {
+		reason = func(error) string { return ReasonHandlerFailed }
+	}
+
+	return &svc{
+		storage: s,
+		handler: handler,
+		reason:  reason,
+	}
+}
+
+func (q *svc) Add(ctx context.Context, body []byte, status types.StatusMessage, reason string, cause error) error {
+	m := types.QuarantinedMessage{
+		DeviceID: status.DeviceID,
+		Tenant:   status.Tenant,
+		Reason:   reason,
+		Body:     string(body),
+	}
+
+	if cause != nil {
+		m.Error = cause.Error()
+	}
+
+	return q.storage.AddQuarantinedMessage(ctx, m)
+}
+
+func (q *svc) Groups(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantineGroup], error) {
+	return q.storage.QuarantineGroups(ctx, query)
+}
+
+// Replay reprocesses quarantined messages. Messages that are handled are removed from
+// the quarantine, others are kept with an updated reason.
+func (q *svc) Replay(ctx context.Context, query quarantinequery.Messages) (types.QuarantineReplay, error) {
+	log := logging.GetFromContext(ctx)
+	result := types.QuarantineReplay{}
+
+	query.AfterID = 0
+	query.Limit = replayBatchSize
+
+	for {
+		messages, err := q.storage.QuarantinedMessages(ctx, query)
+		if err != nil {
+			return result, err
+		}
+
+		for _, m := range messages.Data {
+			query.AfterID = m.ID
+
+			reason, err := q.replay(ctx, m)
+			if err == nil {
+				err = q.storage.DeleteQuarantinedMessage(ctx, m.ID)
+				if err != nil {
+					return result, err
+				}
+				result.Replayed++
+				continue
+			}
+
+			log.Debug("could not replay quarantined message", "id", m.ID, "device_id", m.DeviceID, "reason", reason, "err", err.Error())
+			result.Failed++
+
+			err = q.storage.UpdateQuarantinedMessage(ctx, m.ID, reason, err.Error())
+			if err != nil {
+				return result, err
+			}
+		}
+
+		if len(messages.Data) < replayBatchSize {
+			return result, nil
+		}
+	}
+}
+
+func (q *svc) replay(ctx context.Context, m types.QuarantinedMessage) (string, error) {
+	status := types.StatusMessage{}
+
+	err := json.Unmarshal([]byte(m.Body), &status)
+	if err != nil {
+		return ReasonInvalidMessage, err
+	}
+
+	err = q.handler.Handle(ctx, status)
+	if err != nil {
+		return q.reason(err), err
+	}
+
+	return "", nil
+}
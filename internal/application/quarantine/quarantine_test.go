@@ -0,0 +1,73 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+type statusHandlerFunc func(ctx context.Context, status types.StatusMessage) error
+
+func (f statusHandlerFunc) Handle(ctx context.Context, status types.StatusMessage) error {
+	return f(ctx, status)
+}
+
+func TestReplayRemovesHandledMessages(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	errNotFound := errors.New("device not found")
+
+	s := &QuarantineStorageMock{
+		QuarantinedMessagesFunc: func(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantinedMessage], error) {
+			if query.AfterID > 0 {
+				return types.Collection[types.QuarantinedMessage]{}, nil
+			}
+			return types.Collection[types.QuarantinedMessage]{
+				Data: []types.QuarantinedMessage{
+					{ID: 1, DeviceID: "known", Body: `{"deviceID":"known"}`},
+					{ID: 2, DeviceID: "unknown", Body: `{"deviceID":"unknown"}`},
+					{ID: 3, Body: `{"deviceID":`},
+				},
+				Count: 3,
+			}, nil
+		},
+		DeleteQuarantinedMessageFunc: func(ctx context.Context, id int64) error {
+			return nil
+		},
+		UpdateQuarantinedMessageFunc: func(ctx context.Context, id int64, reason string, errorText string) error {
+			return nil
+		},
+	}
+
+	handler := statusHandlerFunc(func(ctx context.Context, status types.StatusMessage) error {
+		if status.DeviceID == "known" {
+			return nil
+		}
+		return errNotFound
+	})
+
+	svc := New(s, handler, func(err error) string {
+		if errors.Is(err, errNotFound) {
+			return ReasonDeviceNotFound
+		}
+		return ReasonHandlerFailed
+	})
+
+	result, err := svc.Replay(ctx, quarantinequery.Messages{AllowedTenants: []string{"default"}})
+	is.NoErr(err)
+
+	is.Equal(result.Replayed, 1)
+	is.Equal(result.Failed, 2)
+
+	is.Equal(len(s.DeleteQuarantinedMessageCalls()), 1)
+	is.Equal(s.DeleteQuarantinedMessageCalls()[0].ID, int64(1))
+
+	is.Equal(len(s.UpdateQuarantinedMessageCalls()), 2)
+	is.Equal(s.UpdateQuarantinedMessageCalls()[0].Reason, ReasonDeviceNotFound)
+	is.Equal(s.UpdateQuarantinedMessageCalls()[1].Reason, ReasonInvalidMessage)
+}
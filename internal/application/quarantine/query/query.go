@@ -0,0 +1,14 @@
+package query
+
+type Messages struct {
+	DeviceID       string
+	Tenant         string
+	Reason         string
+	AllowedTenants []string
+	// IncludeUntenanted also includes messages that could not be read and have no tenant,
+	// which only admins are allowed to see.
+	IncludeUntenanted bool
+	// AfterID only includes messages with a greater id, used to page through messages.
+	AfterID int64
+	Limit   int
+}
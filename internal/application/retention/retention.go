@@ -0,0 +1,142 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/periodic"
+)
+
+const (
+	DefaultInterval = 60
+	DefaultRaw      = 21
+	DefaultHourly   = 90
+	DefaultDaily    = 730
+
+	// MinRaw is the least number of days raw status rows are kept, so that
+	// the latest hours and days can be rolled up again when late messages arrive.
+	MinRaw = 3
+)
+
+// Config holds how long, in days, raw status rows and hourly and daily rollups
+// are kept, and how often, in minutes, the retention job runs.
+type Config struct {
+	Interval int      `yaml:"interval"`
+	Raw      int      `yaml:"raw"`
+	Hourly   int      `yaml:"hourly"`
+	Daily    int      `yaml:"daily"`
+	Policies []Policy `yaml:"policies"`
+}
+
+// Policy overrides the retention for sensors belonging to a tenant, using a
+// sensor profile, or both. Periods that are not set are taken from the defaults.
+type Policy struct {
+	Tenant  string `yaml:"tenant"`
+	Profile string `yaml:"profile"`
+	Raw     int    `yaml:"raw"`
+	Hourly  int    `yaml:"hourly"`
+	Daily   int    `yaml:"daily"`
+}
+
+// Rank is used to prefer the most specific policy that matches a sensor.
+func (p Policy) Rank() int {
+	rank := 0
+	if p.Tenant != "" {
+		rank += 2
+	}
+	if p.Profile != "" {
+		rank += 1
+	}
+	return rank
+}
+
+type RetentionStorage interface {
+	RollupSensorStatus(ctx context.Context) error
+	DeleteExpiredSensorStatus(ctx context.Context, policies []Policy) error
+}
+
+//go:generate moq -rm -out retentionstorage_mock.go . RetentionStorage
+
+type Retention interface {
+	Start(context.Context)
+	Stop(context.Context)
+	Run(context.Context) error
+}
+
+type retention struct {
+	storage  RetentionStorage
+	interval time.Duration
+	policies []Policy
+
+	job *periodic.Job
+}
+
+func New(s RetentionStorage, cfg *Config) Retention {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	interval := DefaultInterval
+	if cfg.Interval > 0 {
+		interval = cfg.Interval
+	}
+
+	r := &retention{
+		storage:  s,
+		interval: time.Duration(interval) * time.Minute,
+		policies: policies(cfg),
+	}
+	r.job = periodic.New("status retention", r.interval, r.Run)
+
+	return r
+}
+
+// policies returns the configured policies with missing periods filled in from
+// the defaults, followed by the default policy that matches every sensor.
+func policies(cfg *Config) []Policy {
+	def := Policy{
+		Raw:    valueOrDefault(cfg.Raw, DefaultRaw),
+		Hourly: valueOrDefault(cfg.Hourly, DefaultHourly),
+		Daily:  valueOrDefault(cfg.Daily, DefaultDaily),
+	}
+	def.Raw = max(def.Raw, MinRaw)
+
+	result := make([]Policy, 0, len(cfg.Policies)+1)
+	for _, p := range cfg.Policies {
+		if p.Tenant == "" && p.Profile == "" {
+			continue
+		}
+		p.Raw = max(valueOrDefault(p.Raw, def.Raw), MinRaw)
+		p.Hourly = valueOrDefault(p.Hourly, def.Hourly)
+		p.Daily = valueOrDefault(p.Daily, def.Daily)
+		result = append(result, p)
+	}
+
+	return append(result, def)
+}
+
+func valueOrDefault(v, def int) int {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+func (r *retention) Start(ctx context.Context) {
+	r.job.Start(ctx)
+}
+
+func (r *retention) Stop(ctx context.Context) {
+	r.job.Stop(ctx)
+}
+
+// Run rolls up raw status rows into hourly and daily aggregates and then deletes
+// the rows that are older than the retention of each sensor.
+func (r *retention) Run(ctx context.Context) error {
+	err := r.storage.RollupSensorStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	return r.storage.DeleteExpiredSensorStatus(ctx, r.policies)
+}
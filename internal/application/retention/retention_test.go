@@ -0,0 +1,52 @@
+package retention
+
+import (
+	"context"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestPoliciesAreFilledWithDefaults(t *testing.T) {
+	is := is.New(t)
+
+	p := policies(&Config{
+		Raw: 1,
+		Policies: []Policy{
+			{Tenant: "default", Profile: "elsys", Daily: 1825},
+			{Profile: "lifebuoy", Raw: 30, Hourly: 180},
+			{Raw: 10},
+		},
+	})
+
+	is.Equal(len(p), 3)
+	is.Equal(p[0], Policy{Tenant: "default", Profile: "elsys", Raw: MinRaw, Hourly: DefaultHourly, Daily: 1825})
+	is.Equal(p[1], Policy{Profile: "lifebuoy", Raw: 30, Hourly: 180, Daily: DefaultDaily})
+	// the default policy is always last and may not keep raw rows for less than MinRaw days
+	is.Equal(p[2], Policy{Raw: MinRaw, Hourly: DefaultHourly, Daily: DefaultDaily})
+
+	is.True(p[0].Rank() > p[1].Rank())
+	is.True(p[1].Rank() > p[2].Rank())
+}
+
+func TestRunRollsUpBeforeDeleting(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	calls := []string{}
+	s := &RetentionStorageMock{
+		RollupSensorStatusFunc: func(ctx context.Context) error {
+			calls = append(calls, "rollup")
+			return nil
+		},
+		DeleteExpiredSensorStatusFunc: func(ctx context.Context, policies []Policy) error {
+			calls = append(calls, "delete")
+			return nil
+		},
+	}
+
+	err := New(s, nil).Run(ctx)
+	is.NoErr(err)
+	is.Equal(calls, []string{"rollup", "delete"})
+	is.Equal(s.DeleteExpiredSensorStatusCalls()[0].Policies, []Policy{{Raw: DefaultRaw, Hourly: DefaultHourly, Daily: DefaultDaily}})
+}
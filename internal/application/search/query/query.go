@@ -0,0 +1,8 @@
+package query
+
+// Search matches devices, sensors and alarms in the allowed tenants by Text.
+type Search struct {
+	Text           string
+	Limit          *int
+	AllowedTenants []string
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+)
+
+var ErrMissingTenant = errors.New("missing tenant information")
+
+//go:generate moq -rm -out searchstorage_mock.go . SearchStorage
+type SearchStorage interface {
+	Search(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error)
+}
+
+//go:generate moq -rm -out searchservice_mock.go . SearchService
+type SearchService interface {
+	Search(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error)
+}
+
+type svc struct {
+	storage SearchStorage
+}
+
+func New(s SearchStorage) SearchService {
+	return &svc{storage: s}
+}
+
+// Search returns the devices, sensors and alarms that match the text, most relevant first.
+// An empty text matches nothing.
+func (s *svc) Search(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.SearchResult]{}, ErrMissingTenant
+	}
+
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return types.Collection[types.SearchResult]{Data: []types.SearchResult{}}, nil
+	}
+
+	return s.storage.Search(ctx, query)
+}
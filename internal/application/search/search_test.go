@@ -0,0 +1,38 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestSearch(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	storage := &SearchStorageMock{
+		SearchFunc: func(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error) {
+			return types.Collection[types.SearchResult]{Count: 1, TotalCount: 1, Data: []types.SearchResult{{Kind: types.SearchKindDevice, ID: "pump-1"}}}, nil
+		},
+	}
+
+	svc := New(storage)
+
+	_, err := svc.Search(ctx, searchquery.Search{Text: "pump"})
+	is.True(errors.Is(err, ErrMissingTenant))
+
+	result, err := svc.Search(ctx, searchquery.Search{Text: "  ", AllowedTenants: []string{"default"}})
+	is.NoErr(err)
+	is.Equal(len(result.Data), 0) // nothing matches an empty text
+
+	result, err = svc.Search(ctx, searchquery.Search{Text: " pump ", AllowedTenants: []string{"default"}})
+	is.NoErr(err)
+	is.Equal(result.Data[0].ID, "pump-1")
+
+	is.Equal(len(storage.SearchCalls()), 1)
+	is.Equal(storage.SearchCalls()[0].Query.Text, "pump")
+}
@@ -1,5 +1,7 @@
 package query
 
+import "github.com/diwise/iot-device-mgmt/pkg/types"
+
 type Sensors struct {
 	Offset      *int
 	Limit       *int
@@ -8,4 +10,13 @@ type Sensors struct {
 	ProfileName string
 	Types       []string
 	Search      string
+	// Discovered only includes sensors found through status traffic that are not yet assigned to a device.
+	Discovered     *bool
+	AllowedTenants []string
+	// Within, Near and Radius match the sensor location as for devices.
+	Within []types.Polygon
+	Near   *types.Location
+	Radius float64
+	// Cursor pages by sensor id, or distance and sensor id, instead of by offset.
+	Cursor *types.Cursor
 }
@@ -0,0 +1,194 @@
+package uptime
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/periodic"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+const DefaultInterval = 60
+
+// Config holds how often, in minutes, the reports directory is checked for missing reports
+// and the directory itself. Reports are not generated when the directory is empty.
+type Config struct {
+	Interval  int    `yaml:"interval"`
+	Directory string `yaml:"directory"`
+}
+
+// Reporter computes uptime reports, i.e. devices.DeviceQueryService.
+type Reporter interface {
+	Tenants(ctx context.Context) (types.Collection[string], error)
+	UptimeReport(ctx context.Context, query dmquery.UptimeFilters) (types.UptimeReport, error)
+}
+
+//go:generate moq -rm -out reporter_mock.go . Reporter
+
+type Generator interface {
+	Start(context.Context)
+	Stop(context.Context)
+	Run(context.Context) error
+}
+
+type generator struct {
+	reporter  Reporter
+	interval  time.Duration
+	directory string
+
+	job *periodic.Job
+}
+
+func New(r Reporter, cfg *Config) Generator {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	g := &generator{
+		reporter:  r,
+		interval:  time.Duration(DefaultInterval) * time.Minute,
+		directory: cfg.Directory,
+	}
+
+	if cfg.Interval > 0 {
+		g.interval = time.Duration(cfg.Interval) * time.Minute
+	}
+
+	g.job = periodic.New("uptime reports", g.interval, g.Run)
+
+	return g
+}
+
+func (g *generator) Start(ctx context.Context) {
+	if g.directory == "" {
+		return
+	}
+
+	g.job.Start(ctx)
+}
+
+func (g *generator) Stop(ctx context.Context) {
+	g.job.Stop(ctx)
+}
+
+// Run writes the report of the previous month of each tenant, as <tenant>/<yyyy-mm>.json
+// and .csv in the reports directory, unless it has already been written.
+func (g *generator) Run(ctx context.Context) error {
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+
+	tenants, err := g.reporter.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, tenant := range tenants.Data {
+		path := filepath.Join(g.directory, filepath.Base(tenant), month.Format("2006-01"))
+
+		if _, err := os.Stat(path + ".json"); err == nil {
+			continue
+		}
+
+		report, err := g.reporter.UptimeReport(ctx, dmquery.UptimeFilters{
+			Filters: dmquery.Filters{Tenant: tenant, AllowedTenants: tenants.Data},
+			Month:   month,
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if err := write(path, report); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		logging.GetFromContext(ctx).Info("generated uptime report", "tenant", tenant, "month", report.Month)
+	}
+
+	return errors.Join(errs...)
+}
+
+// write stores the csv file before the json file, as the json file marks the report as done.
+func write(path string, report types.UptimeReport) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path + ".csv")
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path+".json", b, 0644)
+}
+
+// WriteCSV writes one row per device followed by a row with the totals of the tenant.
+func WriteCSV(w io.Writer, report types.UptimeReport) error {
+	formatUptime := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', 2, 64)
+	}
+
+	rows := [][]string{{"tenant", "month", "deviceID", "name", "monitored", "online", "uptime", "alarms", "alarmTime"}}
+
+	for _, d := range report.Devices {
+		rows = append(rows, []string{
+			report.Tenant,
+			report.Month,
+			d.DeviceID,
+			d.Name,
+			strconv.FormatInt(d.Monitored, 10),
+			strconv.FormatInt(d.Online, 10),
+			formatUptime(d.Uptime),
+			strconv.Itoa(d.Alarms),
+			strconv.FormatInt(d.AlarmTime, 10),
+		})
+	}
+
+	rows = append(rows, []string{
+		report.Tenant,
+		report.Month,
+		"",
+		"",
+		strconv.FormatInt(report.Monitored, 10),
+		strconv.FormatInt(report.Online, 10),
+		formatUptime(report.Uptime),
+		"",
+		"",
+	})
+
+	writer := csv.NewWriter(w)
+	writer.Comma = ';'
+
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+
+	return writer.Error()
+}
@@ -0,0 +1,55 @@
+package uptime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestRunWritesMissingReports(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	uptime := 99.5
+	r := &ReporterMock{
+		TenantsFunc: func(ctx context.Context) (types.Collection[string], error) {
+			return types.Collection[string]{Data: []string{"default", "other"}}, nil
+		},
+		UptimeReportFunc: func(ctx context.Context, query dmquery.UptimeFilters) (types.UptimeReport, error) {
+			return types.UptimeReport{
+				Tenant:  query.Tenant,
+				Month:   query.Month.Format("2006-01"),
+				Uptime:  &uptime,
+				Devices: []types.DeviceUptime{{DeviceID: "device-1", Monitored: 100, Online: 99, Uptime: &uptime}},
+			}, nil
+		},
+	}
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format("2006-01")
+
+	is.NoErr(os.MkdirAll(filepath.Join(dir, "other"), 0755))
+	is.NoErr(os.WriteFile(filepath.Join(dir, "other", month+".json"), []byte("{}"), 0644))
+
+	g := New(r, &Config{Directory: dir})
+	is.NoErr(g.Run(ctx))
+
+	calls := r.UptimeReportCalls()
+	is.Equal(len(calls), 1) // the report of the other tenant already exists
+	is.Equal(calls[0].Query.Tenant, "default")
+
+	b, err := os.ReadFile(filepath.Join(dir, "default", month+".csv"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(b), "default;"+month+";device-1;;100;99;99.50;0;0"))
+
+	_, err = os.Stat(filepath.Join(dir, "default", month+".json"))
+	is.NoErr(err)
+}
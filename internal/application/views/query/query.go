@@ -0,0 +1,10 @@
+package query
+
+// Views matches the views in the allowed tenants that are shared or owned by User.
+type Views struct {
+	ID             string
+	User           string
+	AllowedTenants []string
+	Offset         *int
+	Limit          *int
+}
@@ -0,0 +1,145 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	viewquery "github.com/diwise/iot-device-mgmt/internal/application/views/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrViewNotFound      = errors.New("view not found")
+	ErrViewAlreadyExist  = errors.New("view already exists")
+	ErrViewNotAllowed    = errors.New("view is owned by another user")
+	ErrMissingTenant     = errors.New("missing tenant information")
+	ErrInvalidView       = types.ErrInvalidView
+	errPrivateViewNoUser = fmt.Errorf("%w: a private view requires a user", ErrInvalidView)
+)
+
+//go:generate moq -rm -out viewstorage_mock.go . ViewStorage
+type ViewStorage interface {
+	GetViews(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error)
+	CreateView(ctx context.Context, v types.View) error
+	UpdateView(ctx context.Context, v types.View) error
+	DeleteView(ctx context.Context, viewID string) error
+}
+
+//go:generate moq -rm -out viewservice_mock.go . ViewService
+type ViewService interface {
+	Views(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error)
+	View(ctx context.Context, viewID, user string, tenants []string) (types.View, error)
+	Create(ctx context.Context, v types.View, user string) (types.View, error)
+	Update(ctx context.Context, v types.View, user string, tenants []string) error
+	Delete(ctx context.Context, viewID, user string, tenants []string) error
+}
+
+type svc struct {
+	storage ViewStorage
+}
+
+func New(s ViewStorage) ViewService {
+	return &svc{storage: s}
+}
+
+func (s *svc) Views(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error) {
+	if len(query.AllowedTenants) == 0 {
+		return types.Collection[types.View]{}, ErrMissingTenant
+	}
+
+	return s.storage.GetViews(ctx, query)
+}
+
+// View returns a view that is shared in one of the tenants or owned by the user.
+func (s *svc) View(ctx context.Context, viewID, user string, tenants []string) (types.View, error) {
+	if viewID == "" || len(tenants) == 0 {
+		return types.View{}, ErrViewNotFound
+	}
+
+	result, err := s.storage.GetViews(ctx, viewquery.Views{ID: viewID, User: user, AllowedTenants: tenants})
+	if err != nil {
+		return types.View{}, err
+	}
+
+	if result.Count != 1 {
+		return types.View{}, ErrViewNotFound
+	}
+
+	return result.Data[0], nil
+}
+
+// Create stores a new view owned by the user. Views without a user can only be shared. An id
+// that is taken by any view, visible to the user or not, returns ErrViewAlreadyExist.
+func (s *svc) Create(ctx context.Context, v types.View, user string) (types.View, error) {
+	if v.Tenant == "" {
+		return types.View{}, ErrMissingTenant
+	}
+
+	if !v.Shared && user == "" {
+		return types.View{}, errPrivateViewNoUser
+	}
+
+	if v.ID == "" {
+		v.ID = uuid.NewString()
+	}
+
+	v.Owner = user
+
+	if err := v.Validate(); err != nil {
+		return types.View{}, err
+	}
+
+	if err := s.storage.CreateView(ctx, v); err != nil {
+		return types.View{}, err
+	}
+
+	return v, nil
+}
+
+// Update replaces a view. Only the owner can change a view, except for shared views without an owner.
+func (s *svc) Update(ctx context.Context, v types.View, user string, tenants []string) error {
+	current, err := s.owned(ctx, v.ID, user, tenants)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(tenants, v.Tenant) {
+		return ErrMissingTenant
+	}
+
+	if !v.Shared && current.Owner == "" {
+		return errPrivateViewNoUser
+	}
+
+	v.Owner = current.Owner
+
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
+	return s.storage.UpdateView(ctx, v)
+}
+
+func (s *svc) Delete(ctx context.Context, viewID, user string, tenants []string) error {
+	if _, err := s.owned(ctx, viewID, user, tenants); err != nil {
+		return err
+	}
+
+	return s.storage.DeleteView(ctx, viewID)
+}
+
+func (s *svc) owned(ctx context.Context, viewID, user string, tenants []string) (types.View, error) {
+	v, err := s.View(ctx, viewID, user, tenants)
+	if err != nil {
+		return types.View{}, err
+	}
+
+	if v.Owner != "" && v.Owner != user {
+		return types.View{}, ErrViewNotAllowed
+	}
+
+	return v, nil
+}
@@ -0,0 +1,83 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	viewquery "github.com/diwise/iot-device-mgmt/internal/application/views/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/matryer/is"
+)
+
+func TestCreateView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	storage := &ViewStorageMock{
+		GetViewsFunc: func(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error) {
+			return types.Collection[types.View]{}, nil
+		},
+		CreateViewFunc: func(ctx context.Context, v types.View) error {
+			if v.ID == "taken" {
+				return ErrViewAlreadyExist
+			}
+			return nil
+		},
+	}
+
+	svc := New(storage)
+
+	v, err := svc.Create(ctx, types.View{Name: "offline pumps", Tenant: "default", Filters: url.Values{"online": {"false"}}}, "alice")
+	is.NoErr(err)
+	is.True(v.ID != "")
+	is.Equal(v.Owner, "alice")
+
+	_, err = svc.Create(ctx, types.View{Name: "offline pumps", Tenant: "default"}, "")
+	is.True(errors.Is(err, ErrInvalidView)) // a private view requires a user
+
+	_, err = svc.Create(ctx, types.View{Name: "first page", Tenant: "default", Shared: true, Filters: url.Values{"limit": {"10"}}}, "")
+	is.True(errors.Is(err, ErrInvalidView)) // paging is not a filter
+
+	_, err = svc.Create(ctx, types.View{ID: "taken", Name: "pumps", Tenant: "default", Shared: true}, "")
+	is.True(errors.Is(err, ErrViewAlreadyExist)) // the id of a view in another tenant is not reused
+
+	is.Equal(len(storage.CreateViewCalls()), 2)
+	is.Equal(len(storage.GetViewsCalls()), 0) // a new id is not looked up among the visible views
+}
+
+func TestOnlyTheOwnerCanChangeAView(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	view := types.View{ID: "pumps", Name: "pumps", Tenant: "default", Owner: "alice", Shared: true}
+
+	storage := &ViewStorageMock{
+		GetViewsFunc: func(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error) {
+			return types.Collection[types.View]{Count: 1, Data: []types.View{view}}, nil
+		},
+		UpdateViewFunc: func(ctx context.Context, v types.View) error {
+			return nil
+		},
+		DeleteViewFunc: func(ctx context.Context, viewID string) error {
+			return nil
+		},
+	}
+
+	svc := New(storage)
+
+	err := svc.Update(ctx, types.View{ID: "pumps", Name: "my pumps", Tenant: "default"}, "bob", []string{"default"})
+	is.True(errors.Is(err, ErrViewNotAllowed))
+
+	err = svc.Delete(ctx, "pumps", "bob", []string{"default"})
+	is.True(errors.Is(err, ErrViewNotAllowed))
+
+	err = svc.Update(ctx, types.View{ID: "pumps", Name: "my pumps", Tenant: "default"}, "alice", []string{"default"})
+	is.NoErr(err)
+	is.Equal(storage.UpdateViewCalls()[0].V.Owner, "alice")
+
+	err = svc.Delete(ctx, "pumps", "alice", []string{"default"})
+	is.NoErr(err)
+	is.Equal(storage.GetViewsCalls()[0].Query.User, "bob")
+}
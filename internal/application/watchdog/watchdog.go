@@ -156,6 +156,9 @@ func (l *lastObservedWatcher) checkLastObserved(ctx context.Context) {
 
 		now := time.Now()
 		desc := fmt.Sprintf("current time: %s, interval: %d, last seen: %s, limit: %s", now.UTC().Format(time.RFC3339), d.Interval, d.DeviceState.ObservedAt.Format(time.RFC3339), d.DeviceState.ObservedAt.Add(time.Duration(d.Interval)*time.Second).Format(time.RFC3339))
+		if d.Calendar != nil {
+			desc += fmt.Sprintf(", expected silence: %s", d.Calendar.ExpectedDuration(d.DeviceState.ObservedAt, now).Round(time.Second))
+		}
 
 		l.alarmSvc.Add(ctx, d.DeviceID, types.AlarmDetails{
 			AlarmType:   alarms.AlarmDeviceNotObserved,
@@ -2,9 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
@@ -42,7 +44,9 @@ func (s *Storage) Add(ctx context.Context, deviceID string, a types.AlarmDetails
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = tx.Exec(ctx, `
+	// an alarm that was observed at the same time as, or before, the stored alarm is a
+	// repeat and is neither stored nor counted, as for device status messages.
+	res, err := tx.Exec(ctx, `
 		INSERT INTO device_alarms (device_id, type, description, observed_at, severity)
 		VALUES (@device_id, @type, @description, @observed_at, @severity)
 		ON CONFLICT (device_id, type) DO UPDATE
@@ -51,15 +55,61 @@ func (s *Storage) Add(ctx context.Context, deviceID string, a types.AlarmDetails
 				observed_at=EXCLUDED.observed_at,
 				severity=EXCLUDED.severity,
 				count = device_alarms.count + 1
+			WHERE device_alarms.observed_at < EXCLUDED.observed_at
 				`, args)
 	if err != nil {
 		log.Error("could not insert or update device alarm", "err", err.Error())
 		return err
 	}
 
+	if res.RowsAffected() == 0 {
+		return alarms.ErrAlarmNotNewer
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO device_alarm_history (device_id, type, severity, started_at)
+		SELECT @device_id, @type, @severity, @observed_at
+		WHERE NOT EXISTS (
+			SELECT 1 FROM device_alarm_history
+			WHERE device_id=@device_id AND type=@type AND ended_at IS NULL)
+		ON CONFLICT DO NOTHING`, args)
+	if err != nil {
+		log.Error("could not insert device alarm history", "err", err.Error())
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
+// DeviceObservedAt returns when the current state of a device was observed, or zero if unknown.
+func (s *Storage) DeviceObservedAt(ctx context.Context, deviceID string) (time.Time, error) {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return time.Time{}, err
+	}
+	defer c.Release()
+
+	var observedAt *time.Time
+
+	err = c.QueryRow(ctx, `SELECT observed_at FROM device_state WHERE device_id=@device_id`, pgx.NamedArgs{"device_id": deviceID}).Scan(&observedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, nil
+		}
+		log.Error("could not query device state", "device_id", deviceID, "err", err.Error())
+		return time.Time{}, err
+	}
+
+	if observedAt == nil {
+		return time.Time{}, nil
+	}
+
+	return observedAt.UTC(), nil
+}
+
 func (s *Storage) GetDeviceAlarms(ctx context.Context, deviceID string) (types.Collection[types.AlarmDetails], error) {
 	if deviceID == "" {
 		return types.Collection[types.AlarmDetails]{}, ErrNoID
@@ -151,6 +201,14 @@ func (s *Storage) Remove(ctx context.Context, deviceID string, alarmType string)
 		return err
 	}
 
+	_, err = tx.Exec(ctx, `
+		UPDATE device_alarm_history SET ended_at = NOW()
+		WHERE device_id=@device_id AND type=@alarm_type AND ended_at IS NULL`, args)
+	if err != nil {
+		log.Error("could not end device alarm history", "err", err.Error())
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -162,15 +220,30 @@ func (s *Storage) Alarms(ctx context.Context, query alarmquery.Alarms) (types.Co
 	args := NamedArgs(condition)
 	offsetLimit, offset, limit := OffsetLimit(condition, 0, 5)
 
+	after := ""
+	if query.Cursor != nil {
+		if c := alarmKeyset.after(*query.Cursor); c != "" {
+			after = "WHERE " + c
+		}
+		cursorArgs(args, query.Cursor)
+	}
+
+	// the total is counted before the cursor predicate, while count is the number of
+	// alarms from the cursor on that decides if there is a next page.
 	sql := fmt.Sprintf(`
-		SELECT a.device_id, array_agg(type) as type, MAX(severity) as severity, MAX(observed_at) as observed_at, count(*) OVER () AS count
-		FROM device_alarms a
-		JOIN devices d ON a.device_id = d.device_id
+		WITH grouped AS (
+			SELECT a.device_id, array_agg(type) as type, MAX(severity) as severity, MAX(observed_at) as observed_at
+			FROM device_alarms a
+			JOIN devices d ON a.device_id = d.device_id
+			%s
+			GROUP BY a.device_id
+		)
+		SELECT a.device_id, a.type, a.severity, a.observed_at, %s, count(*) OVER () AS count, (SELECT count(*) FROM grouped) AS total_count
+		FROM grouped a
+		%s
 		%s
-		GROUP BY a.device_id
-		ORDER BY observed_at DESC
 		%s
-	`, Where(condition), offsetLimit)
+	`, Where(condition), alarmKeyset.column(), after, alarmKeyset.orderBy(), offsetLimit)
 
 	c, err := s.conn.Acquire(ctx)
 	if err != nil {
@@ -186,7 +259,8 @@ func (s *Storage) Alarms(ctx context.Context, query alarmquery.Alarms) (types.Co
 	}
 	defer rows.Close()
 
-	var totalCount uint64
+	var count, totalCount uint64
+	var cursorKey string
 	alarms := []types.Alarms{}
 
 	for rows.Next() {
@@ -195,7 +269,7 @@ func (s *Storage) Alarms(ctx context.Context, query alarmquery.Alarms) (types.Co
 		var typs []string
 		var severity int
 
-		err := rows.Scan(&deviceID, &typs, &severity, &observedAt, &totalCount)
+		err := rows.Scan(&deviceID, &typs, &severity, &observedAt, &cursorKey, &count, &totalCount)
 		if err != nil {
 			log.Error("could not scan row", "err", err.Error())
 			return types.Collection[types.Alarms]{}, err
@@ -211,11 +285,17 @@ func (s *Storage) Alarms(ctx context.Context, query alarmquery.Alarms) (types.Co
 		return types.Collection[types.Alarms]{}, err
 	}
 
+	var next *types.Cursor
+	if query.Cursor != nil && len(alarms) > 0 {
+		next = nextCursor(cursorKey, alarms[len(alarms)-1].DeviceID, count, len(alarms))
+	}
+
 	return types.Collection[types.Alarms]{
 		Data:       alarms,
 		Count:      uint64(len(alarms)),
 		Offset:     uint64(offset),
 		Limit:      uint64(limit),
 		TotalCount: totalCount,
+		Cursor:     next,
 	}, nil
 }
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetStatusTimestamps returns the time of each status row of the sensors within a period.
+// Only raw status rows are used, so the period should be within the raw retention.
+func (s *Storage) GetStatusTimestamps(ctx context.Context, sensorIDs []string, from, to time.Time) (map[string][]time.Time, error) {
+	timestamps := map[string][]time.Time{}
+
+	if len(sensorIDs) == 0 {
+		return timestamps, nil
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT sensor_id, observed_at
+		FROM sensor_status
+		WHERE sensor_id = ANY(@sensor_ids) AND observed_at >= @from AND observed_at < @to
+		ORDER BY sensor_id, observed_at`, pgx.NamedArgs{
+		"sensor_ids": sensorIDs,
+		"from":       from.UTC(),
+		"to":         to.UTC(),
+	})
+	if err != nil {
+		log.Error("could not query status timestamps", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sensorID string
+		var observedAt time.Time
+
+		if err := rows.Scan(&sensorID, &observedAt); err != nil {
+			log.Error("could not scan status timestamp", "err", err.Error())
+			return nil, err
+		}
+
+		timestamps[sensorID] = append(timestamps[sensorID], observedAt.UTC())
+	}
+
+	return timestamps, rows.Err()
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/battery"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetBatteryLevels returns the daily average battery level of each sensor since a point in time.
+// Days that have not been rolled up yet are averaged from the raw status rows.
+func (s *Storage) GetBatteryLevels(ctx context.Context, since time.Time) (map[string][]battery.Reading, error) {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT sensor_id, bucket, battery_avg::float8
+		FROM sensor_status_daily
+		WHERE bucket >= @since AND battery_avg IS NOT NULL
+		UNION ALL
+		SELECT sensor_id, date_trunc('day', observed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', avg(battery_level)::float8
+		FROM sensor_status
+		WHERE observed_at >= GREATEST(@since, COALESCE((SELECT max(bucket) + INTERVAL '1 day' FROM sensor_status_daily), '-infinity'::timestamptz))
+			AND battery_level IS NOT NULL
+		GROUP BY 1, 2`, pgx.NamedArgs{"since": since.UTC()})
+	if err != nil {
+		log.Error("could not query battery levels", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	levels := map[string][]battery.Reading{}
+
+	for rows.Next() {
+		var sensorID string
+		var r battery.Reading
+
+		err := rows.Scan(&sensorID, &r.ObservedAt, &r.Level)
+		if err != nil {
+			log.Error("could not scan battery level", "err", err.Error())
+			return nil, err
+		}
+
+		r.ObservedAt = r.ObservedAt.UTC()
+		levels[sensorID] = append(levels[sensorID], r)
+	}
+
+	return levels, rows.Err()
+}
+
+// SetBatteryForecasts replaces all battery forecasts.
+func (s *Storage) SetBatteryForecasts(ctx context.Context, forecasts []types.BatteryForecast) error {
+	var sensorIDs []string
+	var level, rate, threshold []float64
+	var depletesAt, replacedAt []*time.Time
+	var samples []int32
+	var computedAt []time.Time
+
+	for _, f := range forecasts {
+		sensorIDs = append(sensorIDs, f.SensorID)
+		level = append(level, f.Level)
+		rate = append(rate, f.Rate)
+		threshold = append(threshold, f.Threshold)
+		depletesAt = append(depletesAt, f.DepletesAt)
+		replacedAt = append(replacedAt, f.ReplacedAt)
+		samples = append(samples, int32(f.Samples))
+		computedAt = append(computedAt, f.ComputedAt)
+	}
+
+	args := pgx.NamedArgs{
+		"sensor_ids":  sensorIDs,
+		"level":       level,
+		"rate":        rate,
+		"threshold":   threshold,
+		"depletes_at": depletesAt,
+		"replaced_at": replacedAt,
+		"samples":     samples,
+		"computed_at": computedAt,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM sensor_battery_forecast
+		WHERE NOT (sensor_id = ANY(COALESCE(@sensor_ids::text[], '{}')));`, args)
+	if err != nil {
+		log.Error("could not delete battery forecasts", "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensor_battery_forecast (sensor_id, level, rate, threshold, depletes_at, replaced_at, samples, computed_at)
+		SELECT f.sensor_id, f.level, f.rate, f.threshold, f.depletes_at, f.replaced_at, f.samples, f.computed_at
+		FROM unnest(@sensor_ids::text[], @level::float8[], @rate::float8[], @threshold::float8[], @depletes_at::timestamptz[], @replaced_at::timestamptz[], @samples::int[], @computed_at::timestamptz[])
+			AS f(sensor_id, level, rate, threshold, depletes_at, replaced_at, samples, computed_at)
+		JOIN sensors s ON s.sensor_id = f.sensor_id
+		ON CONFLICT (sensor_id) DO UPDATE
+			SET level = EXCLUDED.level,
+				rate = EXCLUDED.rate,
+				threshold = EXCLUDED.threshold,
+				depletes_at = EXCLUDED.depletes_at,
+				replaced_at = EXCLUDED.replaced_at,
+				samples = EXCLUDED.samples,
+				computed_at = EXCLUDED.computed_at;`, args)
+	if err != nil {
+		log.Error("could not store battery forecasts", "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetBatteryForecasts returns the battery forecasts of sensors belonging to the allowed
+// tenants, ordered by the expected depletion time.
+func (s *Storage) GetBatteryForecasts(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error) {
+	log := logging.GetFromContext(ctx)
+
+	condition := &Condition{Tenants: query.AllowedTenants, Offset: query.Offset, Limit: query.Limit}
+	offsetLimit, offset, limit := OffsetLimit(condition)
+
+	args := pgx.NamedArgs{"tenants": query.AllowedTenants}
+
+	filter := ""
+	if query.Tenant != "" {
+		filter += " AND COALESCE(d.tenant, s.tenant) = @tenant"
+		args["tenant"] = query.Tenant
+	}
+	if query.DepletesBefore != nil {
+		filter += " AND bf.depletes_at < @depletes_before"
+		args["depletes_before"] = query.DepletesBefore.UTC()
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.BatteryForecast]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, fmt.Sprintf(`
+		SELECT bf.sensor_id, d.device_id, COALESCE(d.tenant, s.tenant),
+			bf.level::float8, bf.rate::float8, bf.threshold::float8, bf.depletes_at, bf.replaced_at, bf.samples, bf.computed_at,
+			count(*) OVER () AS count
+		FROM sensor_battery_forecast bf
+		JOIN sensors s ON s.sensor_id = bf.sensor_id
+		LEFT JOIN devices d ON d.sensor_id = bf.sensor_id AND d.deleted = FALSE
+		WHERE COALESCE(d.tenant, s.tenant) = ANY(@tenants)%s
+		ORDER BY bf.depletes_at ASC NULLS LAST, bf.sensor_id ASC
+		%s`, filter, offsetLimit), args)
+	if err != nil {
+		log.Error("could not query battery forecasts", "err", err.Error())
+		return types.Collection[types.BatteryForecast]{}, err
+	}
+	defer rows.Close()
+
+	forecasts := []types.BatteryForecast{}
+	var count uint64
+
+	for rows.Next() {
+		var f types.BatteryForecast
+		var deviceID, tenant *string
+
+		err := rows.Scan(&f.SensorID, &deviceID, &tenant, &f.Level, &f.Rate, &f.Threshold, &f.DepletesAt, &f.ReplacedAt, &f.Samples, &f.ComputedAt, &count)
+		if err != nil {
+			log.Error("could not scan battery forecast", "err", err.Error())
+			return types.Collection[types.BatteryForecast]{}, err
+		}
+
+		if deviceID != nil {
+			f.DeviceID = *deviceID
+		}
+		if tenant != nil {
+			f.Tenant = *tenant
+		}
+
+		forecasts = append(forecasts, utcBatteryForecast(f))
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Error("error iterating rows", "err", err.Error())
+		return types.Collection[types.BatteryForecast]{}, err
+	}
+
+	return types.Collection[types.BatteryForecast]{
+		Data:       forecasts,
+		Count:      uint64(len(forecasts)),
+		TotalCount: count,
+		Offset:     uint64(offset),
+		Limit:      uint64(limit),
+	}, nil
+}
+
+func utcBatteryForecast(f types.BatteryForecast) types.BatteryForecast {
+	if f.DepletesAt != nil {
+		t := f.DepletesAt.UTC()
+		f.DepletesAt = &t
+	}
+	if f.ReplacedAt != nil {
+		t := f.ReplacedAt.UTC()
+		f.ReplacedAt = &t
+	}
+	f.ComputedAt = f.ComputedAt.UTC()
+	return f
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+// GetDeviceClusters groups the located devices matching the filters into the cells of a web
+// mercator grid, with 2^zoom * ClusterCellsPerTile cells along each side, and counts the
+// devices of each cell by online status, state and alarm severity.
+func (s *Storage) GetDeviceClusters(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+	condition := deviceConditionFromQuery(dmquery.DeviceFilters{Filters: query.Filters})
+	condition.Trigram = s.trigram
+
+	args := NamedArgs(condition)
+	args["cells"] = (1 << query.Zoom) * dmquery.ClusterCellsPerTile
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	// devices without a location are stored at point(0,0) and are left out
+	rows, err := c.Query(ctx, fmt.Sprintf(`
+		WITH located AS (
+			SELECT
+				d.device_id,
+				d.location[0] AS lon,
+				LEAST(GREATEST(d.location[1], -85.05112878), 85.05112878) AS lat,
+				COALESCE(dst.online, FALSE) AS online,
+				COALESCE(dst.state, -1) AS state,
+				(SELECT max(a.severity) FROM device_alarms a WHERE a.device_id = d.device_id) AS severity
+			FROM devices d
+			LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
+			LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+			LEFT JOIN device_state dst ON dst.device_id = d.device_id
+			LEFT JOIN sensor_battery_forecast bf ON bf.sensor_id = d.sensor_id
+			LEFT JOIN sensor_link_quality lq ON lq.sensor_id = d.sensor_id
+			%s AND d.location IS NOT NULL AND NOT d.location ~= point(0,0)
+		),
+
+		gridded AS (
+			SELECT *,
+				LEAST(GREATEST(floor((1 - ln(tan(radians(lat)) + 1 / cos(radians(lat))) / pi()) / 2 * @cells::int), 0), @cells::int - 1)::int AS grid_row,
+				LEAST(GREATEST(floor((lon + 180) / 360 * @cells::int), 0), @cells::int - 1)::int AS grid_column
+			FROM located
+		)
+
+		SELECT
+			grid_row,
+			grid_column,
+			count(*),
+			min(device_id),
+			avg(lat), avg(lon),
+			min(lat), min(lon), max(lat), max(lon),
+			count(*) FILTER (WHERE online),
+			count(*) FILTER (WHERE state = 1),
+			count(*) FILTER (WHERE state = 2),
+			count(*) FILTER (WHERE state = 3),
+			count(*) FILTER (WHERE severity IS NULL),
+			count(*) FILTER (WHERE severity = 0),
+			count(*) FILTER (WHERE severity = 1),
+			count(*) FILTER (WHERE severity = 2),
+			count(*) FILTER (WHERE severity >= 3)
+		FROM gridded
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, DeviceWhere(condition)), args)
+	if err != nil {
+		log.Error("could not query device clusters", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	clusters := []types.DeviceCluster{}
+
+	for rows.Next() {
+		var cl types.DeviceCluster
+		var deviceID string
+
+		err := rows.Scan(
+			&cl.Row, &cl.Column, &cl.Count, &deviceID,
+			&cl.Location.Latitude, &cl.Location.Longitude,
+			&cl.Bounds.MinLat, &cl.Bounds.MinLon, &cl.Bounds.MaxLat, &cl.Bounds.MaxLon,
+			&cl.Online,
+			&cl.States.OK, &cl.States.Warning, &cl.States.Error,
+			&cl.Alarms.None, &cl.Alarms.Unknown, &cl.Alarms.Low, &cl.Alarms.Medium, &cl.Alarms.High,
+		)
+		if err != nil {
+			log.Error("could not scan device cluster", "err", err.Error())
+			return nil, err
+		}
+
+		if cl.Count == 1 {
+			cl.DeviceID = deviceID
+		}
+
+		cl.Offline = cl.Count - cl.Online
+		cl.States.Unknown = cl.Count - cl.States.OK - cl.States.Warning - cl.States.Error
+
+		clusters = append(clusters, cl)
+	}
+
+	return clusters, rows.Err()
+}
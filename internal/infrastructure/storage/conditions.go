@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"fmt"
+	"maps"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,6 +11,7 @@ import (
 
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
 	"github.com/jackc/pgx/v5"
 )
@@ -40,9 +42,21 @@ type Condition struct {
 
 	LastSeen time.Time
 
+	BatteryDepletesBefore time.Time
+	LinkQuality           []string
+
 	Search string
 
+	// Filter is a filter expression on devices, compiled by filterWhere.
+	Filter dmquery.Expr
+
 	Bounds *Box
+	Within []types.Polygon
+	Near   *types.Location
+	Radius float64
+
+	LocatedWithin []types.Polygon
+	At            time.Time
 
 	Name           string
 	Urn            string
@@ -50,6 +64,12 @@ type Condition struct {
 
 	IncludeDeleted bool
 
+	// Cursor pages devices in the order of deviceKeyset instead of by offset.
+	Cursor *types.Cursor
+
+	// Trigram matches Search by similarity too, when pg_trgm is available.
+	Trigram bool
+
 	Export bool
 
 	SortBy    string
@@ -110,6 +130,8 @@ func WithSortBy(sortBy string) ConditionFunc {
 			fallthrough
 		case "sensor_profile_id":
 			c.SortBy = "sp.sensor_profile_id"
+		case "battery_depletes_at":
+			c.SortBy = "bf.depletes_at"
 		}
 
 		return c
@@ -371,7 +393,28 @@ func Where(c *Condition) string {
 }
 
 func DeviceWhere(c *Condition) string {
-	return where(c, deviceTypeUrnWhere(c))
+	extra := deviceTypeUrnWhere(c)
+
+	if !c.BatteryDepletesBefore.IsZero() {
+		extra = append(extra, "bf.depletes_at < @battery_depletes_before")
+	}
+
+	if len(c.LinkQuality) > 0 {
+		extra = append(extra, "lq.class = ANY(@link_quality)")
+	}
+
+	if c.Filter != nil {
+		where, _ := filterWhere(c.Filter)
+		extra = append(extra, where)
+	}
+
+	if c.Cursor != nil {
+		if after := deviceKeyset(c).after(*c.Cursor); after != "" {
+			extra = append(extra, after)
+		}
+	}
+
+	return where(c, extra)
 }
 
 func where(c *Condition, extra []string) string {
@@ -416,11 +459,23 @@ func where(c *Condition, extra []string) string {
 	}
 
 	if c.Bounds != nil {
-		where = append(where, fmt.Sprintf("location <@ BOX '((%f,%f),(%f,%f))'", c.Bounds.MinX, c.Bounds.MinY, c.Bounds.MaxX, c.Bounds.MaxY))
+		where = append(where, fmt.Sprintf("d.location <@ BOX '((%f,%f),(%f,%f))'", c.Bounds.MinX, c.Bounds.MinY, c.Bounds.MaxX, c.Bounds.MaxY))
+	}
+
+	if len(c.Within) > 0 {
+		where = append(where, withinWhere("d.location", "within", c.Within))
+	}
+
+	if c.Near != nil && c.Radius > 0 {
+		where = append(where, distance("d.location")+" <= @radius")
+	}
+
+	if len(c.LocatedWithin) > 0 && !c.At.IsZero() {
+		where = append(where, locatedWhere(c.LocatedWithin))
 	}
 
 	if c.Search != "" {
-		where = append(where, "(d.device_id ILIKE @search OR d.sensor_id ILIKE @search OR d.name ILIKE @search)")
+		where = append(where, searchMatch(c.Search, deviceSearchDocument, c.Trigram))
 	}
 
 	if !c.LastSeen.IsZero() {
@@ -467,6 +522,183 @@ func where(c *Condition, extra []string) string {
 	return "WHERE " + strings.Join(where, " AND ")
 }
 
+// withinWhere matches a location column inside the exterior ring, and outside the holes,
+// of any of the polygons. The rings are given as arguments, prefixed by name, by polygonArgs.
+func withinWhere(column, name string, polygons []types.Polygon) string {
+	or := []string{}
+
+	for i, p := range polygons {
+		and := []string{}
+		for j := range p {
+			if j == 0 {
+				and = append(and, fmt.Sprintf("%s <@ @%s_%d_%d::polygon", column, name, i, j))
+			} else {
+				and = append(and, fmt.Sprintf("NOT %s <@ @%s_%d_%d::polygon", column, name, i, j))
+			}
+		}
+		or = append(or, "("+strings.Join(and, " AND ")+")")
+	}
+
+	return "(" + strings.Join(or, " OR ") + ")"
+}
+
+// locatedWhere matches devices whose last recorded location at @at is within the polygons.
+func locatedWhere(polygons []types.Polygon) string {
+	return `EXISTS (SELECT 1 FROM (
+		SELECT h.location FROM device_location_history h
+		WHERE h.device_id = d.device_id AND h.observed_at <= @at
+		ORDER BY h.observed_at DESC LIMIT 1) lh
+		WHERE ` + withinWhere("lh.location", "located", polygons) + ")"
+}
+
+// distance is the great-circle distance, in meters, between a location column and @near_lat, @near_lon.
+func distance(column string) string {
+	return fmt.Sprintf(`(2 * 6371008.8 * asin(sqrt(
+		power(sin(radians(%[1]s[1] - @near_lat) / 2), 2) +
+		cos(radians(@near_lat)) * cos(radians(%[1]s[1])) * power(sin(radians(%[1]s[0] - @near_lon) / 2), 2))))`, column)
+}
+
+func geoArgs(args pgx.NamedArgs, within []types.Polygon, near *types.Location, radius float64) {
+	polygonArgs(args, "within", within)
+
+	if near != nil {
+		args["near_lat"] = near.Latitude
+		args["near_lon"] = near.Longitude
+		if radius > 0 {
+			args["radius"] = radius
+		}
+	}
+}
+
+func polygonArgs(args pgx.NamedArgs, name string, polygons []types.Polygon) {
+	for i, p := range polygons {
+		for j, ring := range p {
+			points := make([]string, 0, len(ring))
+			for _, pos := range ring {
+				points = append(points, fmt.Sprintf("(%f,%f)", pos[0], pos[1]))
+			}
+			args[fmt.Sprintf("%s_%d_%d", name, i, j)] = "(" + strings.Join(points, ",") + ")"
+		}
+	}
+}
+
+// filterColumns are the columns of the filter fields that are compared directly. The other
+// fields are matched with subqueries by filterComparison.
+var filterColumns = map[string]string{
+	"deviceID":     "d.device_id",
+	"sensorID":     "d.sensor_id",
+	"name":         "d.name",
+	"description":  "d.description",
+	"environment":  "d.environment",
+	"source":       "d.source",
+	"tenant":       "d.tenant",
+	"profile":      "sp.sensor_profile_id",
+	"active":       "d.active",
+	"online":       "COALESCE(dst.online, FALSE)",
+	"state":        "dst.state",
+	"batteryLevel": "s.battery_level",
+	"rssi":         "s.rssi",
+	"snr":          "s.snr",
+	"lastSeen":     "dst.observed_at",
+}
+
+// filterSets are the subqueries, correlated on d.device_id, from which set fields are matched
+// by their column.
+var filterSets = map[string]struct{ query, column string }{
+	"tag":   {"SELECT 1 FROM device_device_tags ddt WHERE ddt.device_id = d.device_id", "ddt.name"},
+	"alarm": {"SELECT 1 FROM device_alarms a WHERE a.device_id = d.device_id", "a.type"},
+}
+
+// filterWhere compiles a filter expression into a condition and its arguments, named filter_0,
+// filter_1 and so on in the order of the comparisons.
+func filterWhere(e dmquery.Expr) (string, pgx.NamedArgs) {
+	args := pgx.NamedArgs{}
+	return filterExpr(e, args), args
+}
+
+func filterExpr(e dmquery.Expr, args pgx.NamedArgs) string {
+	switch e := e.(type) {
+	case dmquery.And:
+		return "(" + filterExpr(e.Left, args) + " AND " + filterExpr(e.Right, args) + ")"
+	case dmquery.Or:
+		return "(" + filterExpr(e.Left, args) + " OR " + filterExpr(e.Right, args) + ")"
+	case dmquery.Not:
+		return "NOT COALESCE(" + filterExpr(e.Expr, args) + ", FALSE)"
+	case dmquery.Comparison:
+		return filterComparison(e, args)
+	}
+
+	return "FALSE"
+}
+
+// filterComparison compiles a comparison. Values that do not exist, such as a missing metadata
+// key, never match == but always match !=.
+func filterComparison(c dmquery.Comparison, args pgx.NamedArgs) string {
+	arg := func(v any) string {
+		name := fmt.Sprintf("filter_%d", len(args))
+		args[name] = v
+		return "@" + name
+	}
+
+	compare := func(column string) string {
+		switch c.Op {
+		case "in":
+			return column + " = ANY(" + arg(filterList(c.Values)) + ")"
+		case "==", "!=":
+			return column + " = " + arg(c.Values[0])
+		}
+		return column + " " + c.Op + " " + arg(c.Values[0])
+	}
+
+	exists := func(query, column string) string {
+		if c.Op == "!=" {
+			return fmt.Sprintf("NOT EXISTS (%s AND %s)", query, compare(column))
+		}
+		return fmt.Sprintf("EXISTS (%s AND %s)", query, compare(column))
+	}
+
+	switch c.Kind {
+	case dmquery.FieldSet:
+		set := filterSets[c.Field]
+		return exists(set.query, set.column)
+	case dmquery.FieldMetadata:
+		column := "dm.vs"
+		switch c.Values[0].(type) {
+		case float64:
+			column = "dm.v"
+		case bool:
+			column = "dm.vb"
+		}
+		return exists("SELECT 1 FROM device_metadata dm WHERE dm.device_id = d.device_id AND dm.key = "+arg(c.Key), column)
+	}
+
+	column := filterColumns[c.Field]
+	if c.Op == "!=" {
+		return column + " IS DISTINCT FROM " + arg(c.Values[0])
+	}
+
+	return compare(column)
+}
+
+// filterList converts the values of an in operator, which are all of the same type, to a typed
+// slice so that they can be sent as an array.
+func filterList(values []any) any {
+	switch values[0].(type) {
+	case float64:
+		list := make([]float64, 0, len(values))
+		for _, v := range values {
+			list = append(list, v.(float64))
+		}
+		return list
+	}
+
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		list = append(list, fmt.Sprint(v))
+	}
+	return list
+}
+
 func deviceTypeUrnWhere(c *Condition) []string {
 	if len(c.DeviceTypeUrns) == 1 && c.DeviceTypeUrns[0] != "" {
 		return []string{"EXISTS (SELECT 1 FROM device_sensor_profile_types dspt WHERE dspt.device_id = d.device_id AND dspt.sensor_profile_type_id = @device_type_urn)"}
@@ -515,14 +747,26 @@ func NamedArgs(c *Condition) pgx.NamedArgs {
 	if !c.LastSeen.IsZero() {
 		args["last_seen"] = c.LastSeen.UTC().Format(time.RFC3339)
 	}
-	if c.Search != "" {
-		term := c.Search
+	if !c.BatteryDepletesBefore.IsZero() {
+		args["battery_depletes_before"] = c.BatteryDepletesBefore.UTC()
+	}
+	if len(c.LinkQuality) > 0 {
+		args["link_quality"] = c.LinkQuality
+	}
+	geoArgs(args, c.Within, c.Near, c.Radius)
+	cursorArgs(args, c.Cursor)
 
-		if !strings.Contains(term, "%") {
-			term = "%" + strings.TrimSpace(term) + "%"
-		}
+	if c.Filter != nil {
+		_, filterArgs := filterWhere(c.Filter)
+		maps.Copy(args, filterArgs)
+	}
 
-		args["search"] = term
+	if len(c.LocatedWithin) > 0 && !c.At.IsZero() {
+		polygonArgs(args, "located", c.LocatedWithin)
+		args["at"] = c.At.UTC()
+	}
+	if c.Search != "" {
+		searchArgs(args, c.Search)
 	}
 	if c.Offset != nil {
 		args["offset"] = *c.Offset
@@ -612,6 +856,7 @@ func deviceConditionFromQuery(query dmquery.DeviceFilters) *Condition {
 		ProfileName:    query.ProfileNames,
 		Metadata:       query.Metadata,
 		Search:         query.Search,
+		Filter:         query.Filter,
 		Name:           query.Name,
 		Urn:            query.Urn,
 		DeviceTypeUrns: query.Urns,
@@ -633,11 +878,34 @@ func deviceConditionFromQuery(query dmquery.DeviceFilters) *Condition {
 		condition.LastSeen = *query.LastSeen
 	}
 
+	if query.BatteryDepletesBefore != nil {
+		condition.BatteryDepletesBefore = *query.BatteryDepletesBefore
+	}
+
+	condition.LinkQuality = query.LinkQuality
+	condition.Within = query.Within
+	condition.Near = query.Near
+	condition.Radius = query.Radius
+	condition.LocatedWithin = query.LocatedWithin
+
+	if query.At != nil {
+		condition.At = *query.At
+	}
+
 	if query.SortBy != "" {
 		condition = WithSortBy(query.SortBy)(condition)
 		condition = WithSortDesc(query.SortDesc)(condition)
 	}
 
+	if strings.EqualFold(query.SortBy, "distance") && query.Near != nil {
+		condition.SortBy = distance("d.location")
+	}
+
+	if query.Cursor != nil {
+		condition.Cursor = query.Cursor
+		condition.Offset = nil
+	}
+
 	return condition
 }
 
@@ -693,6 +961,10 @@ func alarmConditionFromQuery(query alarmquery.Alarms) *Condition {
 		Limit:     query.Limit,
 	}
 
+	if query.Cursor != nil {
+		condition.Offset = nil
+	}
+
 	if query.ActiveOnly {
 		active := true
 		condition.Active = &active
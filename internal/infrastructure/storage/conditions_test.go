@@ -6,6 +6,12 @@ import (
 	"testing"
 	"time"
 
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
+	viewquery "github.com/diwise/iot-device-mgmt/internal/application/views/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/jackc/pgx/v5"
 	"github.com/matryer/is"
 )
 
@@ -51,7 +57,8 @@ func TestWhere(t *testing.T) {
 		is.True(strings.Contains(where, "sp.decoder = @profile"))
 		is.True(strings.Contains(where, "sp.name = ANY(@profile_name)"))
 		is.True(strings.Contains(where, "location <@ BOX '((1.100000,2.200000),(3.300000,4.400000))'"))
-		is.True(strings.Contains(where, "(d.device_id ILIKE @search OR d.sensor_id ILIKE @search OR d.name ILIKE @search)"))
+		is.True(strings.Contains(where, "(d.search_document ILIKE @search OR "))
+		is.True(strings.Contains(where, "@@ to_tsquery('simple', @search_query)"))
 		is.True(strings.Contains(where, "dst.observed_at >= @last_seen"))
 		is.True(strings.Contains(where, "d.deleted=FALSE"))
 		is.True(strings.Contains(where, "d.name=@name"))
@@ -261,3 +268,247 @@ func TestOrderByWithFallback(t *testing.T) {
 		is.Equal(order, "")
 	})
 }
+
+func TestGeoConditions(t *testing.T) {
+	is := is.New(t)
+
+	square := types.Polygon{
+		{{17.0, 62.0}, {18.0, 62.0}, {18.0, 63.0}, {17.0, 63.0}, {17.0, 62.0}},
+		{{17.4, 62.4}, {17.6, 62.4}, {17.6, 62.6}, {17.4, 62.6}, {17.4, 62.4}},
+	}
+	near := &types.Location{Latitude: 62.39, Longitude: 17.30}
+
+	t.Run("holes are excluded and polygons are combined", func(t *testing.T) {
+		where := Where(&Condition{Within: []types.Polygon{square, square[:1]}})
+		is.True(strings.Contains(where, "((d.location <@ @within_0_0::polygon AND NOT d.location <@ @within_0_1::polygon) OR (d.location <@ @within_1_0::polygon))"))
+	})
+
+	t.Run("radius requires near", func(t *testing.T) {
+		where := Where(&Condition{Radius: 500})
+		is.True(!strings.Contains(where, "@radius"))
+
+		where = Where(&Condition{Near: near, Radius: 500})
+		is.True(strings.Contains(where, distance("d.location")+" <= @radius"))
+	})
+
+	t.Run("located within uses the location at a time", func(t *testing.T) {
+		at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		condition := &Condition{LocatedWithin: []types.Polygon{square}, At: at}
+
+		where := Where(condition)
+		is.True(strings.Contains(where, "FROM device_location_history h"))
+		is.True(strings.Contains(where, "h.observed_at <= @at"))
+		is.True(strings.Contains(where, "(lh.location <@ @located_0_0::polygon AND NOT lh.location <@ @located_0_1::polygon)"))
+
+		args := NamedArgs(condition)
+		is.Equal(args["at"], at)
+		is.True(args["located_0_0"] != nil)
+		is.True(args["within_0_0"] == nil)
+	})
+
+	t.Run("geo args", func(t *testing.T) {
+		args := NamedArgs(&Condition{Within: []types.Polygon{square}, Near: near, Radius: 500})
+		is.Equal(args["within_0_0"], "((17.000000,62.000000),(18.000000,62.000000),(18.000000,63.000000),(17.000000,63.000000),(17.000000,62.000000))")
+		is.True(args["within_0_1"] != nil)
+		is.Equal(args["near_lat"], 62.39)
+		is.Equal(args["near_lon"], 17.30)
+		is.Equal(args["radius"], 500.0)
+	})
+}
+
+func TestGeofenceWhere(t *testing.T) {
+	is := is.New(t)
+
+	where, args := geofenceWhere(dmquery.GeofenceFilters{})
+	is.Equal(where, "WHERE TRUE")
+	is.Equal(len(args), 0)
+
+	where, args = geofenceWhere(dmquery.GeofenceFilters{
+		Filters: dmquery.Filters{DeviceID: "lifebuoy-1", AllowedTenants: []string{"default"}},
+	})
+	is.True(strings.Contains(where, "g.tenant = ANY(@tenants)"))
+	is.True(strings.Contains(where, "g.device_id = @device_id OR g.tag IN"))
+	is.True(strings.Contains(where, "g.tenant = (SELECT d.tenant FROM devices d WHERE d.device_id = @device_id)")) // tag fences of other tenants do not apply
+	is.Equal(args["device_id"], "lifebuoy-1")
+}
+
+func TestDeviceKeyset(t *testing.T) {
+	is := is.New(t)
+
+	ks := deviceKeyset(&Condition{})
+	is.Equal(ks.orderBy(), "ORDER BY d.device_id ASC, d.device_id ASC")
+	is.Equal(ks.after(types.Cursor{}), "") // the first page starts at the beginning
+
+	ks = deviceKeyset(WithSortDesc(true)(WithSortBy("battery_depletes_at")(&Condition{})))
+	is.Equal(ks.orderBy(), "ORDER BY COALESCE(bf.depletes_at, 'infinity'::timestamptz) DESC, d.device_id DESC")
+	is.Equal(ks.after(types.Cursor{Key: "2026-01-01 00:00:00+00", ID: "device-1"}), "(COALESCE(bf.depletes_at, 'infinity'::timestamptz), d.device_id) < (@cursor_key::timestamptz, @cursor_id)")
+
+	offset := 20
+	c := deviceConditionFromQuery(dmquery.DeviceFilters{Filters: dmquery.Filters{
+		SortBy: "name",
+		Offset: &offset,
+		Cursor: &types.Cursor{Key: "pump", ID: "device-1"},
+	}})
+	is.Equal(c.Offset, nil) // a cursor replaces the offset
+	is.True(strings.Contains(DeviceWhere(c), "(COALESCE(d.name, ''), d.device_id) > (@cursor_key::text, @cursor_id)"))
+
+	args := NamedArgs(c)
+	is.Equal(args["cursor_key"], "pump")
+	is.Equal(args["cursor_id"], "device-1")
+}
+
+func TestNextCursor(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(*nextCursor("pump", "device-2", 5, 2), types.Cursor{Key: "pump", ID: "device-2"})
+	is.Equal(nextCursor("pump", "device-2", 2, 2), nil) // the last page
+}
+
+func TestFilterWhere(t *testing.T) {
+	is := is.New(t)
+
+	expr, err := dmquery.ParseFilter(`(environment == "water" || tag in ["pilot"]) && batteryLevel < 20 && metadata.zone != "north" && !online == true`)
+	is.NoErr(err)
+
+	where, args := filterWhere(expr)
+	is.Equal(where, "((((d.environment = @filter_0 OR EXISTS (SELECT 1 FROM device_device_tags ddt WHERE ddt.device_id = d.device_id AND ddt.name = ANY(@filter_1))) AND s.battery_level < @filter_2) AND NOT EXISTS (SELECT 1 FROM device_metadata dm WHERE dm.device_id = d.device_id AND dm.key = @filter_3 AND dm.vs = @filter_4)) AND NOT COALESCE(COALESCE(dst.online, FALSE) = @filter_5, FALSE))")
+	is.Equal(args, pgx.NamedArgs{"filter_0": "water", "filter_1": []string{"pilot"}, "filter_2": 20.0, "filter_3": "zone", "filter_4": "north", "filter_5": true})
+
+	expr, err = dmquery.ParseFilter(`name != "pump" || metadata.depth >= 2.5`)
+	is.NoErr(err)
+
+	c := deviceConditionFromQuery(dmquery.DeviceFilters{Filters: dmquery.Filters{Filter: expr, AllowedTenants: []string{"default"}}})
+	is.True(strings.Contains(DeviceWhere(c), "(d.name IS DISTINCT FROM @filter_0 OR EXISTS (SELECT 1 FROM device_metadata dm WHERE dm.device_id = d.device_id AND dm.key = @filter_1 AND dm.v >= @filter_2))"))
+
+	args = NamedArgs(c)
+	is.Equal(args["filter_0"], "pump")
+	is.Equal(args["filter_2"], 2.5)
+	is.Equal(args["tenants"], []string{"default"})
+}
+
+func TestFilterFieldsHaveColumns(t *testing.T) {
+	is := is.New(t)
+
+	for field, kind := range dmquery.FilterFields {
+		switch kind {
+		case dmquery.FieldSet:
+			_, ok := filterSets[field]
+			is.True(ok) // set field without a subquery
+		case dmquery.FieldMetadata:
+		default:
+			_, ok := filterColumns[field]
+			is.True(ok) // field without a column
+		}
+	}
+}
+
+func TestViewWhere(t *testing.T) {
+	is := is.New(t)
+
+	where, args := viewWhere(viewquery.Views{ID: "pumps", User: "alice", AllowedTenants: []string{"default"}})
+	is.Equal(where, "WHERE v.tenant = ANY(@tenants) AND (v.shared OR v.owner = @owner) AND v.view_id = @view_id")
+	is.Equal(args["owner"], "alice")
+
+	where, _ = viewWhere(viewquery.Views{AllowedTenants: []string{"default"}})
+	is.Equal(where, "WHERE v.tenant = ANY(@tenants) AND v.shared") // only shared views without a user
+}
+
+func TestDeviceStatsQuery(t *testing.T) {
+	is := is.New(t)
+
+	online := false
+	condition := deviceConditionFromQuery(dmquery.DeviceFilters{Filters: dmquery.Filters{Online: &online, AllowedTenants: []string{"default"}}})
+
+	query := deviceStatsQuery(condition, []string{dmquery.FacetProfile, dmquery.FacetAlarm, "unknown"})
+	is.True(strings.Contains(query, "SELECT d.device_id, COALESCE(sp.name, '') AS facet_profile\n"))
+	is.True(strings.Contains(query, "(dst.online = @online OR dst.online IS NULL)"))
+	is.True(strings.Contains(query, "SELECT 'total', NULL, count(*) FROM matched"))
+	is.True(strings.Contains(query, "SELECT 'profile', facet_profile, count(*) FROM matched GROUP BY 2"))
+	is.True(strings.Contains(query, "SELECT 'alarm', a.type, count(DISTINCT m.device_id) FROM matched m JOIN device_alarms a ON a.device_id = m.device_id GROUP BY 2"))
+	is.True(!strings.Contains(query, "unknown")) // facets without a column are left out
+
+	for _, facet := range dmquery.Facets {
+		_, ok := facetColumns[facet]
+		is.True(ok || facet == dmquery.FacetAlarm) // facet without a column
+	}
+}
+
+func TestDeviceSelect(t *testing.T) {
+	is := is.New(t)
+
+	with, columns, joins := deviceSelect(dmquery.DeviceFilters{})
+	is.True(strings.Contains(with, "tag_list AS"))
+	is.True(strings.Contains(with, "alarms_list AS"))
+	is.True(strings.Contains(columns, "tl.tags"))
+	is.True(!strings.Contains(columns, "NULL"))
+	is.Equal(strings.Count(joins, "LEFT JOIN"), 4)
+
+	with, columns, joins = deviceSelect(dmquery.DeviceFilters{Fields: []string{"deviceID", "location", "tags"}})
+	is.True(strings.HasPrefix(with, "WITH tag_list AS"))
+	is.True(!strings.Contains(with, "metadata_list"))
+	is.Equal(joins, "LEFT JOIN tag_list tl ON tl.device_id = d.device_id")
+	is.True(strings.Contains(columns, "d.location"))
+	is.True(strings.Contains(columns, "NULL::timestamptz AS state_observed_at")) // the default order refers to it
+	is.Equal(len(strings.Split(columns, ",\n")), len(deviceColumns))
+}
+
+func TestPrefixQuery(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(prefixQuery("Pump station"), "pump:* & station:*")
+	is.Equal(prefixQuery("a81758fffe06bfa3"), "a81758fffe06bfa3:*")
+	is.Equal(prefixQuery(" 'nödbrunn' & (x|y) "), "nödbrunn:* & x:* & y:*") // operators are not passed on
+	is.Equal(prefixQuery("%-%"), "")
+}
+
+func TestSearchMatch(t *testing.T) {
+	is := is.New(t)
+
+	args := pgx.NamedArgs{}
+	searchArgs(args, " pump ")
+	is.Equal(args["search"], "%pump%")
+	is.Equal(args["search_text"], "pump")
+	is.Equal(args["search_query"], "pump:*")
+
+	match := searchMatch("pump", "doc", true)
+	is.Equal(match, "(doc ILIKE @search OR to_tsvector('simple', doc) @@ to_tsquery('simple', @search_query) OR @search_text <% doc)")
+
+	match = searchMatch("%", "doc", true)
+	is.Equal(match, "(doc ILIKE @search OR @search_text <% doc)") // no words to query
+	is.Equal(searchRank("%", "doc", true), "word_similarity(@search_text, doc)")
+
+	// without pg_trgm there is no similarity to match or rank by
+	match = searchMatch("pump", "doc", false)
+	is.Equal(match, "(doc ILIKE @search OR to_tsvector('simple', doc) @@ to_tsquery('simple', @search_query))")
+	is.Equal(searchRank("pump", "doc", false), "ts_rank(to_tsvector('simple', doc), to_tsquery('simple', @search_query))")
+	is.Equal(searchRank("%", "doc", false), "0::real")
+
+	is.Equal(highlightSnippet("<b>«pump»</b> station"), "&lt;b&gt;<mark>pump</mark>&lt;/b&gt; station")
+}
+
+func TestSearchSQL(t *testing.T) {
+	is := is.New(t)
+
+	limit := 1000
+	sql, args := searchSQL(searchquery.Search{Text: "pump", Limit: &limit, AllowedTenants: []string{"default"}}, true)
+
+	is.Equal(args["limit"], 100) // limited to a page of 100
+	is.Equal(args["tenants"], []string{"default"})
+	is.Equal(strings.Count(sql, "UNION ALL"), 2)
+	is.Equal(strings.Count(sql, "d.tenant = ANY(@tenants)"), 2)
+	is.True(strings.Contains(sql, "(s.tenant IS NULL OR s.tenant = ANY(@tenants))"))
+	is.True(strings.Contains(sql, "ts_headline('simple', d.search_document"))
+	is.True(!strings.Contains(sql, "LATERAL")) // the documents are stored, not computed per row
+	is.True(strings.Contains(sql, "ORDER BY rank DESC"))
+}
+
+func TestQuarantineWhere(t *testing.T) {
+	is := is.New(t)
+
+	where, args := quarantineWhere(quarantinequery.Messages{Reason: "unknown device", AllowedTenants: []string{"default"}})
+	is.Equal(where, "WHERE tenant = ANY(@tenants) AND reason = @reason") // messages without a tenant are hidden
+	is.Equal(args["reason"], "unknown device")
+
+	where, _ = quarantineWhere(quarantinequery.Messages{AllowedTenants: []string{"default"}, IncludeUntenanted: true})
+	is.Equal(where, "WHERE (tenant = '' OR tenant = ANY(@tenants))")
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+
+	sensorquery "github.com/diwise/iot-device-mgmt/internal/application/sensors/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// keyset orders rows by a sort key and then by a unique id, so that a cursor can continue after
+// the last row of a page even when rows are added or removed between requests. The key must
+// never be NULL and is read back from the cursor as cast.
+type keyset struct {
+	key  string
+	cast string
+	id   string
+	desc bool
+}
+
+func (k keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("ORDER BY %s %s, %s %s", k.key, direction, k.id, direction)
+}
+
+// after matches the rows after the cursor, there is no condition for the first page.
+func (k keyset) after(c types.Cursor) string {
+	if c.ID == "" {
+		return ""
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s, %s) %s (@cursor_key::%s, @cursor_id)", k.key, k.id, op, k.cast)
+}
+
+// column selects the key as text for the cursor of the next page.
+func (k keyset) column() string {
+	return fmt.Sprintf("(%s)::text AS cursor_key", k.key)
+}
+
+func cursorArgs(args pgx.NamedArgs, c *types.Cursor) {
+	if c != nil && c.ID != "" {
+		args["cursor_key"] = c.Key
+		args["cursor_id"] = c.ID
+	}
+}
+
+// nextCursor continues after the last row of a page as long as count, the number of rows from the
+// current cursor on, is more than the rows on the page.
+func nextCursor(key, id string, count uint64, rows int) *types.Cursor {
+	if rows == 0 || count <= uint64(rows) {
+		return nil
+	}
+	return &types.Cursor{Key: key, ID: id}
+}
+
+// deviceKeyset is the cursor order for devices sorted with WithSortBy, or by device id.
+func deviceKeyset(c *Condition) keyset {
+	k := keyset{key: "d.device_id", cast: "text", id: "d.device_id", desc: c.SortOrder == "DESC"}
+
+	switch c.SortBy {
+	case "d.sensor_id", "d.name", "sp.sensor_profile_id":
+		k.key = fmt.Sprintf("COALESCE(%s, '')", c.SortBy)
+	case "bf.depletes_at":
+		k.key = "COALESCE(bf.depletes_at, 'infinity'::timestamptz)"
+		k.cast = "timestamptz"
+	case distance("d.location"):
+		k.key = fmt.Sprintf("COALESCE(%s, 'Infinity'::float8)", c.SortBy)
+		k.cast = "float8"
+	}
+
+	return k
+}
+
+// sensorKeyset is the cursor order for sensors, by distance when near a location or by sensor id.
+func sensorKeyset(query sensorquery.Sensors) keyset {
+	if query.Near != nil {
+		return keyset{key: fmt.Sprintf("COALESCE(%s, 'Infinity'::float8)", distance("s.location")), cast: "float8", id: "s.sensor_id"}
+	}
+	return keyset{key: "s.sensor_id", cast: "text", id: "s.sensor_id"}
+}
+
+// alarmKeyset is the cursor order for alarms grouped by device, latest first.
+var alarmKeyset = keyset{key: "a.observed_at", cast: "timestamptz", id: "a.device_id", desc: true}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func (s *Storage) CreateSensorProfile(ctx context.Context, p types.SensorProfile) error {
@@ -37,12 +39,14 @@ func (s *Storage) CreateSensorProfile(ctx context.Context, p types.SensorProfile
 		"name":              strings.TrimSpace(p.Name),
 		"decoder":           strings.TrimSpace(p.Decoder),
 		"interval":          p.Interval,
+		"calendar":          p.Calendar,
 	}
 
 	_, err = tx.Exec(ctx, `
-		INSERT INTO sensor_profiles (sensor_profile_id, name, decoder, interval)
-		VALUES (@sensor_profile_id, @name, @decoder, @interval)
-		ON CONFLICT DO NOTHING`, args)
+		INSERT INTO sensor_profiles (sensor_profile_id, name, decoder, interval, calendar)
+		VALUES (@sensor_profile_id, @name, @decoder, @interval, @calendar)
+		ON CONFLICT (sensor_profile_id) DO UPDATE
+			SET calendar = EXCLUDED.calendar`, args)
 	if err != nil {
 		log.Error("could not insert sensor profile", "args", args, "err", err.Error())
 		return err
@@ -221,6 +225,7 @@ func (s *Storage) CreateOrUpdateDevice(ctx context.Context, d types.Device) erro
 		"lat":         d.Location.Latitude,
 		"lon":         d.Location.Longitude,
 		"interval":    d.Interval,
+		"calendar":    d.Calendar,
 	}
 	if sensorID == "" {
 		args["sensor_id"] = nil
@@ -229,8 +234,8 @@ func (s *Storage) CreateOrUpdateDevice(ctx context.Context, d types.Device) erro
 	}
 
 	_, err = tx.Exec(ctx, `
-		INSERT INTO devices (device_id,sensor_id,active,name,description,environment,source,tenant,location,interval)
-		VALUES (@device_id,@sensor_id,@active,@name,@description,@environment,@source,@tenant,point(@lon,@lat),@interval)
+		INSERT INTO devices (device_id,sensor_id,active,name,description,environment,source,tenant,location,interval,calendar)
+		VALUES (@device_id,@sensor_id,@active,@name,@description,@environment,@source,@tenant,point(@lon,@lat),@interval,@calendar)
 		ON CONFLICT (device_id) DO UPDATE
 			SET
 				sensor_id = EXCLUDED.sensor_id,
@@ -242,6 +247,7 @@ func (s *Storage) CreateOrUpdateDevice(ctx context.Context, d types.Device) erro
 				tenant = EXCLUDED.tenant,
 				location = EXCLUDED.location,
 				interval = EXCLUDED.interval,
+				calendar = EXCLUDED.calendar,
 				modified_on = NOW()
 			WHERE devices.deleted = FALSE
 		`, args)
@@ -518,15 +524,283 @@ func (s *Storage) AddDeviceStatus(ctx context.Context, status types.StatusMessag
 		return err
 	}
 
-	_, err = tx.Exec(ctx, `DELETE FROM sensor_status WHERE sensor_id=@sensor_id AND observed_at < NOW() - INTERVAL '3 weeks'`, args)
+	_, err = tx.Exec(ctx, `
+		UPDATE sensors
+		SET observed_at = @observed_at,
+			battery_level = @battery_level,
+			rssi = @rssi,
+			snr = @snr,
+			fq = @fq,
+			sf = @sf,
+			dr = @dr
+		WHERE sensor_id = @sensor_id
+			AND (observed_at IS NULL OR observed_at < @observed_at);`, args)
+	if err != nil {
+		log.Error("could not update latest sensor status", "args", args, "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AddDeviceStatuses writes a batch of status messages in a single transaction. Messages
+// that can not be resolved to a sensor are skipped.
+func (s *Storage) AddDeviceStatuses(ctx context.Context, statuses []types.StatusMessage) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	lookupIDs := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		lookupIDs = append(lookupIDs, status.DeviceID)
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT device_id, sensor_id
+		FROM devices
+		WHERE deleted=FALSE
+			AND sensor_id IS NOT NULL
+			AND (device_id=ANY(@lookup_ids) OR sensor_id=ANY(@lookup_ids))
+		ORDER BY device_id DESC`, pgx.NamedArgs{"lookup_ids": lookupIDs})
+	if err != nil {
+		log.Error("could not find sensors for device statuses", "err", err.Error())
+		return err
+	}
+
+	// rows are sorted so that the first device, by id, wins as for single status messages
+	sensorIDs := map[string]string{}
+	for rows.Next() {
+		var deviceID, sensorID string
+		err = rows.Scan(&deviceID, &sensorID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		sensorIDs[sensorID] = sensorID
+		sensorIDs[deviceID] = sensorID
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	var observedAt []time.Time
+	var sensors []string
+	var battery, rssi, snr, sf []*float64
+	var fq, dr []*int64
+
+	for _, status := range statuses {
+		sensorID, ok := sensorIDs[status.DeviceID]
+		if !ok {
+			log.Debug("skipping status for unknown sensor", "lookup_id", status.DeviceID)
+			continue
+		}
+
+		var dataRate *int64
+		if status.DR != nil {
+			v := int64(*status.DR)
+			dataRate = &v
+		}
+
+		observedAt = append(observedAt, status.Timestamp.UTC())
+		sensors = append(sensors, sensorID)
+		battery = append(battery, status.BatteryLevel)
+		rssi = append(rssi, status.RSSI)
+		snr = append(snr, status.LoRaSNR)
+		fq = append(fq, status.Frequency)
+		sf = append(sf, status.SpreadingFactor)
+		dr = append(dr, dataRate)
+	}
+
+	if len(sensors) == 0 {
+		return nil
+	}
+
+	args := pgx.NamedArgs{
+		"observed_at":   observedAt,
+		"sensor_id":     sensors,
+		"battery_level": battery,
+		"rssi":          rssi,
+		"snr":           snr,
+		"fq":            fq,
+		"sf":            sf,
+		"dr":            dr,
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensor_status (observed_at, sensor_id, battery_level, rssi, snr, fq, sf, dr)
+		SELECT *
+		FROM unnest(@observed_at::timestamptz[], @sensor_id::text[], @battery_level::float8[], @rssi::float8[], @snr::float8[], @fq::int8[], @sf::float8[], @dr::int8[])
+		ON CONFLICT DO NOTHING;`, args)
+	if err != nil {
+		log.Error("could not insert device statuses", "count", len(sensors), "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE sensors
+		SET observed_at = latest.observed_at,
+			battery_level = latest.battery_level,
+			rssi = latest.rssi,
+			snr = latest.snr,
+			fq = latest.fq,
+			sf = latest.sf,
+			dr = latest.dr
+		FROM (
+			SELECT DISTINCT ON (sensor_id) *
+			FROM unnest(@observed_at::timestamptz[], @sensor_id::text[], @battery_level::float8[], @rssi::float8[], @snr::float8[], @fq::int8[], @sf::float8[], @dr::int8[])
+				AS s(observed_at, sensor_id, battery_level, rssi, snr, fq, sf, dr)
+			ORDER BY sensor_id, observed_at DESC
+		) AS latest
+		WHERE sensors.sensor_id = latest.sensor_id
+			AND (sensors.observed_at IS NULL OR sensors.observed_at < latest.observed_at);`, args)
+	if err != nil {
+		log.Error("could not update latest sensor statuses", "count", len(sensors), "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DiscoverSensor creates, or updates, a sensor with the unknown profile for a status
+// message that could not be resolved to a device.
+func (s *Storage) DiscoverSensor(ctx context.Context, status types.StatusMessage) error {
+	if strings.TrimSpace(status.DeviceID) == "" {
+		return ErrNoID
+	}
+
+	args := pgx.NamedArgs{
+		"sensor_id":     strings.TrimSpace(status.DeviceID),
+		"tenant":        status.Tenant,
+		"observed_at":   status.Timestamp.UTC(),
+		"battery_level": status.BatteryLevel,
+		"rssi":          status.RSSI,
+		"snr":           status.LoRaSNR,
+		"fq":            status.Frequency,
+		"sf":            status.SpreadingFactor,
+		"dr":            status.DR,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensors (sensor_id, sensor_profile, discovered, tenant, first_seen, observed_at, battery_level, rssi, snr, fq, sf, dr)
+		VALUES (@sensor_id, 'unknown', TRUE, @tenant, @observed_at, @observed_at, @battery_level, @rssi, @snr, @fq, @sf, @dr)
+		ON CONFLICT (sensor_id) DO UPDATE
+			SET
+				observed_at = EXCLUDED.observed_at,
+				battery_level = EXCLUDED.battery_level,
+				rssi = EXCLUDED.rssi,
+				snr = EXCLUDED.snr,
+				fq = EXCLUDED.fq,
+				sf = EXCLUDED.sf,
+				dr = EXCLUDED.dr,
+				modified_on = NOW()
+			WHERE sensors.observed_at IS NULL OR sensors.observed_at < EXCLUDED.observed_at;`, args)
+	if err != nil {
+		log.Error("could not discover sensor", "args", args, "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensor_status (observed_at, sensor_id, battery_level, rssi, snr, fq, sf, dr)
+		VALUES (@observed_at, @sensor_id, @battery_level, @rssi, @snr, @fq, @sf, @dr)
+		ON CONFLICT DO NOTHING;`, args)
 	if err != nil {
-		log.Error("could not delete old device statuses", "args", args, "err", err.Error())
+		log.Error("could not insert status for discovered sensor", "args", args, "err", err.Error())
 		return err
 	}
 
 	return tx.Commit(ctx)
 }
 
+const (
+	rawStatus    = "raw"
+	hourlyStatus = "hourly"
+	dailyStatus  = "daily"
+)
+
+// statusSources selects status rows, or the averages of rolled up rows, with the columns of sensor_status.
+var statusSources = map[string]string{
+	rawStatus:    `SELECT sensor_id, observed_at, battery_level, rssi, snr, fq, sf, dr FROM sensor_status`,
+	hourlyStatus: `SELECT sensor_id, bucket AS observed_at, battery_avg AS battery_level, rssi_avg AS rssi, snr_avg AS snr, NULL::numeric AS fq, NULL::numeric AS sf, NULL::numeric AS dr FROM sensor_status_hourly`,
+	dailyStatus:  `SELECT sensor_id, bucket AS observed_at, battery_avg AS battery_level, rssi_avg AS rssi, snr_avg AS snr, NULL::numeric AS fq, NULL::numeric AS sf, NULL::numeric AS dr FROM sensor_status_daily`,
+}
+
+// deviceStatusSource looks up how far back the status data of a device goes and
+// returns the source to use for a range starting at from.
+func deviceStatusSource(ctx context.Context, c *pgxpool.Conn, from *time.Time, args pgx.NamedArgs) (string, error) {
+	if from == nil {
+		return rawStatus, nil
+	}
+
+	var earliestRaw, earliestHourly, earliestDaily *time.Time
+	err := c.QueryRow(ctx, `
+		SELECT
+			(SELECT min(observed_at) FROM sensor_status WHERE sensor_id = d.sensor_id),
+			(SELECT min(bucket) FROM sensor_status_hourly WHERE sensor_id = d.sensor_id),
+			(SELECT min(bucket) FROM sensor_status_daily WHERE sensor_id = d.sensor_id)
+		FROM devices d
+		WHERE d.device_id=@device_id
+		  AND d.tenant=ANY(@tenants)`, args).Scan(&earliestRaw, &earliestHourly, &earliestDaily)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		logging.GetFromContext(ctx).Error("could not query earliest device status", "err", err.Error())
+		return "", err
+	}
+
+	return statusSource(*from, earliestRaw, earliestHourly, earliestDaily), nil
+}
+
+// statusSource returns the most detailed status data that still covers a range starting
+// at from. Raw rows are removed before rollups, so if none of them go back far enough the
+// coarsest available data is used.
+func statusSource(from time.Time, earliestRaw, earliestHourly, earliestDaily *time.Time) string {
+	covers := func(earliest *time.Time) bool {
+		return earliest != nil && !from.Before(*earliest)
+	}
+
+	switch {
+	case covers(earliestRaw):
+		return rawStatus
+	case covers(earliestHourly):
+		return hourlyStatus
+	case earliestDaily != nil:
+		return dailyStatus
+	case earliestHourly != nil:
+		return hourlyStatus
+	default:
+		return rawStatus
+	}
+}
+
 func (s *Storage) GetDeviceStatus(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatus], error) {
 	log := logging.GetFromContext(ctx)
 
@@ -534,22 +808,10 @@ func (s *Storage) GetDeviceStatus(ctx context.Context, deviceID string, query dm
 
 	offsetLimitSql, offset, limit := OffsetLimit(condition, 0, 100)
 
-	sql := fmt.Sprintf(`
-		SELECT observed_at, battery_level, rssi, snr, fq, sf, dr, total_count
-		FROM (
-			SELECT ss.observed_at, ss.battery_level, ss.rssi, ss.snr, ss.fq, ss.sf, ss.dr, count(*) OVER () AS total_count
-			FROM devices d
-			JOIN sensor_status ss ON d.sensor_id = ss.sensor_id
-			WHERE d.device_id=@device_id
-			  AND d.tenant=ANY(@tenants)
-			ORDER BY ss.observed_at DESC
-			%s
-		) AS statuses
-		ORDER BY observed_at ASC;
-		`, offsetLimitSql)
-
 	args := NamedArgs(condition)
 	args["device_id"] = deviceID
+	args["from"] = query.From
+	args["to"] = query.To
 
 	now := time.Now()
 
@@ -560,6 +822,27 @@ func (s *Storage) GetDeviceStatus(ctx context.Context, deviceID string, query dm
 	}
 	defer c.Release()
 
+	source, err := deviceStatusSource(ctx, c, query.From, args)
+	if err != nil {
+		return types.Collection[types.SensorStatus]{}, err
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT observed_at, battery_level, rssi, snr, fq, sf, dr, total_count
+		FROM (
+			SELECT ss.observed_at, ss.battery_level, ss.rssi, ss.snr, ss.fq, ss.sf, ss.dr, count(*) OVER () AS total_count
+			FROM devices d
+			JOIN (%s) ss ON d.sensor_id = ss.sensor_id
+			WHERE d.device_id=@device_id
+			  AND d.tenant=ANY(@tenants)
+			  AND (@from::timestamptz IS NULL OR ss.observed_at >= @from::timestamptz)
+			  AND (@to::timestamptz IS NULL OR ss.observed_at < @to::timestamptz)
+			ORDER BY ss.observed_at DESC
+			%s
+		) AS statuses
+		ORDER BY observed_at ASC;
+		`, statusSources[source], offsetLimitSql)
+
 	rows, err := c.Query(ctx, sql, args)
 	if err != nil {
 		log.Debug("failed to query device statuses", "args", args, "err", err.Error())
@@ -619,6 +902,118 @@ func (s *Storage) GetDeviceStatus(ctx context.Context, deviceID string, query dm
 	}, nil
 }
 
+// statusBuckets truncates the time of a raw status row to the start of an hour or a day.
+var statusBuckets = map[string]string{
+	types.BucketHour: `date_trunc('hour', ss.observed_at)`,
+	types.BucketDay:  `date_trunc('day', ss.observed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`,
+}
+
+// statusRollups holds the rollup table used for each bucket size.
+var statusRollups = map[string]string{
+	types.BucketHour: "sensor_status_hourly",
+	types.BucketDay:  "sensor_status_daily",
+}
+
+// GetDeviceStatusAggregates returns the min, max and average status values of a device per
+// hour or day. Raw rows are aggregated if they cover the range, otherwise the rollups are used.
+func (s *Storage) GetDeviceStatusAggregates(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error) {
+	log := logging.GetFromContext(ctx)
+
+	bucket, ok := statusBuckets[query.Bucket]
+	if !ok {
+		return types.Collection[types.SensorStatusAggregate]{}, fmt.Errorf("unsupported bucket %q", query.Bucket)
+	}
+
+	condition := statusConditionFromQuery(deviceID, query)
+
+	offsetLimitSql, offset, limit := OffsetLimit(condition, 0, 100)
+
+	args := NamedArgs(condition)
+	args["device_id"] = deviceID
+	args["from"] = query.From
+	args["to"] = query.To
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.SensorStatusAggregate]{}, err
+	}
+	defer c.Release()
+
+	source, err := deviceStatusSource(ctx, c, query.From, args)
+	if err != nil {
+		return types.Collection[types.SensorStatusAggregate]{}, err
+	}
+
+	aggregates := fmt.Sprintf(`
+		SELECT %s AS bucket, %s
+		FROM devices d
+		JOIN sensor_status ss ON d.sensor_id = ss.sensor_id
+		WHERE d.device_id=@device_id
+		  AND d.tenant=ANY(@tenants)
+		  AND (@from::timestamptz IS NULL OR ss.observed_at >= @from::timestamptz)
+		  AND (@to::timestamptz IS NULL OR ss.observed_at < @to::timestamptz)
+		GROUP BY 1`, bucket, rollupAggregates)
+
+	if source != rawStatus {
+		aggregates = fmt.Sprintf(`
+			SELECT r.bucket, %s
+			FROM devices d
+			JOIN %s r ON d.sensor_id = r.sensor_id
+			WHERE d.device_id=@device_id
+			  AND d.tenant=ANY(@tenants)
+			  AND (@from::timestamptz IS NULL OR r.bucket >= @from::timestamptz)
+			  AND (@to::timestamptz IS NULL OR r.bucket < @to::timestamptz)`, rollupColumns, statusRollups[query.Bucket])
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT a.*, count(*) OVER () AS total_count
+		FROM (%s) AS a
+		ORDER BY bucket ASC
+		%s;`, aggregates, offsetLimitSql)
+
+	rows, err := c.Query(ctx, sql, args)
+	if err != nil {
+		log.Debug("failed to query device status aggregates", "args", args, "err", err.Error())
+		return types.Collection[types.SensorStatusAggregate]{}, err
+	}
+	defer rows.Close()
+
+	result := []types.SensorStatusAggregate{}
+
+	var count int64
+
+	for rows.Next() {
+		var a types.SensorStatusAggregate
+		err := rows.Scan(&a.Bucket, &a.Samples,
+			&a.BatteryLevel.Min, &a.BatteryLevel.Max, &a.BatteryLevel.Avg,
+			&a.RSSI.Min, &a.RSSI.Max, &a.RSSI.Avg,
+			&a.LoRaSNR.Min, &a.LoRaSNR.Max, &a.LoRaSNR.Avg,
+			&a.SpreadingFactor.Min, &a.SpreadingFactor.Max, &a.SpreadingFactor.Avg,
+			&a.DR.Min, &a.DR.Max, &a.DR.Avg,
+			&count)
+		if err != nil {
+			log.Error("could not scan device status aggregate row", "args", args, "err", err.Error())
+			return types.Collection[types.SensorStatusAggregate]{}, err
+		}
+
+		a.Bucket = a.Bucket.UTC()
+		result = append(result, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.SensorStatusAggregate]{}, err
+	}
+
+	return types.Collection[types.SensorStatusAggregate]{
+		Data:       result,
+		Count:      uint64(len(result)),
+		TotalCount: uint64(count),
+		Offset:     uint64(offset),
+		Limit:      uint64(limit),
+	}, nil
+}
+
 func (s *Storage) SetDeviceState(ctx context.Context, deviceID string, state types.DeviceState) error {
 	args := pgx.NamedArgs{
 		"device_id":   deviceID,
@@ -626,6 +1021,11 @@ func (s *Storage) SetDeviceState(ctx context.Context, deviceID string, state typ
 		"online":      state.Online,
 		"state":       state.State,
 	}
+	if state.DegradedUntil != nil {
+		args["degraded_until"] = state.DegradedUntil.UTC()
+	} else {
+		args["degraded_until"] = nil
+	}
 
 	log := logging.GetFromContext(ctx)
 
@@ -642,15 +1042,31 @@ func (s *Storage) SetDeviceState(ctx context.Context, deviceID string, state typ
 	}
 	defer tx.Rollback(ctx)
 
+	// changes between online and offline are kept in the state history
+	_, err = tx.Exec(ctx, `
+		INSERT INTO device_state_history (device_id, online, observed_at)
+		SELECT @device_id, @online, @observed_at
+		WHERE NOT EXISTS (
+			SELECT 1 FROM device_state
+			WHERE device_id=@device_id AND (online=@online OR observed_at > @observed_at))
+		ON CONFLICT DO NOTHING;
+		`, args)
+	if err != nil {
+		log.Error("could not insert device state history", "err", err.Error())
+		return err
+	}
+
 	_, err = tx.Exec(ctx, `
-		INSERT INTO device_state (device_id, observed_at, online, state)
-		VALUES (@device_id, @observed_at, @online, @state)
+		INSERT INTO device_state (device_id, observed_at, online, state, degraded_until)
+		VALUES (@device_id, @observed_at, @online, @state, @degraded_until)
 		ON CONFLICT (device_id) DO UPDATE
 			SET
 				observed_at = EXCLUDED.observed_at,
 				online = EXCLUDED.online,
 				state = EXCLUDED.state,
-				modified_on = NOW();
+				degraded_until = EXCLUDED.degraded_until,
+				modified_on = NOW()
+			WHERE device_state.observed_at IS NULL OR device_state.observed_at <= EXCLUDED.observed_at;
 		`, args)
 	if err != nil {
 		log.Error("could not insert or update device state", "args", args, "err", err.Error())
@@ -660,40 +1076,246 @@ func (s *Storage) SetDeviceState(ctx context.Context, deviceID string, state typ
 	return tx.Commit(ctx)
 }
 
-func (s *Storage) GetDeviceBySensorID(ctx context.Context, sensorID string) (types.Device, bool, error) {
-	if sensorID == "" {
-		return types.Device{}, false, ErrNoID
+// SetDeviceStates sets the state of several devices in one statement. As for SetDeviceState
+// a stored state is only replaced by a state that is at least as recent.
+func (s *Storage) SetDeviceStates(ctx context.Context, states map[string]types.DeviceState) error {
+	if len(states) == 0 {
+		return nil
+	}
+
+	var deviceIDs []string
+	var observedAt []time.Time
+	var online []bool
+	var state []int64
+	var degradedUntil []*time.Time
+
+	for deviceID, st := range states {
+		deviceIDs = append(deviceIDs, deviceID)
+		observedAt = append(observedAt, st.ObservedAt.UTC())
+		online = append(online, st.Online)
+		state = append(state, int64(st.State))
+
+		var until *time.Time
+		if st.DegradedUntil != nil {
+			u := st.DegradedUntil.UTC()
+			until = &u
+		}
+		degradedUntil = append(degradedUntil, until)
 	}
 
 	args := pgx.NamedArgs{
-		"sensor_id": sensorID,
+		"device_id":      deviceIDs,
+		"observed_at":    observedAt,
+		"online":         online,
+		"state":          state,
+		"degraded_until": degradedUntil,
 	}
 
 	log := logging.GetFromContext(ctx)
 
-	var device_id, sensor_id, name, description, environment, source, tenant, device_profile string
-	var active bool
-	var location pgtype.Point
-	var typesList [][]string
-
 	c, err := s.conn.Acquire(ctx)
 	if err != nil {
 		log.Error("could not acquire connection", "err", err.Error())
-		return types.Device{}, false, err
+		return err
 	}
 	defer c.Release()
 
-	row := c.QueryRow(ctx, `
-		WITH types_list AS (
-			SELECT ddpt.device_id, array_agg(ARRAY[dpt.sensor_profile_type_id, dpt.name]) AS types
-			FROM device_sensor_profile_types ddpt
-			JOIN sensor_profile_types dpt USING (sensor_profile_type_id)
-			JOIN devices d USING (device_id)
-			WHERE d.deleted = FALSE
-			GROUP BY ddpt.device_id
-		)
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
 
-		SELECT d.device_id,d.sensor_id,d.active,d.name,d.description,d.environment,d.source,d.tenant,d.location,s.sensor_profile,types_list.types
+	_, err = tx.Exec(ctx, `
+		INSERT INTO device_state_history (device_id, online, observed_at)
+		SELECT n.device_id, n.online, n.observed_at
+		FROM unnest(@device_id::text[], @observed_at::timestamptz[], @online::bool[]) AS n(device_id, observed_at, online)
+		LEFT JOIN device_state ds ON ds.device_id = n.device_id
+		WHERE ds.device_id IS NULL OR (ds.online <> n.online AND (ds.observed_at IS NULL OR ds.observed_at <= n.observed_at))
+		ON CONFLICT DO NOTHING;
+		`, args)
+	if err != nil {
+		log.Error("could not insert device state history", "count", len(deviceIDs), "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO device_state (device_id, observed_at, online, state, degraded_until)
+		SELECT *
+		FROM unnest(@device_id::text[], @observed_at::timestamptz[], @online::bool[], @state::int8[], @degraded_until::timestamptz[])
+		ON CONFLICT (device_id) DO UPDATE
+			SET
+				observed_at = EXCLUDED.observed_at,
+				online = EXCLUDED.online,
+				state = EXCLUDED.state,
+				degraded_until = EXCLUDED.degraded_until,
+				modified_on = NOW()
+			WHERE device_state.observed_at IS NULL OR device_state.observed_at <= EXCLUDED.observed_at;
+		`, args)
+	if err != nil {
+		log.Error("could not insert or update device states", "count", len(deviceIDs), "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// deviceStateSelect selects the id, tenant, location, decoder and state of devices, which is
+// all that is needed to handle status messages from them.
+const deviceStateSelect = `
+		SELECT d.device_id, d.tenant, d.location, COALESCE(sp.decoder, ''), dst.online, dst.state, dst.observed_at, dst.degraded_until
+		FROM devices d
+		LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
+		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+		LEFT JOIN device_state dst ON dst.device_id = d.device_id
+		WHERE d.deleted = FALSE`
+
+// GetDeviceState returns the id, tenant, location, decoder and state of a device.
+func (s *Storage) GetDeviceState(ctx context.Context, deviceID string) (types.Device, bool, error) {
+	if deviceID == "" {
+		return types.Device{}, false, ErrNoID
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Device{}, false, err
+	}
+	defer c.Release()
+
+	row := c.QueryRow(ctx, deviceStateSelect+" AND d.device_id = @device_id", pgx.NamedArgs{"device_id": deviceID})
+
+	d, err := scanDeviceState(row)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return types.Device{}, false, nil
+		}
+
+		log.Error("could not query device state", "device_id", deviceID, "err", err.Error())
+		return types.Device{}, false, err
+	}
+
+	return d, true, nil
+}
+
+// GetDeviceStates returns the id, tenant, location, decoder and state of several devices in
+// one query, by device id. Devices that are not found are left out.
+func (s *Storage) GetDeviceStates(ctx context.Context, deviceIDs []string) (map[string]types.Device, error) {
+	result := map[string]types.Device{}
+
+	if len(deviceIDs) == 0 {
+		return result, nil
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, deviceStateSelect+" AND d.device_id = ANY(@device_ids)", pgx.NamedArgs{"device_ids": deviceIDs})
+	if err != nil {
+		log.Error("could not query device states", "count", len(deviceIDs), "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		d, err := scanDeviceState(rows)
+		if err != nil {
+			log.Error("could not scan device state", "err", err.Error())
+			return nil, err
+		}
+		result[d.DeviceID] = d
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func scanDeviceState(row pgx.Row) (types.Device, error) {
+	var device_id, tenant, decoder string
+	var location pgtype.Point
+	var online *bool
+	var state *int
+	var observedAt, degradedUntil *time.Time
+
+	err := row.Scan(&device_id, &tenant, &location, &decoder, &online, &state, &observedAt, &degradedUntil)
+	if err != nil {
+		return types.Device{}, err
+	}
+
+	d := types.Device{
+		DeviceID: device_id,
+		Tenant:   tenant,
+		Location: types.Location{
+			Latitude:  location.P.Y,
+			Longitude: location.P.X,
+		},
+		SensorProfile: types.SensorProfile{
+			Decoder: decoder,
+		},
+	}
+
+	if online != nil {
+		d.DeviceState.Online = *online
+	}
+	if state != nil {
+		d.DeviceState.State = *state
+	}
+	if observedAt != nil {
+		d.DeviceState.ObservedAt = observedAt.UTC()
+	}
+	if degradedUntil != nil {
+		until := degradedUntil.UTC()
+		d.DeviceState.DegradedUntil = &until
+	}
+
+	return d, nil
+}
+
+func (s *Storage) GetDeviceBySensorID(ctx context.Context, sensorID string) (types.Device, bool, error) {
+	if sensorID == "" {
+		return types.Device{}, false, ErrNoID
+	}
+
+	args := pgx.NamedArgs{
+		"sensor_id": sensorID,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	var device_id, sensor_id, name, description, environment, source, tenant, device_profile string
+	var active bool
+	var location pgtype.Point
+	var typesList [][]string
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Device{}, false, err
+	}
+	defer c.Release()
+
+	row := c.QueryRow(ctx, `
+		WITH types_list AS (
+			SELECT ddpt.device_id, array_agg(ARRAY[dpt.sensor_profile_type_id, dpt.name]) AS types
+			FROM device_sensor_profile_types ddpt
+			JOIN sensor_profile_types dpt USING (sensor_profile_type_id)
+			JOIN devices d USING (device_id)
+			WHERE d.deleted = FALSE
+			GROUP BY ddpt.device_id
+		)
+
+		SELECT d.device_id,d.sensor_id,d.active,d.name,d.description,d.environment,d.source,d.tenant,d.location,s.sensor_profile,types_list.types
 		FROM devices d
 		LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
 		LEFT JOIN types_list ON types_list.device_id = d.device_id
@@ -740,10 +1362,19 @@ func (s *Storage) GetDeviceBySensorID(ctx context.Context, sensorID string) (typ
 	return d, true, nil
 }
 
+// deviceFrom joins the tables that the columns and conditions of a device query refer to.
+const deviceFrom = `FROM devices d
+		LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
+		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+		LEFT JOIN device_state dst ON dst.device_id = d.device_id
+		LEFT JOIN sensor_battery_forecast bf ON bf.sensor_id = d.sensor_id
+		LEFT JOIN sensor_link_quality lq ON lq.sensor_id = d.sensor_id`
+
 func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
 	log := logging.GetFromContext(ctx)
 
 	condition := deviceConditionFromQuery(query)
+	condition.Trigram = s.trigram
 	offsetLimit, offset, limit := OffsetLimit(condition, 0, 10)
 
 	if condition.Export {
@@ -751,92 +1382,39 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 		offset = 0
 	}
 
-	sql := fmt.Sprintf(`
-		WITH latest_status AS (
-			SELECT DISTINCT ON (sensor_id)
-				sensor_id, battery_level, rssi, snr, fq, sf, dr, observed_at
-			FROM sensor_status
-			ORDER BY sensor_id, observed_at DESC
-		),
-
-		tag_list AS (
-			SELECT ddt.device_id, array_agg(dt.name) AS tags
-			FROM device_device_tags ddt
-			JOIN device_tags dt USING (name)
-			GROUP BY ddt.device_id
-		),
-
-		metadata_list AS (
-			SELECT dm.device_id, array_agg(ARRAY[dm.key, dm.vs]) AS meta
-			FROM device_metadata dm
-			GROUP BY dm.device_id
-		),
+	ks := deviceKeyset(condition)
+	orderBy := OrderByWithFallback(condition, "ORDER BY active DESC, state_observed_at DESC NULLS LAST, device_id ASC")
+	if condition.Search != "" && condition.SortBy == "" {
+		orderBy = "ORDER BY " + searchRank(condition.Search, deviceSearchDocument, condition.Trigram) + " DESC, d.device_id ASC"
+	}
+	if condition.Cursor != nil {
+		orderBy = ks.orderBy()
+	}
 
-		types_list AS (
-			SELECT ddpt.device_id, array_agg(ARRAY[dpt.sensor_profile_type_id, dpt.name]) AS types
-			FROM device_sensor_profile_types ddpt
-			JOIN sensor_profile_types dpt USING (sensor_profile_type_id)
-			JOIN devices d USING (device_id)
-			WHERE d.deleted = FALSE
-			GROUP BY ddpt.device_id
-		),
+	with, columns, joins := deviceSelect(query)
 
-		alarms_list AS (
-			SELECT a.device_id, array_agg(a.type) AS alarms
-			FROM device_alarms a
-			GROUP BY a.device_id
-		)
+	// count is the number of devices from the cursor on that decides if there is a
+	// next page, while the total is counted without the cursor predicate.
+	total := "count(*) OVER ()"
+	if condition.Cursor != nil {
+		all := *condition
+		all.Cursor = nil
+		total = fmt.Sprintf("(SELECT count(*) %s %s)", deviceFrom, DeviceWhere(&all))
+	}
 
+	sql := fmt.Sprintf(`
+		%s
 		SELECT
-			d.device_id,
-			d.sensor_id,
-			d.active,
-			d.location,
-			d.name           AS device_name,
-			d.description    AS device_description,
-			d.environment,
-			d.source,
-			d.tenant,
-
-			sp.sensor_profile_id,
-			sp.name          AS profile_name,
-			sp.decoder,
-			sp.description   AS profile_description,
-			sp.interval      AS profile_interval,
-			d.interval	     AS device_interval,
-
-			dst.online      AS state_online,
-			dst.state       AS state_value,
-			dst.observed_at AS state_observed_at,
-
-			ls.battery_level,
-			ls.rssi,
-			ls.snr,
-			ls.fq,
-			ls.sf,
-			ls.dr,
-			ls.observed_at  AS status_observed_at,
-
-			tl.tags,
-			ml.meta,
-			types_list.types,
-
-			alarms_list.alarms,
-
-			count(*) OVER () AS count
+			%s,
+			%s,
+			count(*) OVER () AS count,
+			%s AS total_count
 
-		FROM devices d
-		LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
-		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
-		LEFT JOIN device_state dst ON dst.device_id = d.device_id
-		LEFT JOIN latest_status ls ON ls.sensor_id = d.sensor_id
-		LEFT JOIN tag_list tl ON tl.device_id = d.device_id
-		LEFT JOIN types_list ON types_list.device_id = d.device_id
-		LEFT JOIN alarms_list ON alarms_list.device_id = d.device_id
-		LEFT JOIN metadata_list ml ON ml.device_id = d.device_id
 		%s
 		%s
-		%s;`, DeviceWhere(condition), OrderByWithFallback(condition, "ORDER BY active DESC, state_observed_at DESC NULLS LAST, device_id ASC"), offsetLimit)
+		%s
+		%s
+		%s;`, with, columns, ks.column(), total, deviceFrom, joins, DeviceWhere(condition), orderBy, offsetLimit)
 
 	args := NamedArgs(condition)
 
@@ -859,19 +1437,25 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 	log.Debug("query executed", slog.Duration("duration", time.Duration(time.Since(now).Milliseconds())))
 
 	var devices []types.Device
-	var count uint64
+	var count, totalCount uint64
+	var cursorKey string
 	for rows.Next() {
 		var location pgtype.Point
 		var profileName, profileDescription, deviceName, deviceDescription, environment, source, tenant *string
 		var deviceID, sensorID, profileID *string
 		var active, online *bool
 		var rssi, snr, sf, batteryLevel *float64
-		var statusObservedAt, stateObservedAt *time.Time
+		var statusObservedAt, stateObservedAt, degradedUntil *time.Time
 		var tagList, alarmsList []string
 		var typesList, metadataList [][]string
 		var decoder *string
 		var interval, deviceInterval, stateValue, dr *int
 		var fq *int64
+		var profileCalendar, deviceCalendar *types.ReportingCalendar
+		var forecastLevel, forecastRate, forecastThreshold *float64
+		var depletesAt, replacedAt, forecastComputedAt *time.Time
+		var forecastSamples *int
+		var linkQuality *types.LinkQuality
 
 		err = rows.Scan(
 			&deviceID,
@@ -889,9 +1473,12 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 			&profileDescription,
 			&interval,
 			&deviceInterval,
+			&profileCalendar,
+			&deviceCalendar,
 			&online,
 			&stateValue,
 			&stateObservedAt,
+			&degradedUntil,
 			&batteryLevel,
 			&rssi,
 			&snr,
@@ -903,7 +1490,17 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 			&metadataList,
 			&typesList,
 			&alarmsList,
+			&forecastLevel,
+			&forecastRate,
+			&forecastThreshold,
+			&depletesAt,
+			&replacedAt,
+			&forecastSamples,
+			&forecastComputedAt,
+			&linkQuality,
+			&cursorKey,
 			&count,
+			&totalCount,
 		)
 		if err != nil {
 			log.Error("could not scan row", "err", err.Error())
@@ -941,17 +1538,23 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 				Name:     *profileID,
 				Decoder:  *decoder,
 				Interval: *interval,
+				Calendar: profileCalendar,
 			}
 			if deviceInterval != nil && *deviceInterval > 0 {
 				device.SensorProfile.Interval = *deviceInterval
 			}
 		}
+		device.Calendar = deviceCalendar
 		if stateObservedAt != nil {
 			device.DeviceState = types.DeviceState{
 				Online:     *online,
 				State:      *stateValue,
 				ObservedAt: stateObservedAt.UTC(),
 			}
+			if degradedUntil != nil {
+				until := degradedUntil.UTC()
+				device.DeviceState.DegradedUntil = &until
+			}
 		}
 		if statusObservedAt != nil {
 			device.SensorStatus = types.SensorStatus{
@@ -998,6 +1601,20 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 		if len(alarmsList) > 0 {
 			device.Alarms = append(device.Alarms, alarmsList...)
 		}
+		if forecastComputedAt != nil {
+			forecast := utcBatteryForecast(types.BatteryForecast{
+				SensorID:   device.SensorID,
+				Level:      *forecastLevel,
+				Rate:       *forecastRate,
+				Threshold:  *forecastThreshold,
+				DepletesAt: depletesAt,
+				ReplacedAt: replacedAt,
+				Samples:    *forecastSamples,
+				ComputedAt: *forecastComputedAt,
+			})
+			device.BatteryForecast = &forecast
+		}
+		device.LinkQuality = linkQuality
 
 		devices = append(devices, device)
 	}
@@ -1006,20 +1623,321 @@ func (s *Storage) Query(ctx context.Context, query dmquery.DeviceFilters) (types
 		log.Error("error iterating rows", "err", err.Error())
 		return types.Collection[types.Device]{}, err
 	}
+	rows.Close()
+
+	err = includeDeviceDetails(ctx, c, devices, query.Include)
+	if err != nil {
+		return types.Collection[types.Device]{}, err
+	}
 
 	if condition.Export {
 		limit = len(devices)
 	}
 
+	var next *types.Cursor
+	if condition.Cursor != nil && len(devices) > 0 {
+		next = nextCursor(cursorKey, devices[len(devices)-1].DeviceID, count, len(devices))
+	}
+
 	return types.Collection[types.Device]{
 		Data:       devices,
 		Count:      uint64(len(devices)),
-		TotalCount: count,
+		TotalCount: totalCount,
 		Offset:     uint64(offset),
 		Limit:      uint64(limit),
+		Cursor:     next,
 	}, nil
 }
 
+// deviceColumn is a column of the device query that is selected, or replaced by a NULL of
+// its type with the same name, depending on whether the field it belongs to is part of the devices.
+type deviceColumn struct {
+	field  string
+	column string
+	null   string
+}
+
+// deviceColumns are the columns of the device query in the order they are scanned. The id,
+// active state and tenant of a device are always selected.
+var deviceColumns = []deviceColumn{
+	{"", "d.device_id", ""},
+	{"sensorID", "d.sensor_id", "NULL::text"},
+	{"", "d.active", ""},
+	{"location", "d.location", "NULL::point"},
+	{"name", "d.name AS device_name", "NULL::text"},
+	{"description", "d.description AS device_description", "NULL::text"},
+	{"environment", "d.environment", "NULL::text"},
+	{"source", "d.source", "NULL::text"},
+	{"", "d.tenant", ""},
+
+	{"sensorProfile", "sp.sensor_profile_id", "NULL::text"},
+	{"sensorProfile", "sp.name AS profile_name", "NULL::text"},
+	{"sensorProfile", "sp.decoder", "NULL::text"},
+	{"sensorProfile", "sp.description AS profile_description", "NULL::text"},
+	{"sensorProfile", "sp.interval AS profile_interval", "NULL::numeric"},
+	{"sensorProfile", "d.interval AS device_interval", "NULL::numeric"},
+	{"sensorProfile", "sp.calendar AS profile_calendar", "NULL::jsonb"},
+	{"calendar", "d.calendar AS device_calendar", "NULL::jsonb"},
+
+	{"deviceState", "dst.online AS state_online", "NULL::bool"},
+	{"deviceState", "dst.state AS state_value", "NULL::numeric"},
+	{"deviceState", "dst.observed_at AS state_observed_at", "NULL::timestamptz"},
+	{"deviceState", "dst.degraded_until", "NULL::timestamptz"},
+
+	{"sensorStatus", "s.battery_level", "NULL::numeric"},
+	{"sensorStatus", "s.rssi", "NULL::numeric"},
+	{"sensorStatus", "s.snr", "NULL::numeric"},
+	{"sensorStatus", "s.fq", "NULL::numeric"},
+	{"sensorStatus", "s.sf", "NULL::numeric"},
+	{"sensorStatus", "s.dr", "NULL::numeric"},
+	{"sensorStatus", "s.observed_at AS status_observed_at", "NULL::timestamptz"},
+
+	{"tags", "tl.tags", "NULL::text[]"},
+	{"metadata", "ml.meta", "NULL::text[]"},
+	{"types", "types_list.types", "NULL::text[]"},
+	{"alarms", "alarms_list.alarms", "NULL::text[]"},
+
+	{"batteryForecast", "bf.level::float8 AS battery_level_forecast", "NULL::float8"},
+	{"batteryForecast", "bf.rate::float8 AS battery_rate", "NULL::float8"},
+	{"batteryForecast", "bf.threshold::float8 AS battery_threshold", "NULL::float8"},
+	{"batteryForecast", "bf.depletes_at AS battery_depletes_at", "NULL::timestamptz"},
+	{"batteryForecast", "bf.replaced_at AS battery_replaced_at", "NULL::timestamptz"},
+	{"batteryForecast", "bf.samples AS battery_samples", "NULL::int"},
+	{"batteryForecast", "bf.computed_at AS battery_computed_at", "NULL::timestamptz"},
+
+	{"linkQuality", "lq.stats AS link_quality", "NULL::jsonb"},
+}
+
+// deviceLists are the lists that are aggregated for each device, with the join of the
+// aggregate, and only queried when their field is part of the devices.
+var deviceLists = []struct {
+	field string
+	cte   string
+	join  string
+}{
+	{"tags", `tag_list AS (
+			SELECT ddt.device_id, array_agg(dt.name) AS tags
+			FROM device_device_tags ddt
+			JOIN device_tags dt USING (name)
+			GROUP BY ddt.device_id
+		)`, "LEFT JOIN tag_list tl ON tl.device_id = d.device_id"},
+	{"metadata", `metadata_list AS (
+			SELECT dm.device_id, array_agg(ARRAY[dm.key, dm.vs]) AS meta
+			FROM device_metadata dm
+			GROUP BY dm.device_id
+		)`, "LEFT JOIN metadata_list ml ON ml.device_id = d.device_id"},
+	{"types", `types_list AS (
+			SELECT ddpt.device_id, array_agg(ARRAY[dpt.sensor_profile_type_id, dpt.name]) AS types
+			FROM device_sensor_profile_types ddpt
+			JOIN sensor_profile_types dpt USING (sensor_profile_type_id)
+			JOIN devices d USING (device_id)
+			WHERE d.deleted = FALSE
+			GROUP BY ddpt.device_id
+		)`, "LEFT JOIN types_list ON types_list.device_id = d.device_id"},
+	{"alarms", `alarms_list AS (
+			SELECT a.device_id, array_agg(a.type) AS alarms
+			FROM device_alarms a
+			GROUP BY a.device_id
+		)`, "LEFT JOIN alarms_list ON alarms_list.device_id = d.device_id"},
+}
+
+// deviceSelect returns the common table expressions, columns and joins of the device query
+// for the fields of the query.
+func deviceSelect(query dmquery.DeviceFilters) (string, string, string) {
+	columns := make([]string, 0, len(deviceColumns))
+	for _, c := range deviceColumns {
+		if c.field == "" || query.HasField(c.field) {
+			columns = append(columns, c.column)
+			continue
+		}
+
+		// keep the name of the column as the ordering may refer to it
+		null := c.null
+		if _, name, ok := strings.Cut(c.column, " AS "); ok {
+			null += " AS " + name
+		}
+		columns = append(columns, null)
+	}
+
+	var ctes, joins []string
+	for _, l := range deviceLists {
+		if query.HasField(l.field) {
+			ctes = append(ctes, l.cte)
+			joins = append(joins, l.join)
+		}
+	}
+
+	with := ""
+	if len(ctes) > 0 {
+		with = "WITH " + strings.Join(ctes, ",\n\n\t\t")
+	}
+
+	return with, strings.Join(columns, ",\n\t\t\t"), strings.Join(joins, "\n\t\t")
+}
+
+// includeDeviceDetails embeds the latest measurements and the alarms requested with include
+// in a page of devices, using one query for each kind of data. The latest status is always
+// part of a device and needs no additional query.
+func includeDeviceDetails(ctx context.Context, c *pgxpool.Conn, devices []types.Device, include []string) error {
+	if len(devices) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(devices))
+	deviceIDs := make([]string, 0, len(devices))
+	for i, d := range devices {
+		index[d.DeviceID] = i
+		deviceIDs = append(deviceIDs, d.DeviceID)
+	}
+
+	if slices.Contains(include, dmquery.IncludeLatestMeasurements) {
+		measurements, err := latestMeasurements(ctx, c, deviceIDs)
+		if err != nil {
+			return err
+		}
+		for deviceID, m := range measurements {
+			devices[index[deviceID]].LatestMeasurements = m
+		}
+	}
+
+	if slices.Contains(include, dmquery.IncludeAlarms) {
+		alarms, err := deviceAlarms(ctx, c, deviceIDs)
+		if err != nil {
+			return err
+		}
+		for deviceID, a := range alarms {
+			devices[index[deviceID]].AlarmDetails = a
+		}
+	}
+
+	return nil
+}
+
+// latestMeasurementsWindow bounds how far back the latest measurements of devices are looked
+// for, so that devices that stopped reporting long ago do not scan all their measurements.
+const latestMeasurementsWindow = 30 * 24 * time.Hour
+
+// latestMeasurements returns the latest value of each measurement, grouped by device. As with
+// latest=true on the measurements of a device, a measurement is identified by its id rather
+// than its urn, so that a device with several objects of the same type, such as two
+// temperature sensors, gets the latest value of each of them.
+func latestMeasurements(ctx context.Context, c *pgxpool.Conn, deviceIDs []string) (map[string][]types.Measurement, error) {
+	log := logging.GetFromContext(ctx)
+
+	args := pgx.NamedArgs{
+		"device_ids": deviceIDs,
+		"since":      time.Now().UTC().Add(-latestMeasurementsWindow),
+	}
+
+	rows, err := c.Query(ctx, `
+		SELECT DISTINCT ON (d.device_id, d.id) d.device_id, d."time", d.id, d.urn, d.n, d.v, d.vs, d.vb, d.unit
+		FROM events_measurements d
+		WHERE d.device_id = ANY(@device_ids) AND d."time" >= @since
+		ORDER BY d.device_id, d.id, d."time" DESC`, args)
+	if err != nil {
+		log.Error("failed to query latest measurements", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string][]types.Measurement{}
+
+	var ts time.Time
+	var deviceID, id, urn string
+	var v *float64
+	var n, vs, unit *string
+	var vb *bool
+
+	for rows.Next() {
+		err := rows.Scan(&deviceID, &ts, &id, &urn, &n, &v, &vs, &vb, &unit)
+		if err != nil {
+			log.Error("failed to scan measurement row", "err", err.Error())
+			return nil, err
+		}
+
+		result[deviceID] = append(result[deviceID], newMeasurement(ts, id, urn, n, v, vs, vb, unit))
+	}
+
+	return result, rows.Err()
+}
+
+// deviceAlarms returns the alarms of the devices, grouped by device.
+func deviceAlarms(ctx context.Context, c *pgxpool.Conn, deviceIDs []string) (map[string][]types.AlarmDetails, error) {
+	log := logging.GetFromContext(ctx)
+
+	rows, err := c.Query(ctx, `
+		SELECT device_id, type, COALESCE(description, ''), observed_at, severity
+		FROM device_alarms
+		WHERE device_id = ANY(@device_ids)
+		ORDER BY device_id, observed_at ASC`, pgx.NamedArgs{"device_ids": deviceIDs})
+	if err != nil {
+		log.Error("could not query device alarms", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string][]types.AlarmDetails{}
+
+	for rows.Next() {
+		var deviceID, alarmType, description string
+		var observedAt time.Time
+		var severity int
+
+		err := rows.Scan(&deviceID, &alarmType, &description, &observedAt, &severity)
+		if err != nil {
+			log.Error("could not scan row", "err", err.Error())
+			return nil, err
+		}
+
+		result[deviceID] = append(result[deviceID], types.AlarmDetails{
+			DeviceID:    deviceID,
+			AlarmType:   alarmType,
+			Description: description,
+			ObservedAt:  observedAt.UTC(),
+			Severity:    severity,
+		})
+	}
+
+	return result, rows.Err()
+}
+
+func newMeasurement(ts time.Time, id, urn string, n *string, v *float64, vs *string, vb *bool, unit *string) types.Measurement {
+	m := types.Measurement{
+		ID:        id,
+		Urn:       urn,
+		Unit:      unit,
+		Timestamp: ts.UTC(),
+	}
+	if n != nil && *n != "" {
+		name := *n
+		m.Name = &name
+	}
+	if v != nil {
+		m.Value = *v
+	} else if vs != nil {
+		m.Value = *vs
+	} else if vb != nil {
+		m.Value = *vb
+	}
+	return m
+}
+
+// measurementBuckets truncates the time of a measurement to the start of an hour or a day.
+var measurementBuckets = map[string]string{
+	types.BucketHour: `date_trunc('hour', d."time")`,
+	types.BucketDay:  `date_trunc('day', d."time" AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`,
+}
+
+var measurementAggregates = map[string]string{
+	"avg":   `avg(d.v)`,
+	"min":   `min(d.v)`,
+	"max":   `max(d.v)`,
+	"sum":   `sum(d.v)`,
+	"count": `count(*)`,
+	"first": `(array_agg(d.v ORDER BY d."time" ASC) FILTER (WHERE d.v IS NOT NULL))[1]`,
+	"last":  `(array_agg(d.v ORDER BY d."time" DESC) FILTER (WHERE d.v IS NOT NULL))[1]`,
+}
+
 func (s *Storage) GetDeviceMeasurements(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error) {
 	log := logging.GetFromContext(ctx)
 
@@ -1028,17 +1946,59 @@ func (s *Storage) GetDeviceMeasurements(ctx context.Context, deviceID string, qu
 	args := NamedArgs(condition)
 	offsetLimit, offset, limit := OffsetLimit(condition)
 
-	if offsetLimit == "" {
-		offsetLimit = "OFFSET 0 LIMIT 10 "
+	extra := []string{}
+	if query.MeasurementID != "" {
+		extra = append(extra, "d.id IN (@measurement_id::text, @device_id::text || @measurement_id::text, @device_id::text || '/' || @measurement_id::text)")
+		args["measurement_id"] = query.MeasurementID
+	}
+	if query.From != nil {
+		extra = append(extra, `d."time" >= @from`)
+		args["from"] = query.From.UTC()
+	}
+	if query.To != nil {
+		extra = append(extra, `d."time" < @to`)
+		args["to"] = query.To.UTC()
 	}
 
-	sql := fmt.Sprintf(`
-		SELECT d."time",d.id,d.urn,d.n,d.v,d.vs,d.vb,d.unit, count(*) OVER () AS count
+	source := fmt.Sprintf(`
+		SELECT d."time", d.id, d.urn, d.n, d.v, d.vs, d.vb, d.unit
 		FROM events_measurements d
-		-- LEFT JOIN sensor_profile_types spt ON d.urn=spt.sensor_profile_type_id
-		%s
-		ORDER BY d."time" DESC
-		%s`, Where(condition), offsetLimit)
+		%s`, where(condition, extra))
+
+	switch {
+	case query.Bucket != "":
+		bucket, ok := measurementBuckets[query.Bucket]
+		if !ok {
+			return types.Collection[types.Measurement]{}, fmt.Errorf("unsupported bucket %q", query.Bucket)
+		}
+
+		aggregate := query.Aggregate
+		if aggregate == "" {
+			aggregate = "avg"
+		}
+		fn, ok := measurementAggregates[aggregate]
+		if !ok {
+			return types.Collection[types.Measurement]{}, fmt.Errorf("unsupported aggregate %q", query.Aggregate)
+		}
+
+		source = fmt.Sprintf(`
+			SELECT %s AS "time", d.id, d.urn, d.n, (%s)::float8 AS v, NULL::text AS vs, NULL::boolean AS vb, d.unit
+			FROM events_measurements d
+			%s
+			GROUP BY 1, d.id, d.urn, d.n, d.unit`, bucket, fn, where(condition, extra))
+	case query.Latest:
+		source = fmt.Sprintf(`
+			SELECT DISTINCT ON (d.id) d."time", d.id, d.urn, d.n, d.v, d.vs, d.vb, d.unit
+			FROM events_measurements d
+			%s
+			ORDER BY d.id, d."time" DESC`, where(condition, extra))
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT m."time", m.id, m.urn, m.n, m.v, m.vs, m.vb, m.unit, count(*) OVER () AS count
+		FROM (%s) AS m
+		ORDER BY m."time" DESC, m.id ASC
+		%s`, source, offsetLimit)
 
 	c, err := s.conn.Acquire(ctx)
 	if err != nil {
@@ -1057,9 +2017,9 @@ func (s *Storage) GetDeviceMeasurements(ctx context.Context, deviceID string, qu
 	measurements := []types.Measurement{}
 	var totalCount int64
 	var ts time.Time
-	var id, urn, n string
+	var id, urn string
 	var v *float64
-	var vs, unit *string
+	var n, vs, unit *string
 	var vb *bool
 
 	for rows.Next() {
@@ -1069,27 +2029,7 @@ func (s *Storage) GetDeviceMeasurements(ctx context.Context, deviceID string, qu
 			return types.Collection[types.Measurement]{}, err
 		}
 
-		m := types.Measurement{
-			ID: strings.Replace(id, deviceID, "", 1),
-			//Urn:       urn,
-			Unit:      unit,
-			Timestamp: ts.UTC(),
-		}
-		/*
-			if name != nil {
-				n := strings.ToLower(*name)
-				m.Name = &n
-			}
-		*/
-		if v != nil {
-			m.Value = *v
-		} else if vs != nil {
-			m.Value = *vs
-		} else if vb != nil {
-			m.Value = *vb
-		}
-
-		measurements = append(measurements, m)
+		measurements = append(measurements, newMeasurement(ts, id, urn, n, v, vs, vb, unit))
 	}
 
 	if err := rows.Err(); err != nil {
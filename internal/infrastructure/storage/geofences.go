@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// CreateGeofence stores a new geofence, or returns ErrGeofenceAlreadyExist if the id is taken
+// by any geofence, including geofences in other tenants.
+func (s *Storage) CreateGeofence(ctx context.Context, g types.Geofence) error {
+	log := logging.GetFromContext(ctx)
+
+	args, err := geofenceArgs(g)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	result, err := c.Exec(ctx, `
+		INSERT INTO geofences (geofence_id, tenant, name, device_id, tag, polygon, center, radius)
+		VALUES (@geofence_id, @tenant, @name, @device_id, @tag, @polygon::jsonb, @center, @radius)
+		ON CONFLICT (geofence_id) DO NOTHING`, args)
+	if err != nil {
+		log.Error("could not store geofence", "geofence_id", g.ID, "err", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return devices.ErrGeofenceAlreadyExist
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateGeofence(ctx context.Context, g types.Geofence) error {
+	log := logging.GetFromContext(ctx)
+
+	args, err := geofenceArgs(g)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	result, err := c.Exec(ctx, `
+		UPDATE geofences
+		SET tenant = @tenant,
+			name = @name,
+			device_id = @device_id,
+			tag = @tag,
+			polygon = @polygon::jsonb,
+			center = @center,
+			radius = @radius,
+			modified_on = CURRENT_TIMESTAMP
+		WHERE geofence_id = @geofence_id`, args)
+	if err != nil {
+		log.Error("could not update geofence", "geofence_id", g.ID, "err", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return devices.ErrGeofenceNotFound
+	}
+
+	return nil
+}
+
+func geofenceArgs(g types.Geofence) (pgx.NamedArgs, error) {
+	args := pgx.NamedArgs{
+		"geofence_id": g.ID,
+		"tenant":      g.Tenant,
+		"name":        nullIfEmpty(g.Name),
+		"device_id":   nullIfEmpty(g.DeviceID),
+		"tag":         nullIfEmpty(g.Tag),
+		"polygon":     nil,
+		"center":      pgtype.Point{},
+		"radius":      nil,
+	}
+
+	if len(g.Polygon) > 0 {
+		b, err := json.Marshal(g.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		args["polygon"] = string(b)
+	}
+
+	if g.Center != nil && g.Radius > 0 {
+		args["center"] = pgtype.Point{P: pgtype.Vec2{X: g.Center.Longitude, Y: g.Center.Latitude}, Valid: true}
+		args["radius"] = g.Radius
+	}
+
+	return args, nil
+}
+
+func (s *Storage) DeleteGeofence(ctx context.Context, geofenceID string) error {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `DELETE FROM geofences WHERE geofence_id = @geofence_id`, pgx.NamedArgs{"geofence_id": geofenceID})
+	if err != nil {
+		log.Error("could not delete geofence", "geofence_id", geofenceID, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) GetGeofences(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+	log := logging.GetFromContext(ctx)
+
+	where, args := geofenceWhere(query)
+
+	condition := &Condition{Offset: query.Offset, Limit: query.Limit}
+	offsetLimit, offset, limit := OffsetLimit(condition, 0, 100)
+	if query.Offset != nil {
+		args["offset"] = *query.Offset
+	}
+	if query.Limit != nil {
+		args["limit"] = *query.Limit
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.Geofence]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT g.geofence_id, g.tenant, COALESCE(g.name, ''), COALESCE(g.device_id, ''), COALESCE(g.tag, ''),
+			g.polygon, g.center, COALESCE(g.radius, 0), count(*) OVER () AS count
+		FROM geofences g
+		`+where+`
+		ORDER BY g.geofence_id ASC
+		`+offsetLimit, args)
+	if err != nil {
+		log.Error("could not query geofences", "err", err.Error())
+		return types.Collection[types.Geofence]{}, err
+	}
+	defer rows.Close()
+
+	geofences := []types.Geofence{}
+	var count uint64
+
+	for rows.Next() {
+		var g types.Geofence
+		var polygon []byte
+		var center pgtype.Point
+
+		err := rows.Scan(&g.ID, &g.Tenant, &g.Name, &g.DeviceID, &g.Tag, &polygon, &center, &g.Radius, &count)
+		if err != nil {
+			log.Error("could not scan geofence", "err", err.Error())
+			return types.Collection[types.Geofence]{}, err
+		}
+
+		if len(polygon) > 0 {
+			if err := json.Unmarshal(polygon, &g.Polygon); err != nil {
+				log.Error("could not unmarshal geofence polygon", "geofence_id", g.ID, "err", err.Error())
+				return types.Collection[types.Geofence]{}, err
+			}
+		}
+
+		if center.Valid {
+			g.Center = &types.Location{Latitude: center.P.Y, Longitude: center.P.X}
+		}
+
+		geofences = append(geofences, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.Geofence]{}, err
+	}
+
+	return types.Collection[types.Geofence]{
+		Data:       geofences,
+		Count:      uint64(len(geofences)),
+		Offset:     uint64(offset),
+		Limit:      uint64(limit),
+		TotalCount: count,
+	}, nil
+}
+
+// geofenceWhere matches the fences of a device both by device and by the tags of the device,
+// in the tenant of the device as tag names are shared by tenants. Without allowed tenants all
+// tenants are matched, which is only used internally.
+func geofenceWhere(query dmquery.GeofenceFilters) (string, pgx.NamedArgs) {
+	where := []string{"TRUE"}
+	args := pgx.NamedArgs{}
+
+	if len(query.AllowedTenants) > 0 {
+		where = append(where, "g.tenant = ANY(@tenants)")
+		args["tenants"] = query.AllowedTenants
+	}
+
+	if query.Tenant != "" {
+		where = append(where, "g.tenant = @tenant")
+		args["tenant"] = query.Tenant
+	}
+
+	if query.ID != "" {
+		where = append(where, "g.geofence_id = @geofence_id")
+		args["geofence_id"] = query.ID
+	}
+
+	if query.DeviceID != "" {
+		where = append(where, "(g.device_id = @device_id OR g.tag IN (SELECT ddt.name FROM device_device_tags ddt WHERE ddt.device_id = @device_id))")
+		where = append(where, "g.tenant = (SELECT d.tenant FROM devices d WHERE d.device_id = @device_id)")
+		args["device_id"] = query.DeviceID
+	}
+
+	if query.Tag != "" {
+		where = append(where, "g.tag = @tag")
+		args["tag"] = query.Tag
+	}
+
+	return "WHERE " + strings.Join(where, " AND "), args
+}
+
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
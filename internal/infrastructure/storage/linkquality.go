@@ -0,0 +1,238 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetLinkQualityStatistics returns RSSI and SNR percentiles, the SF and DR distribution and
+// the RSSI trend of each sensor with status rows since a point in time.
+func (s *Storage) GetLinkQualityStatistics(ctx context.Context, since time.Time) ([]types.LinkQuality, error) {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		WITH s AS (
+			SELECT sensor_id, observed_at, rssi::float8 AS rssi, snr::float8 AS snr, round(sf)::int AS sf, round(dr)::int AS dr
+			FROM sensor_status
+			WHERE observed_at >= @since AND (rssi IS NOT NULL OR snr IS NOT NULL)
+		),
+		sf AS (
+			SELECT sensor_id, jsonb_object_agg(sf::text, n) AS sf
+			FROM (SELECT sensor_id, sf, count(*) AS n FROM s WHERE sf IS NOT NULL GROUP BY 1, 2) x
+			GROUP BY 1
+		),
+		dr AS (
+			SELECT sensor_id, jsonb_object_agg(dr::text, n) AS dr
+			FROM (SELECT sensor_id, dr, count(*) AS n FROM s WHERE dr IS NOT NULL GROUP BY 1, 2) x
+			GROUP BY 1
+		)
+		SELECT s.sensor_id, count(*),
+			percentile_cont(0.1) WITHIN GROUP (ORDER BY s.rssi),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY s.rssi),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY s.rssi),
+			percentile_cont(0.1) WITHIN GROUP (ORDER BY s.snr),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY s.snr),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY s.snr),
+			regr_slope(s.rssi, extract(epoch FROM s.observed_at)::float8 / 86400),
+			min(s.observed_at), max(s.observed_at),
+			sf.sf, dr.dr
+		FROM s
+		LEFT JOIN sf USING (sensor_id)
+		LEFT JOIN dr USING (sensor_id)
+		GROUP BY s.sensor_id, sf.sf, dr.dr`, pgx.NamedArgs{"since": since.UTC()})
+	if err != nil {
+		log.Error("could not query link quality statistics", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []types.LinkQuality{}
+
+	for rows.Next() {
+		var lq types.LinkQuality
+
+		err := rows.Scan(
+			&lq.SensorID, &lq.Samples,
+			&lq.RSSI.P10, &lq.RSSI.P50, &lq.RSSI.P90,
+			&lq.LoRaSNR.P10, &lq.LoRaSNR.P50, &lq.LoRaSNR.P90,
+			&lq.RSSITrend,
+			&lq.From, &lq.To,
+			&lq.SpreadingFactors, &lq.DataRates,
+		)
+		if err != nil {
+			log.Error("could not scan link quality statistics", "err", err.Error())
+			return nil, err
+		}
+
+		lq.From = lq.From.UTC()
+		lq.To = lq.To.UTC()
+		stats = append(stats, lq)
+	}
+
+	return stats, rows.Err()
+}
+
+// SetLinkQuality replaces the link quality of all sensors.
+func (s *Storage) SetLinkQuality(ctx context.Context, stats []types.LinkQuality) error {
+	var sensorIDs, classes, values []string
+	var computedAt []time.Time
+
+	for _, lq := range stats {
+		sensorID := lq.SensorID
+		lq.SensorID = ""
+
+		b, err := json.Marshal(lq)
+		if err != nil {
+			return err
+		}
+
+		sensorIDs = append(sensorIDs, sensorID)
+		classes = append(classes, lq.Class)
+		values = append(values, string(b))
+		computedAt = append(computedAt, lq.ComputedAt)
+	}
+
+	args := pgx.NamedArgs{
+		"sensor_ids":  sensorIDs,
+		"classes":     classes,
+		"stats":       values,
+		"computed_at": computedAt,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM sensor_link_quality
+		WHERE NOT (sensor_id = ANY(COALESCE(@sensor_ids::text[], '{}')));`, args)
+	if err != nil {
+		log.Error("could not delete link quality", "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO sensor_link_quality (sensor_id, class, stats, computed_at)
+		SELECT l.sensor_id, l.class, l.stats, l.computed_at
+		FROM unnest(@sensor_ids::text[], @classes::text[], @stats::jsonb[], @computed_at::timestamptz[])
+			AS l(sensor_id, class, stats, computed_at)
+		JOIN sensors s ON s.sensor_id = l.sensor_id
+		ON CONFLICT (sensor_id) DO UPDATE
+			SET class = EXCLUDED.class,
+				stats = EXCLUDED.stats,
+				computed_at = EXCLUDED.computed_at;`, args)
+	if err != nil {
+		log.Error("could not store link quality", "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetCoverage divides the bounds into a grid of size by size cells and aggregates the link
+// quality of the devices within each cell. Cells without devices are left out.
+func (s *Storage) GetCoverage(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error) {
+	if query.Bounds == nil || query.Size <= 0 {
+		return nil, fmt.Errorf("bounds and size are required")
+	}
+
+	b := *query.Bounds
+
+	args := pgx.NamedArgs{
+		"tenants": query.AllowedTenants,
+		"min_x":   b.MinLon,
+		"max_x":   b.MaxLon,
+		"min_y":   b.MinLat,
+		"max_y":   b.MaxLat,
+		"size":    query.Size,
+	}
+
+	filter := ""
+	if query.Tenant != "" {
+		filter = " AND d.tenant = @tenant"
+		args["tenant"] = query.Tenant
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, fmt.Sprintf(`
+		SELECT
+			LEAST(width_bucket(d.location[1], @min_y::float8, @max_y::float8, @size::int), @size::int) - 1 AS grid_row,
+			LEAST(width_bucket(d.location[0], @min_x::float8, @max_x::float8, @size::int), @size::int) - 1 AS grid_column,
+			count(*),
+			count(*) FILTER (WHERE lq.class = 'good'),
+			count(*) FILTER (WHERE lq.class = 'marginal'),
+			count(*) FILTER (WHERE lq.class = 'poor'),
+			avg((lq.stats->'rssi'->>'p50')::float8),
+			avg((lq.stats->'loRaSNR'->>'p50')::float8)
+		FROM devices d
+		JOIN sensor_link_quality lq ON lq.sensor_id = d.sensor_id
+		WHERE d.deleted = FALSE
+			AND d.tenant = ANY(@tenants)%s
+			AND d.location <@ box(point(@min_x::float8, @min_y::float8), point(@max_x::float8, @max_y::float8))
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, filter), args)
+	if err != nil {
+		log.Error("could not query coverage", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	width := (b.MaxLon - b.MinLon) / float64(query.Size)
+	height := (b.MaxLat - b.MinLat) / float64(query.Size)
+
+	cells := []types.CoverageCell{}
+
+	for rows.Next() {
+		var cell types.CoverageCell
+
+		err := rows.Scan(&cell.Row, &cell.Column, &cell.Devices, &cell.Good, &cell.Marginal, &cell.Poor, &cell.RSSI, &cell.LoRaSNR)
+		if err != nil {
+			log.Error("could not scan coverage cell", "err", err.Error())
+			return nil, err
+		}
+
+		cell.Bounds = types.Bounds{
+			MinLon: b.MinLon + float64(cell.Column)*width,
+			MaxLon: b.MinLon + float64(cell.Column+1)*width,
+			MinLat: b.MinLat + float64(cell.Row)*height,
+			MaxLat: b.MinLat + float64(cell.Row+1)*height,
+		}
+
+		cells = append(cells, cell)
+	}
+
+	return cells, rows.Err()
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// AddDeviceLocation records that a device was observed at a location. A location at the same
+// time as an already recorded one is ignored.
+func (s *Storage) AddDeviceLocation(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `
+		INSERT INTO device_location_history (device_id, location, source, observed_at)
+		VALUES (@device_id, point(@lon, @lat), @source, @observed_at)
+		ON CONFLICT (device_id, observed_at) DO NOTHING`, pgx.NamedArgs{
+		"device_id":   deviceID,
+		"lon":         location.Longitude,
+		"lat":         location.Latitude,
+		"source":      source,
+		"observed_at": observedAt.UTC(),
+	})
+	if err != nil {
+		log.Error("could not add device location", "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetDeviceLocations returns the recorded locations of a device, oldest first. From also
+// includes the location the device had when the period starts.
+func (s *Storage) GetDeviceLocations(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error) {
+	log := logging.GetFromContext(ctx)
+
+	condition := &Condition{Offset: query.Offset, Limit: query.Limit}
+	offsetLimit, offset, limit := OffsetLimit(condition, 0, 1000)
+
+	args := NamedArgs(condition)
+	args["device_id"] = deviceID
+
+	period := ""
+	if query.From != nil {
+		period += ` AND h.observed_at >= COALESCE((
+			SELECT max(p.observed_at) FROM device_location_history p
+			WHERE p.device_id = h.device_id AND p.observed_at <= @from), @from)`
+		args["from"] = query.From.UTC()
+	}
+	if query.To != nil {
+		period += " AND h.observed_at < @to"
+		args["to"] = query.To.UTC()
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.DeviceLocation]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT h.location, h.source, h.observed_at, count(*) OVER () AS count
+		FROM device_location_history h
+		WHERE h.device_id = @device_id`+period+`
+		ORDER BY h.observed_at ASC
+		`+offsetLimit, args)
+	if err != nil {
+		log.Error("could not query device locations", "err", err.Error())
+		return types.Collection[types.DeviceLocation]{}, err
+	}
+	defer rows.Close()
+
+	locations := []types.DeviceLocation{}
+	var count uint64
+
+	for rows.Next() {
+		var p pgtype.Point
+		l := types.DeviceLocation{DeviceID: deviceID}
+
+		if err := rows.Scan(&p, &l.Source, &l.ObservedAt, &count); err != nil {
+			log.Error("could not scan device location", "err", err.Error())
+			return types.Collection[types.DeviceLocation]{}, err
+		}
+
+		l.Location = types.Location{Latitude: p.P.Y, Longitude: p.P.X}
+		l.ObservedAt = l.ObservedAt.UTC()
+
+		locations = append(locations, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.DeviceLocation]{}, err
+	}
+
+	return types.Collection[types.DeviceLocation]{
+		Data:       locations,
+		Count:      uint64(len(locations)),
+		Offset:     uint64(offset),
+		Limit:      uint64(limit),
+		TotalCount: count,
+	}, nil
+}
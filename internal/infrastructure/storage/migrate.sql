@@ -1,3 +1,12 @@
+-- pg_trgm is used to search by similarity, but creating it may require privileges that the
+-- service does not have. Without it, search matches by ILIKE and text search only.
+DO $$
+BEGIN
+	CREATE EXTENSION IF NOT EXISTS pg_trgm;
+EXCEPTION WHEN OTHERS THEN
+	RAISE NOTICE 'pg_trgm is not available, search by similarity is disabled: %', SQLERRM;
+END $$;
+
 CREATE TABLE IF NOT EXISTS sensor_profiles (
 	sensor_profile_id	TEXT NOT NULL,
 	name 				TEXT NULL,
@@ -56,6 +65,8 @@ ALTER TABLE sensors ADD COLUMN IF NOT EXISTS sensor_profile TEXT NULL;
 ALTER TABLE sensors ADD COLUMN IF NOT EXISTS name TEXT NULL;
 ALTER TABLE sensors ADD COLUMN IF NOT EXISTS location POINT NULL;
 ALTER TABLE sensors DROP COLUMN IF EXISTS source;
+ALTER TABLE sensor_profiles ADD COLUMN IF NOT EXISTS calendar JSONB NULL;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS calendar JSONB NULL;
 ALTER TABLE devices DROP CONSTRAINT IF EXISTS fk_device_profiles;
 
 DO $$
@@ -120,6 +131,118 @@ BEGIN
 	END IF;
 END $$;
 
+DO $$
+BEGIN
+	IF NOT EXISTS (
+		SELECT 1
+		FROM information_schema.columns
+		WHERE table_name = 'sensors' AND column_name = 'observed_at'
+	) THEN
+		ALTER TABLE sensors
+			ADD COLUMN observed_at		timestamp with time zone NULL,
+			ADD COLUMN battery_level	NUMERIC NULL,
+			ADD COLUMN rssi				NUMERIC NULL,
+			ADD COLUMN snr				NUMERIC NULL,
+			ADD COLUMN fq				NUMERIC NULL,
+			ADD COLUMN sf				NUMERIC NULL,
+			ADD COLUMN dr				NUMERIC NULL;
+
+		UPDATE sensors s
+		SET observed_at = ls.observed_at,
+			battery_level = ls.battery_level,
+			rssi = ls.rssi,
+			snr = ls.snr,
+			fq = ls.fq,
+			sf = ls.sf,
+			dr = ls.dr
+		FROM (
+			SELECT DISTINCT ON (sensor_id)
+				sensor_id, battery_level, rssi, snr, fq, sf, dr, observed_at
+			FROM sensor_status
+			ORDER BY sensor_id, observed_at DESC
+		) ls
+		WHERE ls.sensor_id = s.sensor_id;
+	END IF;
+END $$;
+
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS discovered BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS tenant TEXT NULL;
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS first_seen timestamp with time zone NULL;
+
+CREATE TABLE IF NOT EXISTS sensor_status_hourly (
+	bucket			timestamp with time zone NOT NULL,
+	sensor_id		TEXT 	NOT NULL,
+	samples			INTEGER NOT NULL DEFAULT 0,
+	battery_min		NUMERIC NULL,
+	battery_max		NUMERIC NULL,
+	battery_avg		NUMERIC NULL,
+	rssi_min		NUMERIC NULL,
+	rssi_max		NUMERIC NULL,
+	rssi_avg		NUMERIC NULL,
+	snr_min			NUMERIC NULL,
+	snr_max			NUMERIC NULL,
+	snr_avg			NUMERIC NULL,
+	sf_min			NUMERIC NULL,
+	sf_max			NUMERIC NULL,
+	sf_avg			NUMERIC NULL,
+	dr_min			NUMERIC NULL,
+	dr_max			NUMERIC NULL,
+	dr_avg			NUMERIC NULL,
+	created_on  	timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_sensor_status_hourly PRIMARY KEY (bucket, sensor_id),
+	CONSTRAINT fk_sensor_sensor_status_hourly FOREIGN KEY (sensor_id) REFERENCES sensors (sensor_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sensor_status_daily (
+	bucket			timestamp with time zone NOT NULL,
+	sensor_id		TEXT 	NOT NULL,
+	samples			INTEGER NOT NULL DEFAULT 0,
+	battery_min		NUMERIC NULL,
+	battery_max		NUMERIC NULL,
+	battery_avg		NUMERIC NULL,
+	rssi_min		NUMERIC NULL,
+	rssi_max		NUMERIC NULL,
+	rssi_avg		NUMERIC NULL,
+	snr_min			NUMERIC NULL,
+	snr_max			NUMERIC NULL,
+	snr_avg			NUMERIC NULL,
+	sf_min			NUMERIC NULL,
+	sf_max			NUMERIC NULL,
+	sf_avg			NUMERIC NULL,
+	dr_min			NUMERIC NULL,
+	dr_max			NUMERIC NULL,
+	dr_avg			NUMERIC NULL,
+	created_on  	timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_sensor_status_daily PRIMARY KEY (bucket, sensor_id),
+	CONSTRAINT fk_sensor_sensor_status_daily FOREIGN KEY (sensor_id) REFERENCES sensors (sensor_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sensor_battery_forecast (
+	sensor_id		TEXT 	NOT NULL,
+	level			NUMERIC NOT NULL,
+	rate			NUMERIC NOT NULL,
+	threshold		NUMERIC NOT NULL,
+	depletes_at		timestamp with time zone NULL,
+	replaced_at		timestamp with time zone NULL,
+	samples			INTEGER NOT NULL DEFAULT 0,
+	computed_at		timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_sensor_battery_forecast PRIMARY KEY (sensor_id),
+	CONSTRAINT fk_sensor_battery_forecast FOREIGN KEY (sensor_id) REFERENCES sensors (sensor_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sensor_link_quality (
+	sensor_id		TEXT 	NOT NULL,
+	class			TEXT 	NOT NULL,
+	stats			JSONB 	NOT NULL,
+	computed_at		timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_sensor_link_quality PRIMARY KEY (sensor_id),
+	CONSTRAINT fk_sensor_link_quality FOREIGN KEY (sensor_id) REFERENCES sensors (sensor_id) ON DELETE CASCADE
+);
+
 CREATE TABLE IF NOT EXISTS device_state (
 	device_id	TEXT NOT NULL,
 	online 		BOOLEAN NOT NULL DEFAULT FALSE,
@@ -132,6 +255,8 @@ CREATE TABLE IF NOT EXISTS device_state (
 	CONSTRAINT fk_device_device_state FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
 );
 
+ALTER TABLE device_state ADD COLUMN IF NOT EXISTS degraded_until timestamp with time zone NULL;
+
 CREATE TABLE IF NOT EXISTS device_alarms (
 	device_id	TEXT NOT NULL,
 	type		TEXT NOT NULL,
@@ -145,6 +270,93 @@ CREATE TABLE IF NOT EXISTS device_alarms (
 	CONSTRAINT fk_device_alarms FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS device_state_history (
+	device_id	TEXT NOT NULL,
+	online 		BOOLEAN NOT NULL,
+	observed_at	timestamp with time zone NOT NULL,
+
+	CONSTRAINT pk_device_state_history PRIMARY KEY (device_id, observed_at),
+	CONSTRAINT fk_device_state_history FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS device_alarm_history (
+	device_id	TEXT NOT NULL,
+	type		TEXT NOT NULL,
+	severity	NUMERIC NOT NULL DEFAULT 0,
+	started_at	timestamp with time zone NOT NULL,
+	ended_at	timestamp with time zone NULL,
+
+	CONSTRAINT pk_device_alarm_history PRIMARY KEY (device_id, type, started_at),
+	CONSTRAINT fk_device_alarm_history FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
+);
+
+-- devices with a state before the history was kept start out in their current state
+INSERT INTO device_state_history (device_id, online, observed_at)
+SELECT ds.device_id, ds.online, ds.observed_at
+FROM device_state ds
+WHERE ds.observed_at IS NOT NULL
+	AND NOT EXISTS (SELECT 1 FROM device_state_history h WHERE h.device_id = ds.device_id);
+
+-- alarms raised before the history was kept are open since they were first raised
+INSERT INTO device_alarm_history (device_id, type, severity, started_at)
+SELECT a.device_id, a.type, a.severity, a.created_on
+FROM device_alarms a
+WHERE NOT EXISTS (SELECT 1 FROM device_alarm_history h WHERE h.device_id = a.device_id AND h.type = a.type AND h.ended_at IS NULL)
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS device_location_history (
+	device_id	TEXT NOT NULL,
+	location	POINT NOT NULL,
+	source		TEXT NOT NULL,
+	observed_at	timestamp with time zone NOT NULL,
+
+	CONSTRAINT pk_device_location_history PRIMARY KEY (device_id, observed_at),
+	CONSTRAINT fk_device_location_history FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
+);
+
+-- devices located before the history was kept start out at their current location
+INSERT INTO device_location_history (device_id, location, source, observed_at)
+SELECT d.device_id, d.location, 'import', d.created_on
+FROM devices d
+WHERE d.location IS NOT NULL AND NOT d.location ~= point(0,0)
+	AND NOT EXISTS (SELECT 1 FROM device_location_history h WHERE h.device_id = d.device_id);
+
+CREATE TABLE IF NOT EXISTS geofences (
+	geofence_id	TEXT NOT NULL,
+	tenant		TEXT NOT NULL,
+	name		TEXT NULL,
+	device_id	TEXT NULL,
+	tag			TEXT NULL,
+	polygon		JSONB NULL,
+	center		POINT NULL,
+	radius		NUMERIC NULL,
+
+	created_on  timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_on timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_geofences PRIMARY KEY (geofence_id),
+	CONSTRAINT fk_geofences_device FOREIGN KEY (device_id) REFERENCES devices (device_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS views (
+	view_id		TEXT NOT NULL,
+	tenant		TEXT NOT NULL,
+	owner		TEXT NULL,
+	shared		BOOLEAN NOT NULL DEFAULT FALSE,
+	name		TEXT NOT NULL,
+	filters		JSONB NULL,
+	sort_by		TEXT NULL,
+	sort_desc	BOOLEAN NOT NULL DEFAULT FALSE,
+	columns		TEXT[] NULL,
+
+	created_on  timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_on timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_views PRIMARY KEY (view_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_views_tenant ON views(tenant);
+
 CREATE TABLE IF NOT EXISTS device_metadata (
 	device_id	TEXT NOT NULL,
 	key			TEXT NOT NULL,
@@ -192,6 +404,20 @@ CREATE TABLE IF NOT EXISTS device_sensor_profile_types (
 	CONSTRAINT fk_device_sensor_profile_types_type FOREIGN KEY (sensor_profile_type_id) REFERENCES sensor_profile_types (sensor_profile_type_id) ON DELETE CASCADE
 );
 
+CREATE TABLE IF NOT EXISTS quarantined_messages (
+	id			BIGSERIAL,
+	device_id	TEXT NOT NULL DEFAULT '',
+	tenant		TEXT NOT NULL DEFAULT '',
+	reason		TEXT NOT NULL,
+	error		TEXT NULL,
+	body		TEXT NOT NULL,
+	attempts	INTEGER NOT NULL DEFAULT 0,
+	received_at	timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	modified_on timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+	CONSTRAINT pk_quarantined_messages PRIMARY KEY (id)
+);
+
 DO $$
 BEGIN
 	IF to_regclass('public.device_profiles_device_profiles_types') IS NOT NULL THEN
@@ -266,12 +492,132 @@ BEGIN
 	END IF;
 END $$;
 
+-- the text that is searched is kept in a column of each searchable table, so that it can be indexed
+ALTER TABLE sensors ADD COLUMN IF NOT EXISTS search_document TEXT
+	GENERATED ALWAYS AS (sensor_id || ' ' || COALESCE(name, '') || ' ' || COALESCE(sensor_profile, '')) STORED;
+ALTER TABLE devices ADD COLUMN IF NOT EXISTS search_document TEXT NULL;
+ALTER TABLE device_alarms ADD COLUMN IF NOT EXISTS search_document TEXT NULL;
+
+-- the text of a device is its ids, name, description and environment followed by its sensor
+-- profile, tags and metadata values
+CREATE OR REPLACE FUNCTION device_search_document(id TEXT) RETURNS TEXT LANGUAGE sql STABLE AS $$
+	SELECT concat_ws(' ', d.device_id, d.sensor_id, d.name, d.description, d.environment,
+		(SELECT concat_ws(' ', sp.sensor_profile_id, sp.name, sp.decoder)
+			FROM sensors s
+			JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+			WHERE s.sensor_id = d.sensor_id),
+		(SELECT string_agg(ddt.name, ' ') FROM device_device_tags ddt WHERE ddt.device_id = d.device_id),
+		(SELECT string_agg(COALESCE(dm.vs, dm.v::text), ' ') FROM device_metadata dm WHERE dm.device_id = d.device_id))
+	FROM devices d
+	WHERE d.device_id = id
+$$;
+
+-- the text of an alarm includes the device it is raised for
+CREATE OR REPLACE FUNCTION alarm_search_document(alarm_type TEXT, alarm_description TEXT, id TEXT) RETURNS TEXT LANGUAGE sql STABLE AS $$
+	SELECT concat_ws(' ', alarm_type, alarm_description, id, (SELECT d.name FROM devices d WHERE d.device_id = id))
+$$;
+
+CREATE OR REPLACE FUNCTION update_device_search_document() RETURNS TRIGGER LANGUAGE plpgsql AS $$
+DECLARE
+	r RECORD;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		r := OLD;
+	ELSE
+		r := NEW;
+	END IF;
+
+	IF TG_TABLE_NAME = 'sensors' THEN
+		UPDATE devices d SET search_document = device_search_document(d.device_id)
+		WHERE d.sensor_id = r.sensor_id;
+	ELSIF TG_TABLE_NAME = 'sensor_profiles' THEN
+		UPDATE devices d SET search_document = device_search_document(d.device_id)
+		WHERE d.sensor_id IN (SELECT s.sensor_id FROM sensors s WHERE s.sensor_profile = r.sensor_profile_id);
+	ELSE
+		UPDATE devices d SET search_document = device_search_document(d.device_id)
+		WHERE d.device_id = r.device_id;
+	END IF;
+
+	IF TG_TABLE_NAME = 'devices' THEN
+		UPDATE device_alarms a SET search_document = alarm_search_document(a.type, a.description, a.device_id)
+		WHERE a.device_id = r.device_id;
+	END IF;
+
+	RETURN NULL;
+END $$;
+
+CREATE OR REPLACE FUNCTION set_alarm_search_document() RETURNS TRIGGER LANGUAGE plpgsql AS $$
+BEGIN
+	NEW.search_document := alarm_search_document(NEW.type, NEW.description, NEW.device_id);
+	RETURN NEW;
+END $$;
+
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_devices_search_document') THEN
+		CREATE TRIGGER trg_devices_search_document AFTER INSERT OR UPDATE OF sensor_id, name, description, environment ON devices
+			FOR EACH ROW EXECUTE FUNCTION update_device_search_document();
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_device_device_tags_search_document') THEN
+		CREATE TRIGGER trg_device_device_tags_search_document AFTER INSERT OR UPDATE OR DELETE ON device_device_tags
+			FOR EACH ROW EXECUTE FUNCTION update_device_search_document();
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_device_metadata_search_document') THEN
+		CREATE TRIGGER trg_device_metadata_search_document AFTER INSERT OR UPDATE OR DELETE ON device_metadata
+			FOR EACH ROW EXECUTE FUNCTION update_device_search_document();
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_sensors_search_document') THEN
+		CREATE TRIGGER trg_sensors_search_document AFTER UPDATE OF sensor_profile ON sensors
+			FOR EACH ROW EXECUTE FUNCTION update_device_search_document();
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_sensor_profiles_search_document') THEN
+		CREATE TRIGGER trg_sensor_profiles_search_document AFTER UPDATE OF name, decoder ON sensor_profiles
+			FOR EACH ROW EXECUTE FUNCTION update_device_search_document();
+	END IF;
+
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'trg_device_alarms_search_document') THEN
+		CREATE TRIGGER trg_device_alarms_search_document BEFORE INSERT OR UPDATE OF type, description, device_id ON device_alarms
+			FOR EACH ROW EXECUTE FUNCTION set_alarm_search_document();
+	END IF;
+END $$;
+
+-- devices and alarms created before the text was kept are searchable too
+UPDATE devices SET search_document = device_search_document(device_id) WHERE search_document IS NULL;
+UPDATE device_alarms SET search_document = alarm_search_document(type, description, device_id) WHERE search_document IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_devices_search_vector ON devices USING GIN (to_tsvector('simple', search_document));
+CREATE INDEX IF NOT EXISTS idx_sensors_search_vector ON sensors USING GIN (to_tsvector('simple', search_document));
+CREATE INDEX IF NOT EXISTS idx_device_alarms_search_vector ON device_alarms USING GIN (to_tsvector('simple', search_document));
+
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm') THEN
+		CREATE INDEX IF NOT EXISTS idx_devices_search_trgm ON devices USING GIN (search_document gin_trgm_ops);
+		CREATE INDEX IF NOT EXISTS idx_sensors_search_trgm ON sensors USING GIN (search_document gin_trgm_ops);
+		CREATE INDEX IF NOT EXISTS idx_device_alarms_search_trgm ON device_alarms USING GIN (search_document gin_trgm_ops);
+	END IF;
+END $$;
+
 DROP INDEX IF EXISTS uq_devices_sensor_not_deleted;
 CREATE UNIQUE INDEX IF NOT EXISTS uq_devices_sensor_not_deleted ON devices(sensor_id) WHERE deleted = FALSE AND sensor_id IS NOT NULL;
 
 CREATE INDEX IF NOT EXISTS idx_device_state_device_id ON device_state(device_id);
 CREATE INDEX IF NOT EXISTS idx_device_device_tags_name ON device_device_tags(name);
 CREATE INDEX IF NOT EXISTS idx_sensor_status_sensor_id_observed_at ON sensor_status(sensor_id, observed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_sensors_observed_at ON sensors(observed_at);
+CREATE INDEX IF NOT EXISTS idx_sensor_status_hourly_sensor_id_bucket ON sensor_status_hourly(sensor_id, bucket DESC);
+CREATE INDEX IF NOT EXISTS idx_sensor_status_daily_sensor_id_bucket ON sensor_status_daily(sensor_id, bucket DESC);
+CREATE INDEX IF NOT EXISTS idx_sensor_battery_forecast_depletes_at ON sensor_battery_forecast(depletes_at);
+CREATE INDEX IF NOT EXISTS idx_sensor_link_quality_class ON sensor_link_quality(class);
+CREATE INDEX IF NOT EXISTS idx_sensors_discovered ON sensors(discovered) WHERE discovered = TRUE;
+CREATE INDEX IF NOT EXISTS idx_device_alarm_history_open ON device_alarm_history(device_id, type) WHERE ended_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_geofences_device_id ON geofences(device_id) WHERE device_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_geofences_tag ON geofences(tag) WHERE tag IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_quarantined_messages_device_id ON quarantined_messages(device_id, tenant, reason);
 DROP INDEX IF EXISTS idx_device_device_profile_types_type;
 CREATE INDEX IF NOT EXISTS idx_device_sensor_profile_types_type ON device_sensor_profile_types(sensor_profile_type_id);
 
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+func (s *Storage) AddQuarantinedMessage(ctx context.Context, m types.QuarantinedMessage) error {
+	args := pgx.NamedArgs{
+		"device_id": m.DeviceID,
+		"tenant":    m.Tenant,
+		"reason":    m.Reason,
+		"error":     m.Error,
+		"body":      strings.ToValidUTF8(m.Body, ""),
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `
+		INSERT INTO quarantined_messages (device_id, tenant, reason, error, body)
+		VALUES (@device_id, @tenant, @reason, @error, @body)`, args)
+	if err != nil {
+		log.Error("could not insert quarantined message", "device_id", m.DeviceID, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) QuarantinedMessages(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantinedMessage], error) {
+	where, args := quarantineWhere(query)
+	args["after_id"] = query.AfterID
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args["limit"] = limit
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.QuarantinedMessage]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT id, device_id, tenant, reason, COALESCE(error, ''), body, attempts, received_at
+		FROM quarantined_messages
+		`+where+` AND id > @after_id
+		ORDER BY id ASC
+		LIMIT @limit`, args)
+	if err != nil {
+		log.Error("could not query quarantined messages", "err", err.Error())
+		return types.Collection[types.QuarantinedMessage]{}, err
+	}
+	defer rows.Close()
+
+	messages := []types.QuarantinedMessage{}
+
+	for rows.Next() {
+		var m types.QuarantinedMessage
+
+		err := rows.Scan(&m.ID, &m.DeviceID, &m.Tenant, &m.Reason, &m.Error, &m.Body, &m.Attempts, &m.ReceivedAt)
+		if err != nil {
+			log.Error("could not scan row", "err", err.Error())
+			return types.Collection[types.QuarantinedMessage]{}, err
+		}
+
+		m.ReceivedAt = m.ReceivedAt.UTC()
+		messages = append(messages, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.QuarantinedMessage]{}, err
+	}
+
+	return types.Collection[types.QuarantinedMessage]{
+		Data:       messages,
+		Count:      uint64(len(messages)),
+		Offset:     0,
+		Limit:      uint64(limit),
+		TotalCount: uint64(len(messages)),
+	}, nil
+}
+
+func (s *Storage) QuarantineGroups(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantineGroup], error) {
+	where, args := quarantineWhere(query)
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.QuarantineGroup]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT device_id, tenant, reason, count(*), MIN(received_at), MAX(received_at)
+		FROM quarantined_messages
+		`+where+`
+		GROUP BY device_id, tenant, reason
+		ORDER BY MAX(received_at) DESC`, args)
+	if err != nil {
+		log.Error("could not query quarantine groups", "err", err.Error())
+		return types.Collection[types.QuarantineGroup]{}, err
+	}
+	defer rows.Close()
+
+	groups := []types.QuarantineGroup{}
+
+	for rows.Next() {
+		var g types.QuarantineGroup
+		var first, last time.Time
+
+		err := rows.Scan(&g.DeviceID, &g.Tenant, &g.Reason, &g.Count, &first, &last)
+		if err != nil {
+			log.Error("could not scan row", "err", err.Error())
+			return types.Collection[types.QuarantineGroup]{}, err
+		}
+
+		g.FirstReceived = first.UTC()
+		g.LastReceived = last.UTC()
+		groups = append(groups, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.QuarantineGroup]{}, err
+	}
+
+	return types.Collection[types.QuarantineGroup]{
+		Data:       groups,
+		Count:      uint64(len(groups)),
+		Offset:     0,
+		Limit:      uint64(len(groups)),
+		TotalCount: uint64(len(groups)),
+	}, nil
+}
+
+func (s *Storage) UpdateQuarantinedMessage(ctx context.Context, id int64, reason, errorText string) error {
+	args := pgx.NamedArgs{
+		"id":     id,
+		"reason": reason,
+		"error":  errorText,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `
+		UPDATE quarantined_messages
+		SET reason=@reason, error=@error, attempts=attempts+1, modified_on=NOW()
+		WHERE id=@id`, args)
+	if err != nil {
+		log.Error("could not update quarantined message", "id", id, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteQuarantinedMessage(ctx context.Context, id int64) error {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `DELETE FROM quarantined_messages WHERE id=@id`, pgx.NamedArgs{"id": id})
+	if err != nil {
+		log.Error("could not delete quarantined message", "id", id, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// quarantineWhere filters quarantined messages. Messages that could not be read have no
+// tenant and are only included if the query asks for them.
+func quarantineWhere(query quarantinequery.Messages) (string, pgx.NamedArgs) {
+	where := []string{"tenant = ANY(@tenants)"}
+	if query.IncludeUntenanted {
+		where[0] = "(tenant = '' OR tenant = ANY(@tenants))"
+	}
+
+	args := pgx.NamedArgs{
+		"tenants": query.AllowedTenants,
+	}
+
+	if query.DeviceID != "" {
+		where = append(where, "device_id = @device_id")
+		args["device_id"] = query.DeviceID
+	}
+
+	if query.Tenant != "" {
+		where = append(where, "tenant = @tenant")
+		args["tenant"] = query.Tenant
+	}
+
+	if query.Reason != "" {
+		where = append(where, "reason = @reason")
+		args["reason"] = query.Reason
+	}
+
+	return "WHERE " + strings.Join(where, " AND "), args
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/retention"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+const rollupColumns = `samples, battery_min, battery_max, battery_avg, rssi_min, rssi_max, rssi_avg, snr_min, snr_max, snr_avg, sf_min, sf_max, sf_avg, dr_min, dr_max, dr_avg`
+
+const rollupAggregates = `count(*), min(battery_level), max(battery_level), avg(battery_level), min(rssi), max(rssi), avg(rssi), min(snr), max(snr), avg(snr), min(sf), max(sf), avg(sf), min(dr), max(dr), avg(dr)`
+
+const rollupUpdate = `
+	samples = EXCLUDED.samples,
+	battery_min = EXCLUDED.battery_min,
+	battery_max = EXCLUDED.battery_max,
+	battery_avg = EXCLUDED.battery_avg,
+	rssi_min = EXCLUDED.rssi_min,
+	rssi_max = EXCLUDED.rssi_max,
+	rssi_avg = EXCLUDED.rssi_avg,
+	snr_min = EXCLUDED.snr_min,
+	snr_max = EXCLUDED.snr_max,
+	snr_avg = EXCLUDED.snr_avg,
+	sf_min = EXCLUDED.sf_min,
+	sf_max = EXCLUDED.sf_max,
+	sf_avg = EXCLUDED.sf_avg,
+	dr_min = EXCLUDED.dr_min,
+	dr_max = EXCLUDED.dr_max,
+	dr_avg = EXCLUDED.dr_avg`
+
+// RollupSensorStatus aggregates raw status rows into hourly and daily buckets. Completed
+// buckets since shortly before the latest rollup are aggregated again, so that late
+// messages are included. A bucket is only replaced by one with at least as many samples,
+// so buckets whose raw rows have partly expired keep their complete aggregates.
+func (s *Storage) RollupSensorStatus(ctx context.Context) error {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO sensor_status_hourly (bucket, sensor_id, %[1]s)
+		SELECT date_trunc('hour', observed_at), sensor_id, %[2]s
+		FROM sensor_status
+		WHERE observed_at >= COALESCE((SELECT max(bucket) FROM sensor_status_hourly) - INTERVAL '1 day', '-infinity'::timestamptz)
+			AND observed_at < date_trunc('hour', NOW())
+		GROUP BY 1, 2
+		ON CONFLICT (bucket, sensor_id) DO UPDATE
+			SET %[3]s
+			WHERE sensor_status_hourly.samples <= EXCLUDED.samples;`, rollupColumns, rollupAggregates, rollupUpdate))
+	if err != nil {
+		log.Error("could not roll up hourly sensor status", "err", err.Error())
+		return err
+	}
+
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		INSERT INTO sensor_status_daily (bucket, sensor_id, %[1]s)
+		SELECT date_trunc('day', observed_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', sensor_id, %[2]s
+		FROM sensor_status
+		WHERE observed_at >= COALESCE((SELECT max(bucket) FROM sensor_status_daily) - INTERVAL '2 days', '-infinity'::timestamptz)
+			AND observed_at < date_trunc('day', NOW() AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'
+		GROUP BY 1, 2
+		ON CONFLICT (bucket, sensor_id) DO UPDATE
+			SET %[3]s
+			WHERE sensor_status_daily.samples <= EXCLUDED.samples;`, rollupColumns, rollupAggregates, rollupUpdate))
+	if err != nil {
+		log.Error("could not roll up daily sensor status", "err", err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteExpiredSensorStatus deletes raw rows and rollups that are older than the
+// retention of the most specific policy matching each sensor.
+func (s *Storage) DeleteExpiredSensorStatus(ctx context.Context, policies []retention.Policy) error {
+	if len(policies) == 0 {
+		return nil
+	}
+
+	var tenants, profiles []string
+	var raw, hourly, daily, rank []int32
+
+	for _, p := range policies {
+		tenants = append(tenants, p.Tenant)
+		profiles = append(profiles, p.Profile)
+		raw = append(raw, int32(p.Raw))
+		hourly = append(hourly, int32(p.Hourly))
+		daily = append(daily, int32(p.Daily))
+		rank = append(rank, int32(p.Rank()))
+	}
+
+	args := pgx.NamedArgs{
+		"tenants":  tenants,
+		"profiles": profiles,
+		"raw":      raw,
+		"hourly":   hourly,
+		"daily":    daily,
+		"rank":     rank,
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	tx, err := c.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		CREATE TEMPORARY TABLE sensor_retention ON COMMIT DROP AS
+		SELECT DISTINCT ON (s.sensor_id) s.sensor_id, p.raw, p.hourly, p.daily
+		FROM sensors s
+		LEFT JOIN devices d ON d.sensor_id = s.sensor_id AND d.deleted = FALSE
+		JOIN unnest(@tenants::text[], @profiles::text[], @raw::int[], @hourly::int[], @daily::int[], @rank::int[])
+			AS p(tenant, profile, raw, hourly, daily, rank)
+			ON (p.tenant = '' OR p.tenant = COALESCE(d.tenant, s.tenant))
+			AND (p.profile = '' OR p.profile = s.sensor_profile)
+		ORDER BY s.sensor_id, p.rank DESC;`, args)
+	if err != nil {
+		log.Error("could not resolve sensor retention", "err", err.Error())
+		return err
+	}
+
+	expired := []struct {
+		table, timeColumn, days string
+	}{
+		{"sensor_status", "observed_at", "raw"},
+		{"sensor_status_hourly", "bucket", "hourly"},
+		{"sensor_status_daily", "bucket", "daily"},
+	}
+
+	for _, e := range expired {
+		result, err := tx.Exec(ctx, fmt.Sprintf(`
+			DELETE FROM %[1]s t
+			USING sensor_retention r
+			WHERE t.sensor_id = r.sensor_id
+				AND t.%[2]s < NOW() - make_interval(days => r.%[3]s);`, e.table, e.timeColumn, e.days))
+		if err != nil {
+			log.Error("could not delete expired sensor status", "table", e.table, "err", err.Error())
+			return err
+		}
+
+		if result.RowsAffected() > 0 {
+			log.Debug("deleted expired sensor status", "table", e.table, "count", result.RowsAffected())
+		}
+	}
+
+	return tx.Commit(ctx)
+}
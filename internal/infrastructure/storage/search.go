@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"html"
+	"strings"
+	"unicode"
+
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// deviceSearchDocument is the text of a device that is searched, its ids, name, description
+// and environment followed by its sensor profile, tags and metadata values. It is kept up to
+// date by triggers and indexed for text search and similarity, see migrate.sql.
+const deviceSearchDocument = "d.search_document"
+
+// sensorSearchDocument is the text of a sensor that is searched.
+const sensorSearchDocument = "s.search_document"
+
+// alarmSearchDocument is the text of an alarm that is searched, including the device it is raised for.
+const alarmSearchDocument = "a.search_document"
+
+// highlight marks the matching words of a snippet before it is escaped.
+const highlightStart, highlightStop = "«", "»"
+
+// prefixQuery returns a text search query that matches documents with words starting with
+// each of the words in the term, or an empty string if the term has no words.
+func prefixQuery(term string) string {
+	words := strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+// searchArgs sets the arguments of searchMatch and searchRank. Unless the term has wildcards
+// of its own, the columns match if they contain the term.
+func searchArgs(args pgx.NamedArgs, term string) {
+	term = strings.TrimSpace(term)
+
+	pattern := term
+	if !strings.Contains(pattern, "%") {
+		pattern = "%" + pattern + "%"
+	}
+
+	args["search"] = pattern
+	args["search_text"] = term
+	args["search_query"] = prefixQuery(term)
+}
+
+// searchMatch matches rows whose document contains the term, has words starting with the
+// words of the term, or, with trigram, has words similar to the term to allow for typos.
+// Each of them can use an index of the document.
+func searchMatch(term, document string, trigram bool) string {
+	or := []string{document + " ILIKE @search"}
+
+	if prefixQuery(term) != "" {
+		or = append(or, "to_tsvector('simple', "+document+") @@ to_tsquery('simple', @search_query)")
+	}
+
+	if trigram {
+		or = append(or, "@search_text <% "+document)
+	}
+
+	return "(" + strings.Join(or, " OR ") + ")"
+}
+
+// searchRank is the relevance of a document, the text search rank plus, with trigram, the
+// similarity of the closest words to the term.
+func searchRank(term, document string, trigram bool) string {
+	var rank []string
+
+	if prefixQuery(term) != "" {
+		rank = append(rank, "ts_rank(to_tsvector('simple', "+document+"), to_tsquery('simple', @search_query))")
+	}
+
+	if trigram {
+		rank = append(rank, "word_similarity(@search_text, "+document+")")
+	}
+
+	if len(rank) == 0 {
+		return "0::real"
+	}
+
+	return strings.Join(rank, " + ")
+}
+
+// searchSnippet is an excerpt of a document with the words that match the term between
+// highlightStart and highlightStop.
+func searchSnippet(term, document string) string {
+	if prefixQuery(term) == "" {
+		return "left(" + document + ", 200)"
+	}
+
+	return "ts_headline('simple', " + document + ", to_tsquery('simple', @search_query), 'StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MinWords=5, MaxWords=20')"
+}
+
+// highlightSnippet escapes a snippet as HTML, with the matching words in mark elements.
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, highlightStart, "<mark>")
+	return strings.ReplaceAll(snippet, highlightStop, "</mark>")
+}
+
+// searchSQL selects the devices, sensors and alarms in the allowed tenants that match the
+// text, most relevant first.
+func searchSQL(query searchquery.Search, trigram bool) (string, pgx.NamedArgs) {
+	args := pgx.NamedArgs{
+		"tenants": query.AllowedTenants,
+		"limit":   20,
+	}
+	if query.Limit != nil {
+		args["limit"] = min(max(*query.Limit, 1), 100)
+	}
+	searchArgs(args, query.Text)
+
+	text := query.Text
+
+	sql := `
+		SELECT kind, id, name, tenant, snippet, rank, count(*) OVER () AS count
+		FROM (
+			SELECT '` + types.SearchKindDevice + `' AS kind, d.device_id AS id, COALESCE(d.name, '') AS name, d.tenant,
+				` + searchSnippet(text, deviceSearchDocument) + ` AS snippet,
+				` + searchRank(text, deviceSearchDocument, trigram) + ` AS rank
+			FROM devices d
+			WHERE d.deleted = FALSE AND d.tenant = ANY(@tenants)
+				AND ` + searchMatch(text, deviceSearchDocument, trigram) + `
+
+			UNION ALL
+
+			SELECT '` + types.SearchKindSensor + `', s.sensor_id, COALESCE(s.name, ''), COALESCE(s.tenant, ''),
+				` + searchSnippet(text, sensorSearchDocument) + `,
+				` + searchRank(text, sensorSearchDocument, trigram) + `
+			FROM sensors s
+			WHERE (s.tenant IS NULL OR s.tenant = ANY(@tenants))
+				AND ` + searchMatch(text, sensorSearchDocument, trigram) + `
+
+			UNION ALL
+
+			SELECT '` + types.SearchKindAlarm + `', a.device_id, a.type, d.tenant,
+				` + searchSnippet(text, alarmSearchDocument) + `,
+				` + searchRank(text, alarmSearchDocument, trigram) + `
+			FROM device_alarms a
+			JOIN devices d ON d.device_id = a.device_id
+			WHERE d.deleted = FALSE AND d.tenant = ANY(@tenants)
+				AND ` + searchMatch(text, alarmSearchDocument, trigram) + `
+		) results
+		ORDER BY rank DESC, kind ASC, id ASC
+		LIMIT @limit`
+
+	return sql, args
+}
+
+func (s *Storage) Search(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error) {
+	log := logging.GetFromContext(ctx)
+
+	sql, args := searchSQL(query, s.trigram)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.SearchResult]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, sql, args)
+	if err != nil {
+		log.Error("could not search", "err", err.Error())
+		return types.Collection[types.SearchResult]{}, err
+	}
+	defer rows.Close()
+
+	results := []types.SearchResult{}
+	var count uint64
+
+	for rows.Next() {
+		var r types.SearchResult
+
+		err := rows.Scan(&r.Kind, &r.ID, &r.Name, &r.Tenant, &r.Snippet, &r.Rank, &count)
+		if err != nil {
+			log.Error("could not scan search result", "err", err.Error())
+			return types.Collection[types.SearchResult]{}, err
+		}
+
+		r.Snippet = highlightSnippet(r.Snippet)
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.SearchResult]{}, err
+	}
+
+	return types.Collection[types.SearchResult]{
+		Data:       results,
+		Count:      uint64(len(results)),
+		Limit:      uint64(args["limit"].(int)),
+		TotalCount: count,
+	}, nil
+}
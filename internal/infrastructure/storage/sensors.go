@@ -16,8 +16,23 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// sensorFrom joins the tables that the columns and conditions of a sensor query refer to.
+const sensorFrom = `FROM sensors s
+		LEFT JOIN devices d ON d.sensor_id = s.sensor_id AND d.deleted = FALSE
+		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile`
+
+func sensorWhere(where []string) string {
+	if len(where) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(where, " AND ")
+}
+
 func (s *Storage) QuerySensors(ctx context.Context, query sensorquery.Sensors) (types.Collection[types.Sensor], error) {
 	condition := &Condition{Offset: query.Offset, Limit: query.Limit}
+	if query.Cursor != nil {
+		condition.Offset = nil
+	}
 	offsetLimit, offset, limit := OffsetLimit(condition, 0, 10)
 
 	args := pgx.NamedArgs{}
@@ -55,15 +70,53 @@ func (s *Storage) QuerySensors(ctx context.Context, query sensorquery.Sensors) (
 				AND LOWER(sppt.sensor_profile_type_id) = ANY(@profile_types)
 		)`)
 	}
-	if search := strings.TrimSpace(query.Search); search != "" {
-		args["search"] = "%" + search + "%"
-		where = append(where, "(s.sensor_id ILIKE @search OR s.name ILIKE @search)")
+	search := strings.TrimSpace(query.Search)
+	if search != "" {
+		searchArgs(args, search)
+		where = append(where, searchMatch(search, sensorSearchDocument, s.trigram))
+	}
+	if query.Discovered != nil {
+		if *query.Discovered {
+			where = append(where, "s.discovered = TRUE AND d.device_id IS NULL")
+		} else {
+			where = append(where, "(s.discovered = FALSE OR d.device_id IS NOT NULL)")
+		}
+	}
+	if len(query.AllowedTenants) > 0 {
+		args["tenants"] = query.AllowedTenants
+		where = append(where, "(s.tenant IS NULL OR s.tenant = ANY(@tenants))")
+	}
+	if len(query.Within) > 0 {
+		where = append(where, withinWhere("s.location", "within", query.Within))
+	}
+	if query.Near != nil && query.Radius > 0 {
+		where = append(where, distance("s.location")+" <= @radius")
 	}
+	geoArgs(args, query.Within, query.Near, query.Radius)
 
-	whereClause := ""
-	if len(where) > 0 {
-		whereClause = "WHERE " + strings.Join(where, " AND ")
+	orderBy := "ORDER BY s.sensor_id ASC"
+	if search != "" {
+		orderBy = "ORDER BY " + searchRank(search, sensorSearchDocument, s.trigram) + " DESC, s.sensor_id ASC"
 	}
+	if query.Near != nil {
+		orderBy = "ORDER BY " + distance("s.location") + " ASC NULLS LAST, s.sensor_id ASC"
+	}
+
+	// count is the number of sensors from the cursor on that decides if there is a
+	// next page, while the total is counted without the cursor predicate.
+	total := "count(*) OVER ()"
+
+	ks := sensorKeyset(query)
+	if query.Cursor != nil {
+		if after := ks.after(*query.Cursor); after != "" {
+			total = fmt.Sprintf("(SELECT count(*) %s %s)", sensorFrom, sensorWhere(where))
+			where = append(where, after)
+		}
+		cursorArgs(args, query.Cursor)
+		orderBy = ks.orderBy()
+	}
+
+	whereClause := sensorWhere(where)
 
 	log := logging.GetFromContext(ctx)
 
@@ -76,20 +129,14 @@ func (s *Storage) QuerySensors(ctx context.Context, query sensorquery.Sensors) (
 
 	rows, err := c.Query(ctx, fmt.Sprintf(`
 		SELECT
-			s.sensor_id,
-			d.device_id,
-			s.name,
-			s.location,
-			sp.name,
-			sp.decoder,
-			sp.interval,
-			count(*) OVER () AS count
-		FROM sensors s
-		LEFT JOIN devices d ON d.sensor_id = s.sensor_id AND d.deleted = FALSE
-		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+			%s,
+			%s,
+			count(*) OVER () AS count,
+			%s AS total_count
+		%s
+		%s
 		%s
-		ORDER BY s.sensor_id ASC
-		%s`, whereClause, offsetLimit), args)
+		%s`, sensorColumns, ks.column(), total, sensorFrom, whereClause, orderBy, offsetLimit), args)
 	if err != nil {
 		log.Error("failed to query sensors", "args", args, "err", err.Error())
 		return types.Collection[types.Sensor]{}, err
@@ -97,34 +144,36 @@ func (s *Storage) QuerySensors(ctx context.Context, query sensorquery.Sensors) (
 	defer rows.Close()
 
 	items := []types.Sensor{}
-	var count uint64
+	var count, totalCount uint64
+	var cursorKey string
 	for rows.Next() {
-		var sensorID string
-		var deviceID *string
-		var name *string
-		var location pgtype.Point
-		var profileName, decoder *string
-		var interval *int
-
-		err = rows.Scan(&sensorID, &deviceID, &name, &location, &profileName, &decoder, &interval, &count)
+		var row sensorRow
+
+		err = rows.Scan(append(row.dest(), &cursorKey, &count, &totalCount)...)
 		if err != nil {
 			log.Error("failed to scan sensor row", "err", err.Error())
 			return types.Collection[types.Sensor]{}, err
 		}
 
-		items = append(items, sensorFromRow(sensorID, deviceID, name, location, profileName, decoder, interval))
+		items = append(items, row.sensor())
 	}
 
 	if err = rows.Err(); err != nil {
 		return types.Collection[types.Sensor]{}, err
 	}
 
+	var next *types.Cursor
+	if query.Cursor != nil && len(items) > 0 {
+		next = nextCursor(cursorKey, items[len(items)-1].SensorID, count, len(items))
+	}
+
 	return types.Collection[types.Sensor]{
 		Data:       items,
 		Count:      uint64(len(items)),
 		Offset:     uint64(offset),
 		Limit:      uint64(limit),
-		TotalCount: count,
+		TotalCount: totalCount,
+		Cursor:     next,
 	}, nil
 }
 
@@ -142,47 +191,14 @@ func (s *Storage) GetSensor(ctx context.Context, sensorID string) (types.Sensor,
 	}
 	defer c.Release()
 
-	var profileName, decoder *string
-	var deviceID *string
-	var name *string
-	var location pgtype.Point
-	var interval *int
-	var batteryLevel *int
-	var rssi, snr, sf *float64
-	var fq *int64
-	var dr *int
-	var statusObservedAt *time.Time
-
-	err = c.QueryRow(ctx, `
-		WITH latest_status AS (
-			SELECT DISTINCT ON (sensor_id)
-				sensor_id, battery_level, rssi, snr, fq, sf, dr, observed_at
-			FROM sensor_status
-			ORDER BY sensor_id, observed_at DESC
-		)
-
-		SELECT
-			s.sensor_id,
-			d.device_id,
-			s.name,
-			s.location,
-			sp.name,
-			sp.decoder,
-			sp.interval,
-
-			ls.battery_level,
-			ls.rssi,
-			ls.snr,
-			ls.fq,
-			ls.sf,
-			ls.dr,
-			ls.observed_at  AS status_observed_at
+	var row sensorRow
 
+	err = c.QueryRow(ctx, fmt.Sprintf(`
+		SELECT %s
 		FROM sensors s
 		LEFT JOIN devices d ON d.sensor_id = s.sensor_id AND d.deleted = FALSE
 		LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
-		LEFT JOIN latest_status ls ON ls.sensor_id = s.sensor_id
-		WHERE s.sensor_id = @sensor_id`, pgx.NamedArgs{"sensor_id": sensorID}).Scan(&sensorID, &deviceID, &name, &location, &profileName, &decoder, &interval, &batteryLevel, &rssi, &snr, &fq, &sf, &dr, &statusObservedAt)
+		WHERE s.sensor_id = @sensor_id`, sensorColumns), pgx.NamedArgs{"sensor_id": sensorID}).Scan(row.dest()...)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return types.Sensor{}, false, nil
@@ -191,24 +207,7 @@ func (s *Storage) GetSensor(ctx context.Context, sensorID string) (types.Sensor,
 		return types.Sensor{}, false, err
 	}
 
-	sens := sensorFromRow(sensorID, deviceID, name, location, profileName, decoder, interval)
-
-	if statusObservedAt != nil {
-		sens.SensorStatus = &types.SensorStatus{
-			RSSI:            rssi,
-			LoRaSNR:         snr,
-			Frequency:       fq,
-			SpreadingFactor: sf,
-			DR:              dr,
-			ObservedAt:      statusObservedAt.UTC(),
-		}
-		if batteryLevel != nil {
-			bat := *batteryLevel
-			sens.SensorStatus.BatteryLevel = int(bat)
-		}
-	}
-
-	return sens, true, nil
+	return row.sensor(), true, nil
 }
 
 func (s *Storage) CreateSensor(ctx context.Context, sensor types.Sensor) error {
@@ -401,6 +400,75 @@ func normalizeSensorProfileTypes(values []string) []string {
 	return normalized
 }
 
+const sensorColumns string = `
+			s.sensor_id,
+			d.device_id,
+			s.name,
+			s.location,
+			sp.name,
+			sp.decoder,
+			sp.interval,
+			s.tenant,
+			s.discovered,
+			s.first_seen,
+
+			s.battery_level,
+			s.rssi,
+			s.snr,
+			s.fq,
+			s.sf,
+			s.dr,
+			s.observed_at   AS status_observed_at`
+
+type sensorRow struct {
+	sensorID             string
+	deviceID, name       *string
+	location             pgtype.Point
+	profileName, decoder *string
+	interval             *int
+	tenant               *string
+	discovered           bool
+	firstSeen            *time.Time
+	rssi, snr, sf        *float64
+	batteryLevel         *float64
+	fq                   *int64
+	dr                   *int
+	statusObservedAt     *time.Time
+}
+
+func (r *sensorRow) dest() []any {
+	return []any{&r.sensorID, &r.deviceID, &r.name, &r.location, &r.profileName, &r.decoder, &r.interval, &r.tenant, &r.discovered, &r.firstSeen, &r.batteryLevel, &r.rssi, &r.snr, &r.fq, &r.sf, &r.dr, &r.statusObservedAt}
+}
+
+func (r *sensorRow) sensor() types.Sensor {
+	sens := sensorFromRow(r.sensorID, r.deviceID, r.name, r.location, r.profileName, r.decoder, r.interval)
+	sens.Tenant = r.tenant
+	sens.Discovered = r.discovered
+
+	if r.firstSeen != nil {
+		firstSeen := r.firstSeen.UTC()
+		sens.FirstSeen = &firstSeen
+	}
+
+	if r.statusObservedAt != nil {
+		lastSeen := r.statusObservedAt.UTC()
+		sens.LastSeen = &lastSeen
+		sens.SensorStatus = &types.SensorStatus{
+			RSSI:            r.rssi,
+			LoRaSNR:         r.snr,
+			Frequency:       r.fq,
+			SpreadingFactor: r.sf,
+			DR:              r.dr,
+			ObservedAt:      lastSeen,
+		}
+		if r.batteryLevel != nil {
+			sens.SensorStatus.BatteryLevel = int(*r.batteryLevel)
+		}
+	}
+
+	return sens
+}
+
 func sensorFromRow(sensorID string, deviceID, name *string, location pgtype.Point, profileName, decoder *string, interval *int) types.Sensor {
 	sensor := types.Sensor{SensorID: sensorID, DeviceID: deviceID, Name: name}
 	if location.Valid {
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+)
+
+// facetColumns are the values, as text, by which devices are counted for each facet. Alarms
+// are counted separately as a device can have several.
+var facetColumns = map[string]string{
+	dmquery.FacetTenant:      "d.tenant",
+	dmquery.FacetProfile:     "COALESCE(sp.name, '')",
+	dmquery.FacetEnvironment: "COALESCE(d.environment, '')",
+	dmquery.FacetOnline:      "COALESCE(dst.online, FALSE)::text",
+	dmquery.FacetState:       "COALESCE(dst.state, -1)::int::text",
+	dmquery.FacetActive:      "d.active::text",
+}
+
+// GetDeviceStats counts the devices that match the filters, in total and by the values of
+// each of the facets. Paging and sorting of the query are ignored.
+func (s *Storage) GetDeviceStats(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error) {
+	query.Cursor = nil
+	query.Offset = nil
+	query.Limit = nil
+	query.SortBy = ""
+
+	condition := deviceConditionFromQuery(query)
+	condition.Trigram = s.trigram
+	args := NamedArgs(condition)
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.DeviceStats{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, deviceStatsQuery(condition, query.Facets), args)
+	if err != nil {
+		log.Error("could not query device stats", "err", err.Error())
+		return types.DeviceStats{}, err
+	}
+	defer rows.Close()
+
+	stats := types.DeviceStats{Facets: map[string][]types.Facet{}}
+	for _, facet := range query.Facets {
+		stats.Facets[facet] = []types.Facet{}
+	}
+
+	for rows.Next() {
+		var facet string
+		var value *string
+		var count uint64
+
+		err := rows.Scan(&facet, &value, &count)
+		if err != nil {
+			log.Error("could not scan device stats", "err", err.Error())
+			return types.DeviceStats{}, err
+		}
+
+		if value == nil {
+			stats.Total = count
+			continue
+		}
+
+		stats.Facets[facet] = append(stats.Facets[facet], types.Facet{Value: *value, Count: count})
+	}
+
+	return stats, rows.Err()
+}
+
+// deviceStatsQuery selects the total as a row without a value, followed by a row with the count
+// of each value of each facet, most common values first.
+func deviceStatsQuery(condition *Condition, facets []string) string {
+	columns := []string{"d.device_id"}
+	counts := []string{"SELECT 'total', NULL, count(*) FROM matched"}
+
+	for _, facet := range facets {
+		if facet == dmquery.FacetAlarm {
+			counts = append(counts, fmt.Sprintf(`SELECT '%s', a.type, count(DISTINCT m.device_id) FROM matched m JOIN device_alarms a ON a.device_id = m.device_id GROUP BY 2`, facet))
+			continue
+		}
+
+		column, ok := facetColumns[facet]
+		if !ok {
+			continue
+		}
+
+		columns = append(columns, fmt.Sprintf("%s AS facet_%s", column, facet))
+		counts = append(counts, fmt.Sprintf("SELECT '%[1]s', facet_%[1]s, count(*) FROM matched GROUP BY 2", facet))
+	}
+
+	return fmt.Sprintf(`
+		WITH matched AS (
+			SELECT %s
+			FROM devices d
+			LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
+			LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
+			LEFT JOIN device_state dst ON dst.device_id = d.device_id
+			LEFT JOIN sensor_battery_forecast bf ON bf.sensor_id = d.sensor_id
+			LEFT JOIN sensor_link_quality lq ON lq.sensor_id = d.sensor_id
+			%s
+		)
+		%s
+		ORDER BY 1, 3 DESC, 2`, strings.Join(columns, ", "), DeviceWhere(condition), strings.Join(counts, "\n\t\tUNION ALL\n\t\t"))
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -24,6 +25,8 @@ var migrateSQL string
 type Storage struct {
 	conn *pgxpool.Pool
 	mu   sync.Mutex
+	// trigram is set if the pg_trgm extension is available to search by similarity
+	trigram bool
 }
 
 func New(ctx context.Context, config Config) (*Storage, error) {
@@ -72,6 +75,15 @@ func initialize(ctx context.Context, s *Storage) error {
 		return err
 	}
 
+	err = s.conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')`).Scan(&s.trigram)
+	if err != nil {
+		return err
+	}
+
+	if !s.trigram {
+		logging.GetFromContext(ctx).Warn("pg_trgm extension is not available, search by similarity is disabled")
+	}
+
 	return nil
 }
 
@@ -3,14 +3,18 @@ package storage
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/retention"
 	sensorquery "github.com/diwise/iot-device-mgmt/internal/application/sensors/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 func testSetup(t *testing.T) (context.Context, *Storage) {
@@ -249,6 +253,25 @@ func TestStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("page sensors by cursor keeps the total", func(t *testing.T) {
+		limit := 1
+		first, err := s.QuerySensors(ctx, sensorquery.Sensors{Limit: &limit, Cursor: &types.Cursor{}})
+		if err != nil {
+			t.Fatalf("failed to query sensors: %v", err)
+		}
+		if first.TotalCount < 2 || first.Cursor == nil {
+			t.Fatalf("expected more than one page of sensors, got %+v", first)
+		}
+
+		second, err := s.QuerySensors(ctx, sensorquery.Sensors{Limit: &limit, Cursor: first.Cursor})
+		if err != nil {
+			t.Fatalf("failed to query sensors: %v", err)
+		}
+		if second.TotalCount != first.TotalCount {
+			t.Fatalf("expected total %d on every page, got %d", first.TotalCount, second.TotalCount)
+		}
+	})
+
 	t.Run("query unassigned sensors with profile", func(t *testing.T) {
 		limit := 1000
 		assigned := false
@@ -381,6 +404,51 @@ func TestStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("repeated alarm with the same observed time", func(t *testing.T) {
+		observedAt := time.Now().UTC().Truncate(time.Millisecond)
+
+		err := s.Add(ctx, deviceID, types.AlarmDetails{AlarmType: "leak", Description: "first", ObservedAt: observedAt, Severity: 3})
+		if err != nil {
+			t.Fatalf("failed to add alarm: %v", err)
+		}
+
+		err = s.Add(ctx, deviceID, types.AlarmDetails{AlarmType: "leak", Description: "repeat", ObservedAt: observedAt, Severity: 3})
+		if !errors.Is(err, alarms.ErrAlarmNotNewer) {
+			t.Fatalf("expected repeated alarm to be rejected, got %v", err)
+		}
+
+		result, err := s.GetDeviceAlarms(ctx, deviceID)
+		if err != nil {
+			t.Fatalf("failed to get device alarms: %v", err)
+		}
+		for _, a := range result.Data {
+			if a.AlarmType == "leak" && a.Description != "first" {
+				t.Fatalf("expected repeated alarm not to be stored, got %+v", a)
+			}
+		}
+
+		err = s.Remove(ctx, deviceID, "leak")
+		if err != nil {
+			t.Fatalf("failed to remove alarm: %v", err)
+		}
+	})
+
+	t.Run("query with included alarms", func(t *testing.T) {
+		devices, err := s.Query(ctx, dmquery.DeviceFilters{
+			Filters: dmquery.Filters{SensorID: sensorID},
+			Include: []string{dmquery.IncludeAlarms},
+		})
+		if err != nil {
+			t.Fatalf("failed to query devices: %v", err)
+		}
+		if len(devices.Data) != 1 {
+			t.Fatalf("expected 1 device, got %d", len(devices.Data))
+		}
+		if len(devices.Data[0].AlarmDetails) != 1 || devices.Data[0].AlarmDetails[0].AlarmType != "battery_low" {
+			t.Fatalf("expected included battery_low alarm, got %+v", devices.Data[0].AlarmDetails)
+		}
+	})
+
 	t.Run("add device status for missing device", func(t *testing.T) {
 		err := s.AddDeviceStatus(ctx, types.StatusMessage{
 			DeviceID:  "missing-device-id",
@@ -391,6 +459,315 @@ func TestStorage(t *testing.T) {
 		}
 	})
 
+	t.Run("discover sensor from status message", func(t *testing.T) {
+		err := s.CreateSensorProfile(ctx, types.SensorProfile{Name: "unknown", Decoder: "unknown"})
+		if err != nil {
+			t.Fatalf("failed to create unknown sensor profile: %v", err)
+		}
+
+		discoveredID := "discovered-sensor-" + uuid.NewString()
+		firstSeen := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		rssi := -97.0
+
+		err = s.DiscoverSensor(ctx, types.StatusMessage{DeviceID: discoveredID, Tenant: "default", Timestamp: firstSeen})
+		if err != nil {
+			t.Fatalf("failed to discover sensor: %v", err)
+		}
+		err = s.DiscoverSensor(ctx, types.StatusMessage{DeviceID: discoveredID, Tenant: "default", RSSI: &rssi, Timestamp: firstSeen.Add(time.Minute)})
+		if err != nil {
+			t.Fatalf("failed to update discovered sensor: %v", err)
+		}
+
+		sensor, found, err := s.GetSensor(ctx, discoveredID)
+		if err != nil || !found {
+			t.Fatalf("failed to get discovered sensor: %v", err)
+		}
+		if !sensor.Discovered || sensor.Tenant == nil || *sensor.Tenant != "default" {
+			t.Fatalf("expected discovered sensor for tenant default, got %+v", sensor)
+		}
+		if sensor.FirstSeen == nil || !sensor.FirstSeen.Equal(firstSeen) || sensor.LastSeen == nil || !sensor.LastSeen.Equal(firstSeen.Add(time.Minute)) {
+			t.Fatalf("expected first and last seen to be tracked, got %v and %v", sensor.FirstSeen, sensor.LastSeen)
+		}
+		if sensor.SensorStatus == nil || sensor.SensorStatus.RSSI == nil || *sensor.SensorStatus.RSSI != rssi {
+			t.Fatalf("expected signal quality on discovered sensor, got %+v", sensor.SensorStatus)
+		}
+
+		discovered := true
+		result, err := s.QuerySensors(ctx, sensorquery.Sensors{Discovered: &discovered, AllowedTenants: []string{"default"}})
+		if err != nil {
+			t.Fatalf("failed to query discovered sensors: %v", err)
+		}
+		if !slices.ContainsFunc(result.Data, func(s types.Sensor) bool { return s.SensorID == discoveredID }) {
+			t.Fatalf("expected %q among discovered sensors", discoveredID)
+		}
+	})
+
+	t.Run("add device status keeps latest status on sensor", func(t *testing.T) {
+		observedAt := time.Now().UTC().Add(-2 * time.Hour).Truncate(time.Second)
+		bat := 87.0
+
+		err := s.AddDeviceStatus(ctx, types.StatusMessage{
+			DeviceID:     deviceID,
+			BatteryLevel: &bat,
+			Timestamp:    observedAt,
+		})
+		if err != nil {
+			t.Fatalf("failed to add device status: %v", err)
+		}
+
+		older := 12.0
+		err = s.AddDeviceStatus(ctx, types.StatusMessage{
+			DeviceID:     deviceID,
+			BatteryLevel: &older,
+			Timestamp:    observedAt.Add(-time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("failed to add older device status: %v", err)
+		}
+
+		sensor, found, err := s.GetSensor(ctx, sensorID)
+		if err != nil || !found {
+			t.Fatalf("failed to get sensor: %v", err)
+		}
+		if sensor.SensorStatus == nil || sensor.SensorStatus.BatteryLevel != 87 || !sensor.SensorStatus.ObservedAt.Equal(observedAt) {
+			t.Fatalf("expected latest status with battery level 87 observed at %s, got %+v", observedAt, sensor.SensorStatus)
+		}
+
+		stale, err := s.Stale(ctx)
+		if err != nil {
+			t.Fatalf("failed to query stale devices: %v", err)
+		}
+		found = false
+		for _, d := range stale.Data {
+			if d.DeviceID == deviceID {
+				found = d.DeviceState.ObservedAt.Equal(observedAt)
+			}
+		}
+		if !found {
+			t.Fatalf("expected %q to be stale since %s", deviceID, observedAt)
+		}
+	})
+
+	t.Run("add device statuses and states in batches", func(t *testing.T) {
+		observedAt := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+		bat, newer := 80.0, 79.0
+		rssi := -90.0
+
+		err := s.AddDeviceStatuses(ctx, []types.StatusMessage{
+			{DeviceID: deviceID, BatteryLevel: &newer, Timestamp: observedAt.Add(time.Minute)},
+			{DeviceID: deviceID, BatteryLevel: &bat, RSSI: &rssi, Timestamp: observedAt},
+			{DeviceID: deviceID, BatteryLevel: &bat, RSSI: &rssi, Timestamp: observedAt},
+			{DeviceID: "missing-" + uuid.NewString(), BatteryLevel: &bat, Timestamp: observedAt},
+		})
+		if err != nil {
+			t.Fatalf("failed to add device statuses: %v", err)
+		}
+
+		sensor, found, err := s.GetSensor(ctx, sensorID)
+		if err != nil || !found {
+			t.Fatalf("failed to get sensor: %v", err)
+		}
+		if sensor.SensorStatus == nil || sensor.SensorStatus.BatteryLevel != 79 || !sensor.SensorStatus.ObservedAt.Equal(observedAt.Add(time.Minute)) {
+			t.Fatalf("expected latest status with battery level 79, got %+v", sensor.SensorStatus)
+		}
+
+		until := observedAt.Add(time.Hour)
+		err = s.SetDeviceStates(ctx, map[string]types.DeviceState{
+			deviceID: {Online: true, State: types.DeviceStateError, ObservedAt: observedAt, DegradedUntil: &until},
+		})
+		if err != nil {
+			t.Fatalf("failed to set device states: %v", err)
+		}
+
+		err = s.SetDeviceStates(ctx, map[string]types.DeviceState{
+			deviceID: {Online: true, State: types.DeviceStateOK, ObservedAt: observedAt.Add(-time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("failed to set older device states: %v", err)
+		}
+
+		devices, err := s.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{DeviceID: deviceID}})
+		if err != nil || len(devices.Data) != 1 {
+			t.Fatalf("failed to query device: %v", err)
+		}
+		if d := devices.Data[0]; d.DeviceState.State != types.DeviceStateError || !d.DeviceState.ObservedAt.Equal(observedAt) {
+			t.Fatalf("expected newest device state to be kept, got %+v", devices.Data[0].DeviceState)
+		}
+	})
+
+	t.Run("get device state", func(t *testing.T) {
+		d, found, err := s.GetDeviceState(ctx, deviceID)
+		if err != nil || !found {
+			t.Fatalf("failed to get device state: %v", err)
+		}
+		if d.DeviceState.State != types.DeviceStateError || d.DeviceState.DegradedUntil == nil || d.SensorProfile.Decoder == "" {
+			t.Fatalf("expected device state and decoder, got %+v", d)
+		}
+
+		_, found, err = s.GetDeviceState(ctx, "missing-"+uuid.NewString())
+		if err != nil || found {
+			t.Fatalf("expected missing device to return found=false, got %v", err)
+		}
+
+		states, err := s.GetDeviceStates(ctx, []string{deviceID, "missing-" + uuid.NewString()})
+		if err != nil {
+			t.Fatalf("failed to get device states: %v", err)
+		}
+		if len(states) != 1 || states[deviceID].DeviceState.State != d.DeviceState.State {
+			t.Fatalf("expected only the state of %s, got %+v", deviceID, states)
+		}
+	})
+
+	t.Run("roll up and expire device status", func(t *testing.T) {
+		old := time.Now().UTC().AddDate(0, 0, -30).Truncate(time.Hour)
+		bat, low := 60.0, 40.0
+
+		err := s.AddDeviceStatuses(ctx, []types.StatusMessage{
+			{DeviceID: deviceID, BatteryLevel: &bat, Timestamp: old.Add(10 * time.Minute)},
+			{DeviceID: deviceID, BatteryLevel: &low, Timestamp: old.Add(20 * time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("failed to add old device statuses: %v", err)
+		}
+
+		err = s.RollupSensorStatus(ctx)
+		if err != nil {
+			t.Fatalf("failed to roll up device statuses: %v", err)
+		}
+
+		err = s.DeleteExpiredSensorStatus(ctx, []retention.Policy{{Raw: 21, Hourly: 90, Daily: 730}})
+		if err != nil {
+			t.Fatalf("failed to delete expired device statuses: %v", err)
+		}
+
+		from := old.Add(-time.Hour)
+		to := old.Add(time.Hour)
+		statuses, err := s.GetDeviceStatus(ctx, deviceID, dmquery.StatusFilters{Filters: dmquery.Filters{AllowedTenants: []string{"test-tenant"}}, From: &from, To: &to})
+		if err != nil {
+			t.Fatalf("failed to get device status: %v", err)
+		}
+		if len(statuses.Data) != 1 || statuses.Data[0].BatteryLevel != 50 || !statuses.Data[0].ObservedAt.Equal(old) {
+			t.Fatalf("expected one hourly rollup with average battery level 50, got %+v", statuses.Data)
+		}
+
+		aggregates, err := s.GetDeviceStatusAggregates(ctx, deviceID, dmquery.StatusFilters{Filters: dmquery.Filters{AllowedTenants: []string{"test-tenant"}}, From: &from, To: &to, Bucket: types.BucketHour})
+		if err != nil {
+			t.Fatalf("failed to get device status aggregates: %v", err)
+		}
+		if len(aggregates.Data) != 1 || aggregates.Data[0].Samples != 2 || *aggregates.Data[0].BatteryLevel.Min != 40 || *aggregates.Data[0].BatteryLevel.Max != 60 {
+			t.Fatalf("expected hourly aggregate with battery level between 40 and 60, got %+v", aggregates.Data)
+		}
+	})
+
+	t.Run("roll up keeps complete buckets when raw status expires", func(t *testing.T) {
+		recent := time.Now().UTC().Add(-3 * time.Hour).Truncate(time.Hour)
+		bat, low := 60.0, 40.0
+
+		err := s.AddDeviceStatuses(ctx, []types.StatusMessage{
+			{DeviceID: deviceID, BatteryLevel: &bat, Timestamp: recent.Add(10 * time.Minute)},
+			{DeviceID: deviceID, BatteryLevel: &low, Timestamp: recent.Add(20 * time.Minute)},
+		})
+		if err != nil {
+			t.Fatalf("failed to add device statuses: %v", err)
+		}
+
+		err = s.RollupSensorStatus(ctx)
+		if err != nil {
+			t.Fatalf("failed to roll up device statuses: %v", err)
+		}
+
+		_, err = s.conn.Exec(ctx, `DELETE FROM sensor_status WHERE sensor_id = @sensor_id AND observed_at = @observed_at`, pgx.NamedArgs{"sensor_id": sensorID, "observed_at": recent.Add(10 * time.Minute)})
+		if err != nil {
+			t.Fatalf("failed to delete raw device status: %v", err)
+		}
+
+		err = s.RollupSensorStatus(ctx)
+		if err != nil {
+			t.Fatalf("failed to roll up device statuses again: %v", err)
+		}
+
+		// raw rows still cover the hour, so the rollup is read directly
+		var samples int
+		err = s.conn.QueryRow(ctx, `SELECT samples FROM sensor_status_hourly WHERE sensor_id = @sensor_id AND bucket = @bucket`, pgx.NamedArgs{"sensor_id": sensorID, "bucket": recent}).Scan(&samples)
+		if err != nil {
+			t.Fatalf("failed to get hourly rollup: %v", err)
+		}
+		if samples != 2 {
+			t.Fatalf("expected hourly rollup of both samples, got %d", samples)
+		}
+	})
+
+	t.Run("store and query battery forecasts", func(t *testing.T) {
+		depletesAt := time.Now().UTC().AddDate(0, 1, 0).Truncate(time.Second)
+		err := s.SetBatteryForecasts(ctx, []types.BatteryForecast{
+			{SensorID: sensorID, Level: 40, Rate: -1, Threshold: 10, DepletesAt: &depletesAt, Samples: 10, ComputedAt: time.Now().UTC()},
+			{SensorID: "unknown-sensor", Level: 40, Rate: -1, Threshold: 10, Samples: 10, ComputedAt: time.Now().UTC()},
+		})
+		if err != nil {
+			t.Fatalf("failed to set battery forecasts: %v", err)
+		}
+
+		before := depletesAt.Add(time.Hour)
+		devices, err := s.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{SensorID: sensorID, BatteryDepletesBefore: &before}})
+		if err != nil {
+			t.Fatalf("failed to query devices: %v", err)
+		}
+		if len(devices.Data) != 1 || devices.Data[0].BatteryForecast == nil || !devices.Data[0].BatteryForecast.DepletesAt.Equal(depletesAt) {
+			t.Fatalf("expected device with battery forecast, got %+v", devices.Data)
+		}
+
+		forecasts, err := s.GetBatteryForecasts(ctx, dmquery.BatteryFilters{Filters: dmquery.Filters{AllowedTenants: []string{"test-tenant"}}, DepletesBefore: &before})
+		if err != nil {
+			t.Fatalf("failed to get battery forecasts: %v", err)
+		}
+		if !slices.ContainsFunc(forecasts.Data, func(f types.BatteryForecast) bool { return f.SensorID == sensorID && f.DeviceID == deviceID }) {
+			t.Fatalf("expected battery forecast for %s, got %+v", sensorID, forecasts.Data)
+		}
+
+		err = s.SetBatteryForecasts(ctx, nil)
+		if err != nil {
+			t.Fatalf("failed to clear battery forecasts: %v", err)
+		}
+	})
+
+	t.Run("store link quality and query coverage", func(t *testing.T) {
+		rssi, snr := -108.0, -2.0
+		err := s.SetLinkQuality(ctx, []types.LinkQuality{
+			{SensorID: sensorID, Class: types.LinkQualityMarginal, Samples: 10, RSSI: types.Percentiles{P50: &rssi}, LoRaSNR: types.Percentiles{P50: &snr}, SpreadingFactors: map[string]int{"9": 10}, ComputedAt: time.Now().UTC()},
+		})
+		if err != nil {
+			t.Fatalf("failed to set link quality: %v", err)
+		}
+
+		devices, err := s.Query(ctx, dmquery.DeviceFilters{Filters: dmquery.Filters{SensorID: sensorID, LinkQuality: []string{types.LinkQualityMarginal}}})
+		if err != nil {
+			t.Fatalf("failed to query devices: %v", err)
+		}
+		if len(devices.Data) != 1 || devices.Data[0].LinkQuality == nil || devices.Data[0].LinkQuality.SpreadingFactors["9"] != 10 {
+			t.Fatalf("expected device with link quality, got %+v", devices.Data)
+		}
+
+		location := devices.Data[0].Location
+		cells, err := s.GetCoverage(ctx, dmquery.CoverageFilters{
+			Filters: dmquery.Filters{
+				AllowedTenants: []string{"test-tenant"},
+				Bounds:         &types.Bounds{MinLat: location.Latitude - 1, MaxLat: location.Latitude + 1, MinLon: location.Longitude - 1, MaxLon: location.Longitude + 1},
+			},
+			Size: 2,
+		})
+		if err != nil {
+			t.Fatalf("failed to get coverage: %v", err)
+		}
+		if len(cells) == 0 || cells[0].Marginal == 0 {
+			t.Fatalf("expected coverage cell with a marginal device, got %+v", cells)
+		}
+
+		_, err = s.GetLinkQualityStatistics(ctx, time.Now().AddDate(0, 0, -7))
+		if err != nil {
+			t.Fatalf("failed to get link quality statistics: %v", err)
+		}
+	})
+
 	t.Run("set active flag on device", func(t *testing.T) {
 		d, found, err := s.GetDeviceBySensorID(ctx, sensorID)
 		if err != nil {
@@ -451,3 +828,29 @@ func TestStorage(t *testing.T) {
 	})
 
 }
+
+func TestStatusSource(t *testing.T) {
+	now := time.Now()
+	day := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+
+	cases := []struct {
+		from               time.Time
+		raw, hourly, daily *time.Time
+		expected           string
+	}{
+		{*day(1), day(21), day(90), day(700), rawStatus},
+		{*day(30), day(21), day(90), day(700), hourlyStatus},
+		{*day(365), day(21), day(90), day(700), dailyStatus},
+		{*day(365), day(21), day(90), nil, hourlyStatus},
+		{*day(365), nil, nil, nil, rawStatus},
+	}
+
+	for _, c := range cases {
+		if source := statusSource(c.from, c.raw, c.hourly, c.daily); source != c.expected {
+			t.Fatalf("expected %s status for range from %s, got %s", c.expected, c.from, source)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetDeviceHistory returns the state changes and alarm periods within a period of every
+// device of a tenant. The state of a device when the period starts is given by the last
+// change before it.
+func (s *Storage) GetDeviceHistory(ctx context.Context, tenant string, allowedTenants []string, from, to time.Time) ([]types.DeviceHistory, error) {
+	args := pgx.NamedArgs{
+		"tenant":  tenant,
+		"tenants": allowedTenants,
+		"from":    from.UTC(),
+		"to":      to.UTC(),
+	}
+
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return nil, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT device_id, COALESCE(name, '')
+		FROM devices
+		WHERE deleted = FALSE AND tenant = @tenant AND tenant = ANY(@tenants)
+		ORDER BY device_id`, args)
+	if err != nil {
+		log.Error("could not query devices", "err", err.Error())
+		return nil, err
+	}
+
+	history := []types.DeviceHistory{}
+	index := map[string]int{}
+
+	for rows.Next() {
+		h := types.DeviceHistory{States: []types.StateChange{}, Alarms: []types.AlarmPeriod{}}
+		if err := rows.Scan(&h.DeviceID, &h.Name); err != nil {
+			rows.Close()
+			log.Error("could not scan device", "err", err.Error())
+			return nil, err
+		}
+		index[h.DeviceID] = len(history)
+		history = append(history, h)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = c.Query(ctx, `
+		SELECT h.device_id, h.online, h.observed_at
+		FROM device_state_history h
+		JOIN devices d ON d.device_id = h.device_id
+		WHERE d.deleted = FALSE AND d.tenant = @tenant AND d.tenant = ANY(@tenants)
+			AND h.observed_at < @to
+			AND h.observed_at >= COALESCE((
+				SELECT max(p.observed_at) FROM device_state_history p
+				WHERE p.device_id = h.device_id AND p.observed_at < @from), @from)
+		ORDER BY h.device_id, h.observed_at`, args)
+	if err != nil {
+		log.Error("could not query device state history", "err", err.Error())
+		return nil, err
+	}
+
+	for rows.Next() {
+		var deviceID string
+		var sc types.StateChange
+		if err := rows.Scan(&deviceID, &sc.Online, &sc.ObservedAt); err != nil {
+			rows.Close()
+			log.Error("could not scan device state history", "err", err.Error())
+			return nil, err
+		}
+		if i, ok := index[deviceID]; ok {
+			sc.ObservedAt = sc.ObservedAt.UTC()
+			history[i].States = append(history[i].States, sc)
+		}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = c.Query(ctx, `
+		SELECT h.device_id, h.type, h.started_at, h.ended_at
+		FROM device_alarm_history h
+		JOIN devices d ON d.device_id = h.device_id
+		WHERE d.deleted = FALSE AND d.tenant = @tenant AND d.tenant = ANY(@tenants)
+			AND h.started_at < @to AND (h.ended_at IS NULL OR h.ended_at > @from)
+		ORDER BY h.device_id, h.started_at`, args)
+	if err != nil {
+		log.Error("could not query device alarm history", "err", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deviceID string
+		var a types.AlarmPeriod
+		if err := rows.Scan(&deviceID, &a.Type, &a.From, &a.To); err != nil {
+			log.Error("could not scan device alarm history", "err", err.Error())
+			return nil, err
+		}
+		if i, ok := index[deviceID]; ok {
+			a.From = a.From.UTC()
+			if a.To != nil {
+				t := a.To.UTC()
+				a.To = &t
+			}
+			history[i].Alarms = append(history[i].Alarms, a)
+		}
+	}
+
+	return history, rows.Err()
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
+	viewquery "github.com/diwise/iot-device-mgmt/internal/application/views/query"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateView stores a new view, or returns ErrViewAlreadyExist if the id is taken by any view,
+// including views the caller can not see.
+func (s *Storage) CreateView(ctx context.Context, v types.View) error {
+	log := logging.GetFromContext(ctx)
+
+	args, err := viewArgs(v)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	result, err := c.Exec(ctx, `
+		INSERT INTO views (view_id, tenant, owner, shared, name, filters, sort_by, sort_desc, columns)
+		VALUES (@view_id, @tenant, @owner, @shared, @name, @filters::jsonb, @sort_by, @sort_desc, @columns)
+		ON CONFLICT (view_id) DO NOTHING`, args)
+	if err != nil {
+		log.Error("could not store view", "view_id", v.ID, "err", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return views.ErrViewAlreadyExist
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateView(ctx context.Context, v types.View) error {
+	log := logging.GetFromContext(ctx)
+
+	args, err := viewArgs(v)
+	if err != nil {
+		return err
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	result, err := c.Exec(ctx, `
+		UPDATE views
+		SET tenant = @tenant,
+			owner = @owner,
+			shared = @shared,
+			name = @name,
+			filters = @filters::jsonb,
+			sort_by = @sort_by,
+			sort_desc = @sort_desc,
+			columns = @columns,
+			modified_on = CURRENT_TIMESTAMP
+		WHERE view_id = @view_id`, args)
+	if err != nil {
+		log.Error("could not update view", "view_id", v.ID, "err", err.Error())
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return views.ErrViewNotFound
+	}
+
+	return nil
+}
+
+func viewArgs(v types.View) (pgx.NamedArgs, error) {
+	filters, err := json.Marshal(v.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.NamedArgs{
+		"view_id":   v.ID,
+		"tenant":    v.Tenant,
+		"owner":     nullIfEmpty(v.Owner),
+		"shared":    v.Shared,
+		"name":      v.Name,
+		"filters":   string(filters),
+		"sort_by":   nullIfEmpty(v.SortBy),
+		"sort_desc": v.SortDesc,
+		"columns":   v.Columns,
+	}, nil
+}
+
+func (s *Storage) DeleteView(ctx context.Context, viewID string) error {
+	log := logging.GetFromContext(ctx)
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return err
+	}
+	defer c.Release()
+
+	_, err = c.Exec(ctx, `DELETE FROM views WHERE view_id = @view_id`, pgx.NamedArgs{"view_id": viewID})
+	if err != nil {
+		log.Error("could not delete view", "view_id", viewID, "err", err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *Storage) GetViews(ctx context.Context, query viewquery.Views) (types.Collection[types.View], error) {
+	log := logging.GetFromContext(ctx)
+
+	where, args := viewWhere(query)
+
+	condition := &Condition{Offset: query.Offset, Limit: query.Limit}
+	offsetLimit, offset, limit := OffsetLimit(condition, 0, 100)
+	if query.Offset != nil {
+		args["offset"] = *query.Offset
+	}
+	if query.Limit != nil {
+		args["limit"] = *query.Limit
+	}
+
+	c, err := s.conn.Acquire(ctx)
+	if err != nil {
+		log.Error("could not acquire connection", "err", err.Error())
+		return types.Collection[types.View]{}, err
+	}
+	defer c.Release()
+
+	rows, err := c.Query(ctx, `
+		SELECT v.view_id, v.tenant, COALESCE(v.owner, ''), v.shared, v.name, v.filters,
+			COALESCE(v.sort_by, ''), v.sort_desc, COALESCE(v.columns, '{}'), count(*) OVER () AS count
+		FROM views v
+		`+where+`
+		ORDER BY v.name ASC, v.view_id ASC
+		`+offsetLimit, args)
+	if err != nil {
+		log.Error("could not query views", "err", err.Error())
+		return types.Collection[types.View]{}, err
+	}
+	defer rows.Close()
+
+	views := []types.View{}
+	var count uint64
+
+	for rows.Next() {
+		var v types.View
+		var filters []byte
+
+		err := rows.Scan(&v.ID, &v.Tenant, &v.Owner, &v.Shared, &v.Name, &filters, &v.SortBy, &v.SortDesc, &v.Columns, &count)
+		if err != nil {
+			log.Error("could not scan view", "err", err.Error())
+			return types.Collection[types.View]{}, err
+		}
+
+		if len(filters) > 0 {
+			if err := json.Unmarshal(filters, &v.Filters); err != nil {
+				log.Error("could not unmarshal view filters", "view_id", v.ID, "err", err.Error())
+				return types.Collection[types.View]{}, err
+			}
+		}
+
+		views = append(views, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return types.Collection[types.View]{}, err
+	}
+
+	return types.Collection[types.View]{
+		Data:       views,
+		Count:      uint64(len(views)),
+		Offset:     uint64(offset),
+		Limit:      uint64(limit),
+		TotalCount: count,
+	}, nil
+}
+
+// viewWhere matches the views in the allowed tenants that are shared, or owned by the user.
+func viewWhere(query viewquery.Views) (string, pgx.NamedArgs) {
+	where := []string{"v.tenant = ANY(@tenants)"}
+	args := pgx.NamedArgs{"tenants": query.AllowedTenants}
+
+	if query.User != "" {
+		where = append(where, "(v.shared OR v.owner = @owner)")
+		args["owner"] = query.User
+	} else {
+		where = append(where, "v.shared")
+	}
+
+	if query.ID != "" {
+		where = append(where, "v.view_id = @view_id")
+		args["view_id"] = query.ID
+	}
+
+	return "WHERE " + strings.Join(where, " AND "), args
+}
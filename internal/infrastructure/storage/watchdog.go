@@ -8,11 +8,14 @@ import (
 )
 
 func (s *Storage) Stale(ctx context.Context) (types.Collection[types.Device], error) {
+	// the shortest interval in use gives a lower bound that lets the planner use
+	// idx_sensors_observed_at before the per device interval is applied.
 	sql := `
-		WITH last_status AS (
-			SELECT sensor_id, MAX(observed_at) AS last_observed
-			FROM sensor_status
-			GROUP BY sensor_id
+		WITH shortest AS (
+			SELECT LEAST(
+				(SELECT MIN(interval) FROM sensor_profiles),
+				(SELECT MIN(interval) FROM devices WHERE interval > 0)
+			) AS interval
 		)
 
 		SELECT
@@ -23,13 +26,16 @@ func (s *Storage) Stale(ctx context.Context) (types.Collection[types.Device], er
 			s.sensor_profile,
 			d.interval      AS device_interval,
 			sp.interval     AS profile_interval,
-			ls.last_observed,
-			CASE WHEN d.interval = 0 THEN sp.interval ELSE d.interval END AS effective_interval_seconds
-		FROM devices d
-			LEFT JOIN sensors s ON s.sensor_id = d.sensor_id
+			s.observed_at   AS last_observed,
+			COALESCE(NULLIF(d.interval, 0), sp.interval) AS effective_interval_seconds,
+			COALESCE(d.calendar, sp.calendar) AS calendar
+		FROM sensors s
+			JOIN devices d ON d.sensor_id = s.sensor_id
 			LEFT JOIN sensor_profiles sp ON sp.sensor_profile_id = s.sensor_profile
-			LEFT JOIN last_status ls ON ls.sensor_id = d.sensor_id
-		WHERE ls.last_observed IS NOT NULL AND ls.last_observed < NOW() - (COALESCE(NULLIF(d.interval, 0), sp.interval) * INTERVAL '1 second');
+			CROSS JOIN shortest
+		WHERE s.observed_at < NOW() - (COALESCE(shortest.interval, 0) * INTERVAL '1 second')
+			AND s.observed_at < NOW() - (COALESCE(NULLIF(d.interval, 0), sp.interval) * INTERVAL '1 second')
+			AND COALESCE((COALESCE(d.calendar, sp.calendar)->>'eventDriven')::boolean, FALSE) = FALSE;
 	`
 
 	c, err := s.conn.Acquire(ctx)
@@ -53,7 +59,9 @@ func (s *Storage) Stale(ctx context.Context) (types.Collection[types.Device], er
 	var lastObserved *time.Time
 
 	for rows.Next() {
-		err := rows.Scan(&deviceID, &sensorID, &active, &tenant, &profile, &device_interval, &profile_interval, &lastObserved, &effective_interval)
+		var calendar *types.ReportingCalendar
+
+		err := rows.Scan(&deviceID, &sensorID, &active, &tenant, &profile, &device_interval, &profile_interval, &lastObserved, &effective_interval, &calendar)
 		if err != nil {
 			return types.Collection[types.Device]{}, err
 		}
@@ -74,6 +82,7 @@ func (s *Storage) Stale(ctx context.Context) (types.Collection[types.Device], er
 			DeviceID: _did,
 			Tenant:   _tid,
 			Interval: _ei,
+			Calendar: calendar,
 			DeviceState: types.DeviceState{
 				ObservedAt: time.Time{},
 			},
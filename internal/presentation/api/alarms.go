@@ -34,7 +34,7 @@ func getAlarmsHandler(log *slog.Logger, svc alarms.AlarmAPIService) http.Handler
 		}
 
 		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
-		response := ApiResponse{Data: result.Data, Meta: meta, Links: createLinks(r.URL, meta)}
+		response := ApiResponse{Data: result.Data, Meta: meta, Links: createPageLinks(r.URL, meta, query.Cursor, result.Cursor)}
 
 		w.Header().Add("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
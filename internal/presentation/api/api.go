@@ -35,12 +35,19 @@ func RegisterHandlers(ctx context.Context, mux *http.ServeMux, policies io.Reade
 	r.Get("/sensors/{id}", getSensorHandler(log, app.SensorService()))
 	r.Post("/sensors", createSensorHandler(log, app.SensorService()))
 	r.Put("/sensors/{id}", updateSensorHandler(log, app.SensorService()))
+	r.Post("/sensors/{id}/promote", promoteSensorHandler(log, app))
 
 	r.Get("/devices", queryDevicesHandler(log, app.DeviceService()))
+	r.Post("/devices/search", queryDevicesHandler(log, app.DeviceService()))
+	r.Get("/devices/stats", getDeviceStatsHandler(log, app.DeviceService()))
+	r.Get("/devices/clusters", getDeviceClustersHandler(log, app.DeviceService()))
+	r.Get("/devices/tiles/{z}/{x}/{y}", getDeviceTileHandler(log, app.DeviceService()))
 	r.Get("/devices/{id}", getDeviceHandler(log, app.DeviceService()))
 	r.Get("/devices/{id}/status", getDeviceStatusHandler(log, app.DeviceService()))
 	r.Get("/devices/{id}/alarms", getDeviceAlarmsHandler(log, app.DeviceService()))
 	r.Get("/devices/{id}/measurements", getDeviceMeasurementsHandler(log, app.DeviceService()))
+	r.Get("/devices/{id}/locations", getDeviceLocationsHandler(log, app.DeviceService()))
+	r.Get("/devices/{id}/availability", getDeviceAvailabilityHandler(log, app.DeviceService()))
 
 	r.Post("/devices", createDeviceHandler(log, app)) //TODO: fix import endpoint to use device service directly instead of seeding method
 	r.Put("/devices/{id}", updateDeviceHandler(log, app.DeviceService()))
@@ -48,13 +55,35 @@ func RegisterHandlers(ctx context.Context, mux *http.ServeMux, policies io.Reade
 	r.Put("/devices/{id}/sensor", attachDeviceSensorHandler(log, app.DeviceService()))
 	r.Delete("/devices/{id}/sensor", detachDeviceSensorHandler(log, app.DeviceService()))
 
+	r.Get("/geofences", queryGeofencesHandler(log, app.DeviceService()))
+	r.Get("/geofences/{id}", getGeofenceHandler(log, app.DeviceService()))
+	r.Post("/geofences", createGeofenceHandler(log, app.DeviceService()))
+	r.Put("/geofences/{id}", updateGeofenceHandler(log, app.DeviceService()))
+	r.Delete("/geofences/{id}", deleteGeofenceHandler(log, app.DeviceService()))
+
+	r.Get("/views", queryViewsHandler(log, app.ViewService()))
+	r.Get("/views/{id}", getViewHandler(log, app.ViewService()))
+	r.Get("/views/{id}/devices", getViewDevicesHandler(log, app.ViewService(), app.DeviceService()))
+	r.Post("/views", createViewHandler(log, app.ViewService()))
+	r.Put("/views/{id}", updateViewHandler(log, app.ViewService()))
+	r.Delete("/views/{id}", deleteViewHandler(log, app.ViewService()))
+
+	r.Get("/search", searchHandler(log, app.SearchService()))
+
 	r.Get("/alarms", getAlarmsHandler(log, app.AlarmService()))
 
+	r.Get("/reports/battery", getBatteryReportHandler(log, app.DeviceService()))
+	r.Get("/reports/coverage", getCoverageReportHandler(log, app.DeviceService()))
+	r.Get("/reports/delivery", getDeliveryReportHandler(log, app.DeviceService()))
+	r.Get("/reports/availability", getUptimeReportHandler(log, app.DeviceService()))
+
 	r.Get("/admin/deviceprofiles", queryDeviceProfilesHandler(log, app.DeviceService()))
 	r.Get("/admin/deviceprofiles/{id}", queryDeviceProfilesHandler(log, app.DeviceService()))
 	r.Get("/admin/lwm2mtypes", queryLwm2mTypesHandler(log, app.DeviceService()))
 	r.Get("/admin/lwm2mtypes/{urn}", queryLwm2mTypesHandler(log, app.DeviceService()))
 	r.Get("/admin/tenants", queryTenantsHandler())
+	r.Get("/admin/quarantine", getQuarantineHandler(log, app.QuarantineService()))
+	r.Post("/admin/quarantine/replay", replayQuarantineHandler(log, app.QuarantineService()))
 
 	return nil
 }
@@ -1,24 +1,35 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/diwise/iot-device-mgmt/internal/application"
 	"github.com/diwise/iot-device-mgmt/internal/application/alarms"
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	"github.com/diwise/iot-device-mgmt/internal/application/devices"
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/search"
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
 	"github.com/diwise/iot-device-mgmt/internal/application/sensors"
 	sensorquery "github.com/diwise/iot-device-mgmt/internal/application/sensors/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
 	"github.com/diwise/messaging-golang/pkg/messaging"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 )
@@ -36,8 +47,12 @@ func TestApi(t *testing.T) {
 	dm := devices.New(mocks.reader, mocks.writer, mocks.statusWriter, mocks.profiles, msgMock, &devices.Config{})
 	sm := sensors.New(sensorMocks.reader, sensorMocks.writer)
 	as := alarms.AlarmAPIServiceMock{}
+	qs := quarantine.QuarantineServiceMock{}
 
-	app := application.New(dm, sm, &as, true)
+	vs := views.ViewServiceMock{}
+	ss := search.SearchServiceMock{}
+
+	app := application.New(dm, sm, &as, &qs, &vs, &ss, true)
 
 	mux := http.NewServeMux()
 	RegisterHandlers(ctx, mux, policies, app)
@@ -53,6 +68,10 @@ func TestApi(t *testing.T) {
 		testQueryDevicesWithUrnsFilter(t, server.URL, mocks)
 	})
 
+	t.Run("GET /devices?cursor=", func(t *testing.T) {
+		testQueryDevicesWithCursor(t, server.URL, mocks)
+	})
+
 	t.Run("GET /sensors", func(t *testing.T) {
 		testQuerySensors(t, server.URL, sensorMocks)
 	})
@@ -97,14 +116,98 @@ func TestApi(t *testing.T) {
 		testDeviceStatus(t, server.URL, mocks)
 	})
 
+	t.Run("GET /devices/test-device-1/status?bucket=1d", func(t *testing.T) {
+		testDeviceStatusAggregates(t, server.URL, mocks)
+	})
+
 	t.Run("GET /devices/test-device-1/alarms", func(t *testing.T) {
 		testDeviceAlarms(t, server.URL, mocks)
 	})
 
+	t.Run("GET /devices/test-device-1?include=latestMeasurements,alarms", func(t *testing.T) {
+		testGetDeviceWithInclude(t, server.URL, mocks)
+	})
+
 	t.Run("GET /devices/test-device-1/measurements", func(t *testing.T) {
 		testDeviceMeasurements(t, server.URL, mocks)
 	})
 
+	t.Run("GET /devices/test-device-1/measurements?bucket=1h", func(t *testing.T) {
+		testDeviceMeasurementsAggregated(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices?batteryDepletesBefore=2026-12-01", func(t *testing.T) {
+		testQueryDevicesByBatteryDepletion(t, server.URL, mocks)
+	})
+
+	t.Run("GET /reports/battery", func(t *testing.T) {
+		testBatteryReport(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices?linkQuality=marginal,poor", func(t *testing.T) {
+		testQueryDevicesByLinkQuality(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices/stats", func(t *testing.T) {
+		testGetDeviceStats(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices?fields=", func(t *testing.T) {
+		testQueryDevicesWithFields(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices?filter=", func(t *testing.T) {
+		testQueryDevicesByFilterExpression(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices?near=62.39,17.30&radius=500", func(t *testing.T) {
+		testQueryDevicesByArea(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices/clusters", func(t *testing.T) {
+		testDeviceClusters(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices/tiles/{z}/{x}/{y}.mvt", func(t *testing.T) {
+		testDeviceTile(t, server.URL, mocks)
+	})
+
+	t.Run("GET /reports/coverage", func(t *testing.T) {
+		testCoverageReport(t, server.URL, mocks)
+	})
+
+	t.Run("GET /devices/test-device-1/locations", func(t *testing.T) {
+		testDeviceLocations(t, server.URL, mocks)
+	})
+
+	t.Run("POST, PUT and DELETE /geofences", func(t *testing.T) {
+		testGeofences(t, server.URL, mocks)
+	})
+
+	t.Run("POST /views", func(t *testing.T) {
+		testCreateView(t, server.URL, &vs)
+	})
+
+	t.Run("GET /views/{id}/devices", func(t *testing.T) {
+		testGetViewDevices(t, server.URL, mocks, &vs)
+	})
+
+	t.Run("GET /search", func(t *testing.T) {
+		testSearch(t, server.URL, &ss)
+	})
+
+	t.Run("GET /devices/test-device-1/availability", func(t *testing.T) {
+		testDeviceAvailability(t, server.URL, mocks)
+	})
+
+	t.Run("GET /reports/delivery", func(t *testing.T) {
+		testDeliveryReport(t, server.URL, mocks)
+	})
+
+	t.Run("GET /reports/availability?tenant=default&month=2026-01", func(t *testing.T) {
+		testUptimeReport(t, server.URL, mocks)
+	})
+
 	t.Run("GET /alarms", func(t *testing.T) {
 		testGetAlarms(t, server.URL, &as)
 	})
@@ -149,6 +252,18 @@ func TestApi(t *testing.T) {
 		testCreateSensorDuplicate(t, server.URL, sensorMocks)
 	})
 
+	t.Run("GET /sensors?discovered=true", func(t *testing.T) {
+		testQueryDiscoveredSensors(t, server.URL, sensorMocks)
+	})
+
+	t.Run("POST /sensors/discovered-sensor/promote", func(t *testing.T) {
+		testPromoteSensor(t, server.URL, mocks, sensorMocks)
+	})
+
+	t.Run("POST /sensors/discovered-sensor/promote not admin", func(t *testing.T) {
+		testPromoteSensorNotAdmin(t, server.URL, mocks)
+	})
+
 	t.Run("PUT /devices/test-device-1", func(t *testing.T) {
 		testUpdateDevice(t, server.URL, mocks)
 	})
@@ -205,6 +320,14 @@ func TestApi(t *testing.T) {
 		testGetAlarmsInternalError(t, server.URL, &as)
 	})
 
+	t.Run("GET /admin/quarantine", func(t *testing.T) {
+		testGetQuarantine(t, server.URL, &qs)
+	})
+
+	t.Run("POST /admin/quarantine/replay", func(t *testing.T) {
+		testReplayQuarantine(t, server.URL, &qs)
+	})
+
 }
 
 type adminAPIService struct {
@@ -340,6 +463,56 @@ func testQueryDevices(t *testing.T, baseUrl string, mocks deviceMocks) {
 	}
 }
 
+func testQueryDevicesWithCursor(t *testing.T, baseUrl string, mocks deviceMocks) {
+	next := types.Cursor{Key: "test-device-1", ID: "test-device-1"}
+
+	var got dmquery.DeviceFilters
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		got = query
+		return types.Collection[types.Device]{Data: []types.Device{testDevice}, Count: 1, Limit: 1, TotalCount: 3, Cursor: &next}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices?cursor=&limit=1", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if got.Cursor == nil || *got.Cursor != (types.Cursor{}) {
+		t.Fatalf("expected the first page, got %+v", got.Cursor)
+	}
+
+	response := struct {
+		Meta  map[string]any    `json:"meta"`
+		Links map[string]string `json:"links"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("could not unmarshal response: %s", err.Error())
+	}
+	if _, ok := response.Meta["offset"]; ok {
+		t.Fatalf("expected no offset when paging by cursor, got %v", response.Meta)
+	}
+	if !strings.Contains(response.Links["next"], "cursor="+next.String()) || response.Links["last"] != "" {
+		t.Fatalf("expected a next link with the cursor, got %v", response.Links)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?cursor="+next.String(), nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if got.Cursor == nil || *got.Cursor != next {
+		t.Fatalf("expected the cursor to be passed on, got %+v", got.Cursor)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?cursor=invalid", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?cursor=&offset=10", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", statusCode)
+	}
+}
+
 func testQueryDevicesWithUrnsFilter(t *testing.T, baseUrl string, mocks deviceMocks) {
 	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
 		if len(query.Urns) != 2 || query.Urns[0] != "urn:oma:lwm2m:ext:3303" || query.Urns[1] != "urn:oma:lwm2m:ext:3304" {
@@ -504,6 +677,37 @@ func testGetDevice(t *testing.T, baseUrl string, mocks deviceMocks) {
 	}
 }
 
+func testGetDeviceWithInclude(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if !slices.Equal(query.Include, []string{dmquery.IncludeLatestMeasurements, dmquery.IncludeAlarms}) {
+			t.Fatalf("unexpected include %v", query.Include)
+		}
+		device := testDevice
+		device.LatestMeasurements = []types.Measurement{{ID: "test-device-1/3303/5700", Urn: "urn:oma:lwm2m:ext:3303", Value: 21.5}}
+		device.AlarmDetails = []types.AlarmDetails{{DeviceID: "test-device-1", AlarmType: "battery_low"}}
+		return types.Collection[types.Device]{
+			Count: 1,
+			Data:  []types.Device{device},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1?include=latestMeasurements,alarms", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"latestMeasurements":[{"id":"test-device-1/3303/5700"`) {
+		t.Fatalf("expected response to contain latest measurements, got %s", string(body))
+	}
+	if !strings.Contains(string(body), `"alarmDetails":[{"deviceID":"test-device-1","alarmType":"battery_low"`) {
+		t.Fatalf("expected response to contain alarm details, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?include=everything", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unsupported include, got %d", statusCode)
+	}
+}
+
 func testDeviceStatus(t *testing.T, baseUrl string, mocks deviceMocks) {
 	mocks.reader.GetDeviceStatusFunc = func(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatus], error) {
 		collection := types.Collection[types.SensorStatus]{
@@ -526,6 +730,49 @@ func testDeviceStatus(t *testing.T, baseUrl string, mocks deviceMocks) {
 	}
 }
 
+func testDeviceStatusAggregates(t *testing.T, baseUrl string, mocks deviceMocks) {
+	avg, min, max := 81.5, 80.0, 83.0
+	mocks.reader.GetDeviceStatusAggregatesFunc = func(ctx context.Context, deviceID string, query dmquery.StatusFilters) (types.Collection[types.SensorStatusAggregate], error) {
+		if query.Bucket != types.BucketDay || query.From == nil || !query.From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected status query %+v", query)
+		}
+		return types.Collection[types.SensorStatusAggregate]{
+			Data: []types.SensorStatusAggregate{
+				{
+					Bucket:       time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					Samples:      24,
+					BatteryLevel: types.Aggregate{Min: &min, Max: &max, Avg: &avg},
+				},
+			},
+			Count:      1,
+			TotalCount: 1,
+		}, nil
+	}
+
+	requestURL := baseUrl + "/api/v0/devices/test-device-1/status?bucket=1d&from=2025-01-01T00:00:00Z"
+
+	statusCode, body := do(t, http.MethodGet, requestURL, nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"batteryLevel":{"min":80,"max":83,"avg":81.5}`) {
+		t.Fatalf("expected response to contain battery level aggregate, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, requestURL, nil, map[string]string{"Accept": "text/csv"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), "2025-01-01T00:00:00Z;24;80;83;81.5;") {
+		t.Fatalf("expected csv row with battery level aggregate, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/status?bucket=1w", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unsupported bucket, got %d", statusCode)
+	}
+}
+
 func testDeviceAlarms(t *testing.T, baseUrl string, mocks deviceMocks) {
 	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
 		return types.Collection[types.Device]{
@@ -546,36 +793,640 @@ func testDeviceAlarms(t *testing.T, baseUrl string, mocks deviceMocks) {
 		return collection, nil
 	}
 
-	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/alarms", nil)
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/alarms", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	if !strings.Contains(string(body), `"alarmType":"battery_low"`) {
+		t.Fatalf("expected response to contain alarm type 'battery_low', got %s", string(body))
+	}
+}
+
+func testDeviceMeasurements(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetDeviceMeasurementsFunc = func(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error) {
+		collection := types.Collection[types.Measurement]{
+			Data: []types.Measurement{
+				{
+					ID:    "-temp-1",
+					Value: 21.5,
+				},
+			},
+		}
+		return collection, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/measurements", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	if !strings.Contains(string(body), `"value":21.5`) {
+		t.Fatalf("expected response to contain measurement value '21.5', got %s", string(body))
+	}
+}
+
+func testDeviceMeasurementsAggregated(t *testing.T, baseUrl string, mocks deviceMocks) {
+	name, unit := "Temperature", "Cel"
+	mocks.reader.GetDeviceMeasurementsFunc = func(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error) {
+		if query.MeasurementID != "3303/5700" || query.Bucket != types.BucketHour || query.Aggregate != "max" || query.From == nil {
+			t.Fatalf("unexpected measurement query %+v", query)
+		}
+		return types.Collection[types.Measurement]{
+			Data: []types.Measurement{
+				{
+					ID:        "test-device-1/3303/5700",
+					Urn:       "urn:oma:lwm2m:ext:3303",
+					Name:      &name,
+					Value:     22.25,
+					Unit:      &unit,
+					Timestamp: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+				},
+			},
+			Count:      1,
+			TotalCount: 1,
+		}, nil
+	}
+
+	requestURL := baseUrl + "/api/v0/devices/test-device-1/measurements?id=3303/5700&bucket=1h&aggregate=max&from=2025-01-01T00:00:00Z"
+
+	statusCode, body := do(t, http.MethodGet, requestURL, nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"urn":"urn:oma:lwm2m:ext:3303","name":"Temperature","value":22.25`) {
+		t.Fatalf("expected response to contain aggregated measurement, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, requestURL, nil, map[string]string{"Accept": "text/csv"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), "test-device-1/3303/5700;urn:oma:lwm2m:ext:3303;Temperature;22.25;Cel;2025-01-01T12:00:00Z") {
+		t.Fatalf("expected csv row with aggregated measurement, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/measurements?aggregate=median&bucket=1h", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unsupported aggregate, got %d", statusCode)
+	}
+}
+
+func testQueryDevicesByBatteryDepletion(t *testing.T, baseUrl string, mocks deviceMocks) {
+	depletesAt := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if query.BatteryDepletesBefore == nil || !query.BatteryDepletesBefore.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected battery filter %v", query.BatteryDepletesBefore)
+		}
+		device := testDevice
+		device.BatteryForecast = &types.BatteryForecast{SensorID: device.SensorID, Level: 40, Rate: -0.5, Threshold: 10, DepletesAt: &depletesAt}
+		return types.Collection[types.Device]{
+			Count:      1,
+			TotalCount: 1,
+			Limit:      10,
+			Data:       []types.Device{device},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices?batteryDepletesBefore=2026-12-01", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"depletesAt":"2026-11-03T00:00:00Z"`) {
+		t.Fatalf("expected response to contain battery forecast, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?batteryDepletesBefore=december", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid date, got %d", statusCode)
+	}
+}
+
+func testBatteryReport(t *testing.T, baseUrl string, mocks deviceMocks) {
+	depletesAt := time.Date(2026, 11, 3, 0, 0, 0, 0, time.UTC)
+	mocks.reader.GetBatteryForecastsFunc = func(ctx context.Context, query dmquery.BatteryFilters) (types.Collection[types.BatteryForecast], error) {
+		if query.DepletesBefore == nil || len(query.AllowedTenants) == 0 {
+			t.Fatalf("unexpected battery query %+v", query)
+		}
+		return types.Collection[types.BatteryForecast]{
+			Data: []types.BatteryForecast{
+				{SensorID: "test-sensor-1", DeviceID: "test-device-1", Tenant: "default", Level: 40, Rate: -0.5, Threshold: 10, DepletesAt: &depletesAt, Samples: 30},
+			},
+			Count:      1,
+			TotalCount: 1,
+			Limit:      10,
+		}, nil
+	}
+
+	requestURL := baseUrl + "/api/v0/reports/battery?before=2027-01-01"
+
+	statusCode, body := do(t, http.MethodGet, requestURL, nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"sensorID":"test-sensor-1","deviceID":"test-device-1"`) {
+		t.Fatalf("expected response to contain battery forecast, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, requestURL, nil, map[string]string{"Accept": "text/csv"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), "test-sensor-1;test-device-1;default;40;-0.5;10;2026-11-03T00:00:00Z;;30;") {
+		t.Fatalf("expected csv row with battery forecast, got %s", string(body))
+	}
+}
+
+func testQueryDevicesByLinkQuality(t *testing.T, baseUrl string, mocks deviceMocks) {
+	p50 := -112.0
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if !slices.Equal(query.LinkQuality, []string{types.LinkQualityMarginal, types.LinkQualityPoor}) {
+			t.Fatalf("unexpected link quality filter %v", query.LinkQuality)
+		}
+		device := testDevice
+		device.LinkQuality = &types.LinkQuality{Class: types.LinkQualityMarginal, Samples: 42, RSSI: types.Percentiles{P50: &p50}}
+		return types.Collection[types.Device]{
+			Count:      1,
+			TotalCount: 1,
+			Limit:      10,
+			Data:       []types.Device{device},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices?linkQuality=marginal,poor", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"linkQuality":{"class":"marginal","samples":42,"rssi":{"p50":-112}`) {
+		t.Fatalf("expected response to contain link quality, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?linkQuality=excellent", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for unknown class, got %d", statusCode)
+	}
+}
+
+func testGetDeviceStats(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetDeviceStatsFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.DeviceStats, error) {
+		if query.Online == nil || *query.Online {
+			t.Fatalf("expected the device filters, got %+v", query.Filters)
+		}
+		stats := types.DeviceStats{Total: 3, Facets: map[string][]types.Facet{}}
+		for _, facet := range query.Facets {
+			stats.Facets[facet] = []types.Facet{{Value: "x", Count: 3}}
+		}
+		return stats, nil
+	}
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		return types.Collection[types.Device]{Count: 1, TotalCount: 3, Limit: 1, Data: []types.Device{testDevice}}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/stats?online=false", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"total":3`) {
+		t.Fatalf("expected the total, got %s", string(body))
+	}
+	if facets := mocks.reader.GetDeviceStatsCalls()[0].Query.Facets; !slices.Equal(facets, dmquery.Facets) {
+		t.Fatalf("expected all facets by default, got %v", facets)
+	}
+
+	statusCode, body = do(t, http.MethodGet, baseUrl+"/api/v0/devices?online=false&limit=1&facets=profile,alarm", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"facets":{"alarm":[{"value":"x","count":3}],"profile":[{"value":"x","count":3}]}`) {
+		t.Fatalf("expected facets alongside the devices, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/stats?facets=colour", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown facet, got %d", statusCode)
+	}
+}
+
+func testQueryDevicesWithFields(t *testing.T, baseUrl string, mocks deviceMocks) {
+	device := types.Device{
+		DeviceID: "test-device-1",
+		SensorID: "test-sensor-1",
+		Tenant:   "default",
+		Name:     "pump",
+		Location: types.Location{Latitude: 62.39, Longitude: 17.30},
+		DeviceState: types.DeviceState{
+			Online: true,
+			State:  types.DeviceStateOK,
+		},
+	}
+
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if !slices.Equal(query.Fields, []string{"deviceID", "location", "deviceState"}) {
+			t.Fatalf("expected the fields to be passed on, got %v", query.Fields)
+		}
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Limit: 10, Data: []types.Device{device}}, nil
+	}
+
+	requestURL := baseUrl + "/api/v0/devices?fields=deviceID,location,deviceState"
+
+	statusCode, body := do(t, http.MethodGet, requestURL, nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	var response struct {
+		Data []map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("could not unmarshal response: %s", err.Error())
+	}
+	if len(response.Data) != 1 || len(response.Data[0]) != 3 {
+		t.Fatalf("expected only the requested fields, got %s", string(body))
+	}
+	if _, ok := response.Data[0]["deviceState"]; !ok {
+		t.Fatalf("expected the device state, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, requestURL, nil, map[string]string{"Accept": "application/geo+json"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if strings.Contains(string(body), `"name"`) || !strings.Contains(string(body), `"coordinates":[17.3,62.39]`) {
+		t.Fatalf("expected the point without other properties, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, requestURL, nil, map[string]string{"Accept": "text/csv"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if string(body) != "internalID;lat;lon\ntest-device-1;62.390000;17.300000\n" {
+		t.Fatalf("expected only the columns of the fields, got %q", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?fields=battery", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unknown field, got %d", statusCode)
+	}
+}
+
+func testQueryDevicesByFilterExpression(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if _, ok := query.Filter.(dmquery.And); !ok {
+			t.Fatalf("expected a parsed filter expression, got %+v", query.Filter)
+		}
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Limit: 10, Data: []types.Device{testDevice}}, nil
+	}
+
+	filter := url.QueryEscape(`tag in ["pilot"] && batteryLevel < 20`)
+	statusCode, _ := do(t, http.MethodGet, baseUrl+"/api/v0/devices?filter="+filter, nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	filter = url.QueryEscape(`tag in ["pilot"] && batteryLevel <`)
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices?filter="+filter, nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), "at position 35") {
+		t.Fatalf("expected the position of the error, got %s", string(body))
+	}
+}
+
+func testQueryDevicesByArea(t *testing.T, baseUrl string, mocks deviceMocks) {
+	var got dmquery.DeviceFilters
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		got = query
+		return types.Collection[types.Device]{
+			Count:      1,
+			TotalCount: 1,
+			Limit:      10,
+			Data:       []types.Device{testDevice},
+		}, nil
+	}
+
+	statusCode, _ := do(t, http.MethodGet, baseUrl+"/api/v0/devices?near=62.39,17.30&radius=500&sortBy=distance", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if got.Near == nil || got.Near.Latitude != 62.39 || got.Near.Longitude != 17.30 || got.Radius != 500 || got.SortBy != "distance" {
+		t.Fatalf("unexpected area filter %+v", got)
+	}
+
+	polygon := `{"type":"Polygon","coordinates":[[[17,62],[18,62],[18,63],[17,63],[17,62]]]}`
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?within="+url.QueryEscape(polygon), nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if len(got.Within) != 1 || len(got.Within[0]) != 1 || got.Within[0][0][1] != [2]float64{18, 62} {
+		t.Fatalf("unexpected within filter %+v", got.Within)
+	}
+
+	multi := `{"type":"Feature","geometry":{"type":"MultiPolygon","coordinates":[[[[17,62],[18,62],[18,63],[17,62]]],[[[15,60],[16,60],[16,61],[15,60]]]]}}`
+
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/devices/search?limit=10", strings.NewReader(multi))
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if len(got.Within) != 2 {
+		t.Fatalf("expected two polygons, got %+v", got.Within)
+	}
+
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/devices/search", strings.NewReader(`{"type":"Polygon","coordinates":[[[17,62],[18,62],[18,63]]]}`))
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an open ring, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?radius=500", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for radius without near, got %d", statusCode)
+	}
+}
+
+var testClusters = []types.DeviceCluster{
+	{
+		Row: 4532, Column: 8979, Count: 3,
+		Location: types.Location{Latitude: 62.39, Longitude: 17.30},
+		Online:   2, Offline: 1,
+		States: types.StateCounts{OK: 2, Error: 1},
+		Alarms: types.SeverityCounts{None: 2, High: 1},
+	},
+	{
+		Row: 4532, Column: 8983, Count: 1, DeviceID: "test-device-1",
+		Location: types.Location{Latitude: 62.39, Longitude: 17.40},
+		Offline:  1,
+		States:   types.StateCounts{Unknown: 1},
+		Alarms:   types.SeverityCounts{None: 1},
+	},
+}
+
+func testDeviceClusters(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetDeviceClustersFunc = func(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+		if query.Zoom != 10 || query.Bounds == nil || query.Bounds.MaxLat != 63 {
+			t.Fatalf("unexpected cluster query %+v", query)
+		}
+		return testClusters, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/clusters?bounds=[62.0,16.0%3B63.0,18.0]&zoom=10", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"count":3,"online":2,"offline":1,"states":{"unknown":0,"ok":2,"warning":0,"error":1},"alarms":{"none":2,"unknown":0,"low":0,"medium":0,"high":1}`) {
+		t.Fatalf("expected response to contain cluster counts, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, baseUrl+"/api/v0/devices/clusters?bounds=[62.0,16.0%3B63.0,18.0]&zoom=10", nil, map[string]string{"Accept": "application/geo+json"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"id":"4532:8983"`) || !strings.Contains(string(body), `"deviceID":"test-device-1"`) {
+		t.Fatalf("expected clusters as features, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/clusters?zoom=10", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without bounds, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/clusters?bounds=[62.0,16.0%3B63.0,18.0]&zoom=23", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for zoom 23, got %d", statusCode)
+	}
+}
+
+func testDeviceTile(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetDeviceClustersFunc = func(ctx context.Context, query dmquery.ClusterFilters) ([]types.DeviceCluster, error) {
+		if query.Zoom != 8 || query.Bounds == nil || query.Bounds.MinLon != 16.875 || query.Bounds.MaxLon != 18.28125 {
+			t.Fatalf("unexpected tile query %+v", query)
+		}
+		return testClusters, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/tiles/8/140/70.mvt", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	layer := decodeMessage(t, body)[3][0]
+	fields := decodeMessage(t, layer)
+	if string(fields[1][0]) != "devices" || len(fields[2]) != 2 {
+		t.Fatalf("expected a devices layer with two features, got %q with %d", fields[1][0], len(fields[2]))
+	}
+	if !slices.ContainsFunc(fields[3], func(k []byte) bool { return string(k) == "deviceID" }) {
+		t.Fatal("expected a deviceID key")
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/tiles/8/256/70.mvt", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a tile outside of the map, got %d", statusCode)
+	}
+}
+
+// decodeMessage returns the length delimited fields of a protobuf message by field number.
+func decodeMessage(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	fields := map[protowire.Number][][]byte{}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			t.Fatalf("invalid tag: %v", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if typ == protowire.BytesType {
+			v, m := protowire.ConsumeBytes(b)
+			if m < 0 {
+				t.Fatalf("invalid field: %v", protowire.ParseError(m))
+			}
+			fields[num] = append(fields[num], v)
+			n = m
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		b = b[n:]
+	}
+
+	return fields
+}
+
+func testCoverageReport(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetCoverageFunc = func(ctx context.Context, query dmquery.CoverageFilters) ([]types.CoverageCell, error) {
+		if query.Size != 4 || query.Bounds == nil || query.Bounds.MinLat != 62.0 || query.Bounds.MaxLon != 18.0 {
+			t.Fatalf("unexpected coverage query %+v", query)
+		}
+		return []types.CoverageCell{
+			{Row: 1, Column: 2, Bounds: types.Bounds{MinLon: 17.0, MaxLon: 17.5, MinLat: 62.25, MaxLat: 62.5}, Devices: 3, Good: 2, Poor: 1},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/reports/coverage?bounds=[62.0,16.0%3B63.0,18.0]&size=4", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `{"row":1,"column":2,"bounds":{"minLon":17,"maxLon":17.5,"minLat":62.25,"maxLat":62.5},"devices":3,"good":2,"marginal":0,"poor":1}`) {
+		t.Fatalf("expected response to contain coverage cell, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/reports/coverage?size=4", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without bounds, got %d", statusCode)
+	}
+}
+
+func availabilityMocks(t *testing.T, mocks deviceMocks) func() {
+	query := mocks.reader.QueryFunc
+
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		return types.Collection[types.Device]{
+			Data: []types.Device{{
+				DeviceID:      "test-device-1",
+				SensorID:      "test-sensor-1",
+				Tenant:        "default",
+				SensorProfile: types.SensorProfile{Name: "elsys", Interval: 3600},
+			}},
+			Count:      1,
+			TotalCount: 1,
+			Limit:      10,
+		}, nil
+	}
+	mocks.reader.GetStatusTimestampsFunc = func(ctx context.Context, sensorIDs []string, from, to time.Time) (map[string][]time.Time, error) {
+		if len(sensorIDs) != 1 || sensorIDs[0] != "test-sensor-1" {
+			t.Fatalf("unexpected sensor ids %v", sensorIDs)
+		}
+		timestamps := []time.Time{}
+		for h := range 12 {
+			timestamps = append(timestamps, from.Add(time.Duration(h)*time.Hour))
+		}
+		return map[string][]time.Time{"test-sensor-1": timestamps}, nil
+	}
+
+	return func() { mocks.reader.QueryFunc = query }
+}
+
+func testDeviceLocations(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Data: []types.Device{testDevice}}, nil
+	}
+	mocks.reader.GetDeviceLocationsFunc = func(ctx context.Context, deviceID string, query dmquery.LocationFilters) (types.Collection[types.DeviceLocation], error) {
+		if query.From == nil || !query.From.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected from %v", query.From)
+		}
+		return types.Collection[types.DeviceLocation]{
+			Count:      2,
+			TotalCount: 2,
+			Limit:      1000,
+			Data: []types.DeviceLocation{
+				{DeviceID: deviceID, Location: types.Location{Latitude: 62.39, Longitude: 17.30}, Source: types.LocationSourceImport, ObservedAt: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)},
+				{DeviceID: deviceID, Location: types.Location{Latitude: 62.40, Longitude: 17.31}, Source: types.LocationSourceStatus, ObservedAt: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)},
+			},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/locations?from=2026-01-01", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `{"deviceID":"test-device-1","location":{"latitude":62.4,"longitude":17.31},"source":"status","observedAt":"2026-01-02T10:00:00Z"}`) {
+		t.Fatalf("expected response to contain locations, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/locations?from=2026-01-01&geometry=lineString", nil, map[string]string{"Accept": "application/geo+json"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `{"type":"LineString","coordinates":[[17.3,62.39],[17.31,62.4]]}`) {
+		t.Fatalf("expected a line string, got %s", string(body))
+	}
+
+	var got dmquery.DeviceFilters
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		got = query
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Limit: 10, Data: []types.Device{testDevice}}, nil
+	}
+
+	polygon := url.QueryEscape(`{"type":"Polygon","coordinates":[[[17,62],[18,62],[18,63],[17,63],[17,62]]]}`)
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?locatedWithin="+polygon+"&at=2026-01-01", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if len(got.LocatedWithin) != 1 || got.At == nil || !got.At.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected located filter %+v", got)
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices?locatedWithin="+polygon, nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without at, got %d", statusCode)
+	}
+}
+
+func testDeviceAvailability(t *testing.T, baseUrl string, mocks deviceMocks) {
+	defer availabilityMocks(t, mocks)()
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/availability?from=2026-01-01&to=2026-01-02&gap=3", nil)
 	if statusCode != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", statusCode)
 	}
+	if !strings.Contains(string(body), `"expected":24,"received":12,"deliveryRatio":0.5`) {
+		t.Fatalf("expected response to contain delivery ratio, got %s", string(body))
+	}
+	if !strings.Contains(string(body), `"gaps":[{"from":"2026-01-01T11:00:00Z","to":"2026-01-02T00:00:00Z","intervals":13}]`) {
+		t.Fatalf("expected response to contain gap, got %s", string(body))
+	}
 
-	if !strings.Contains(string(body), `"alarmType":"battery_low"`) {
-		t.Fatalf("expected response to contain alarm type 'battery_low', got %s", string(body))
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/availability?from=2026-01-02&to=2026-01-01", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 when from is after to, got %d", statusCode)
 	}
 }
 
-func testDeviceMeasurements(t *testing.T, baseUrl string, mocks deviceMocks) {
-	mocks.reader.GetDeviceMeasurementsFunc = func(ctx context.Context, deviceID string, query dmquery.MeasurementFilters) (types.Collection[types.Measurement], error) {
-		collection := types.Collection[types.Measurement]{
-			Data: []types.Measurement{
-				{
-					ID:    "-temp-1",
-					Value: 21.5,
-				},
-			},
+func testDeliveryReport(t *testing.T, baseUrl string, mocks deviceMocks) {
+	defer availabilityMocks(t, mocks)()
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/reports/delivery?from=2026-01-01&to=2026-01-03", nil, map[string]string{"Accept": "text/csv"})
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), "test-device-1;default;2026-01-01;3600;24;12;0.5") || !strings.Contains(string(body), "test-device-1;default;2026-01-02;3600;24;0;0") {
+		t.Fatalf("expected one row per day, got %s", string(body))
+	}
+}
+
+func testUptimeReport(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.reader.GetDeviceHistoryFunc = func(ctx context.Context, tenant string, allowedTenants []string, from, to time.Time) ([]types.DeviceHistory, error) {
+		if tenant != "default" || !from.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected uptime query %s %s", tenant, from)
 		}
-		return collection, nil
+		return []types.DeviceHistory{{
+			DeviceID: "test-device-1",
+			States: []types.StateChange{
+				{Online: true, ObservedAt: from},
+				{Online: false, ObservedAt: from.Add(to.Sub(from) * 3 / 4)},
+			},
+		}}, nil
 	}
 
-	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/devices/test-device-1/measurements", nil)
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/reports/availability?tenant=default&month=2026-01", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"tenant":"default","month":"2026-01"`) || !strings.Contains(string(body), `"uptime":75`) {
+		t.Fatalf("expected response to contain uptime report, got %s", string(body))
+	}
+
+	statusCode, body = do(t, http.MethodGet, baseUrl+"/api/v0/reports/availability?tenant=default&month=2026-01", nil, map[string]string{"Accept": "text/csv"})
 	if statusCode != http.StatusOK {
 		t.Fatalf("expected status 200, got %d", statusCode)
 	}
+	if !strings.Contains(string(body), "default;2026-01;test-device-1;;2678400;2008800;75.00;0;0") {
+		t.Fatalf("expected csv row per device, got %s", string(body))
+	}
 
-	if !strings.Contains(string(body), `"value":21.5`) {
-		t.Fatalf("expected response to contain measurement value '21.5', got %s", string(body))
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/reports/availability?month=2026-01", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without tenant, got %d", statusCode)
 	}
 }
 
@@ -609,6 +1460,52 @@ func testGetAlarms(t *testing.T, baseUrl string, service *alarms.AlarmAPIService
 	}
 }
 
+func testGetQuarantine(t *testing.T, baseUrl string, service *quarantine.QuarantineServiceMock) {
+	service.GroupsFunc = func(ctx context.Context, query quarantinequery.Messages) (types.Collection[types.QuarantineGroup], error) {
+		return types.Collection[types.QuarantineGroup]{
+			Data: []types.QuarantineGroup{{
+				DeviceID: "unknown-device",
+				Tenant:   "default",
+				Reason:   quarantine.ReasonDeviceNotFound,
+				Count:    3,
+			}},
+			Count:      1,
+			TotalCount: 1,
+			Limit:      1,
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/admin/quarantine?reason=device_not_found", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	if !strings.Contains(string(body), `"deviceID":"unknown-device"`) || !strings.Contains(string(body), `"count":3`) {
+		t.Fatalf("expected response to contain quarantine group, got %s", string(body))
+	}
+	if len(service.GroupsCalls()) != 1 || service.GroupsCalls()[0].Query.Reason != quarantine.ReasonDeviceNotFound {
+		t.Fatalf("expected groups query to filter on reason, got %+v", service.GroupsCalls())
+	}
+}
+
+func testReplayQuarantine(t *testing.T, baseUrl string, service *quarantine.QuarantineServiceMock) {
+	service.ReplayFunc = func(ctx context.Context, query quarantinequery.Messages) (types.QuarantineReplay, error) {
+		return types.QuarantineReplay{Replayed: 2, Failed: 1}, nil
+	}
+
+	statusCode, body := do(t, http.MethodPost, baseUrl+"/api/v0/admin/quarantine/replay?deviceID=unknown-device", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	if !strings.Contains(string(body), `"replayed":2`) {
+		t.Fatalf("expected response to contain replay result, got %s", string(body))
+	}
+	if len(service.ReplayCalls()) != 1 || service.ReplayCalls()[0].Query.DeviceID != "unknown-device" {
+		t.Fatalf("expected replay for device, got %+v", service.ReplayCalls())
+	}
+}
+
 func testGetAlarmsWithInvalidLimit(t *testing.T, baseUrl string) {
 	statusCode, _ := do(t, http.MethodGet, baseUrl+"/api/v0/alarms?limit=invalid", nil)
 	if statusCode != http.StatusBadRequest {
@@ -645,7 +1542,7 @@ func testGetDeviceProfiles(t *testing.T, dm devices.DeviceAPIService) {
 		},
 	}
 
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 
 	mux := http.NewServeMux()
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
@@ -679,7 +1576,7 @@ func testGetDeviceProfilesNotFound(t *testing.T, dm devices.DeviceAPIService) {
 	}
 
 	mux := http.NewServeMux()
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
 		t.Fatalf("failed to register handlers: %v", err)
 	}
@@ -707,7 +1604,7 @@ func testGetDeviceProfilesInternalError(t *testing.T, dm devices.DeviceAPIServic
 	}
 
 	mux := http.NewServeMux()
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
 		t.Fatalf("failed to register handlers: %v", err)
 	}
@@ -740,7 +1637,7 @@ func testGetLwm2mTypes(t *testing.T, dm devices.DeviceAPIService) {
 	}
 
 	mux := http.NewServeMux()
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
 		t.Fatalf("failed to register handlers: %v", err)
 	}
@@ -772,7 +1669,7 @@ func testGetLwm2mTypesNotFound(t *testing.T, dm devices.DeviceAPIService) {
 	}
 
 	mux := http.NewServeMux()
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
 		t.Fatalf("failed to register handlers: %v", err)
 	}
@@ -800,7 +1697,7 @@ func testGetLwm2mTypesInternalError(t *testing.T, dm devices.DeviceAPIService) {
 	}
 
 	mux := http.NewServeMux()
-	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, true)
+	app := application.New(service, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, &views.ViewServiceMock{}, &search.SearchServiceMock{}, true)
 	if err := RegisterHandlers(t.Context(), mux, policies, app); err != nil {
 		t.Fatalf("failed to register handlers: %v", err)
 	}
@@ -843,6 +1740,87 @@ func testCreateDevice(t *testing.T, baseUrl string, mocks deviceMocks) {
 	}
 }
 
+func testQueryDiscoveredSensors(t *testing.T, baseUrl string, mocks sensorMocks) {
+	mocks.reader.QueryFunc = func(ctx context.Context, query sensorquery.Sensors) (types.Collection[types.Sensor], error) {
+		if query.Discovered == nil || !*query.Discovered {
+			t.Fatalf("expected discovered filter, got %+v", query.Discovered)
+		}
+		if len(query.AllowedTenants) == 0 {
+			t.Fatal("expected allowed tenants to be set")
+		}
+
+		return types.Collection[types.Sensor]{
+			Data:       []types.Sensor{discoveredSensor},
+			Count:      1,
+			Limit:      10,
+			TotalCount: 1,
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/sensors?discovered=true", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	if !strings.Contains(string(body), `"discovered":true`) || !strings.Contains(string(body), `"firstSeen"`) {
+		t.Fatalf("expected response to contain discovered sensor, got %s", string(body))
+	}
+}
+
+func testPromoteSensor(t *testing.T, baseUrl string, mocks deviceMocks, sensorMocks sensorMocks) {
+	sensorMocks.reader.GetFunc = func(ctx context.Context, sensorID string) (types.Sensor, bool, error) {
+		return discoveredSensor, true, nil
+	}
+	sensorMocks.writer.UpdateFunc = func(ctx context.Context, sensor types.Sensor) error {
+		if sensor.SensorProfile == nil || sensor.SensorProfile.Decoder != "elsys" {
+			t.Fatalf("expected sensor profile to be updated to elsys, got %+v", sensor.SensorProfile)
+		}
+		return nil
+	}
+
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		return types.Collection[types.Device]{}, nil
+	}
+	mocks.reader.GetSensorFunc = func(ctx context.Context, sensorID string) (types.Sensor, bool, error) {
+		return types.Sensor{SensorID: sensorID, SensorProfile: &types.SensorProfile{Decoder: "elsys"}}, true, nil
+	}
+	mocks.reader.GetDeviceBySensorIDFunc = func(ctx context.Context, sensorID string) (types.Device, bool, error) {
+		return types.Device{}, false, nil
+	}
+
+	var created types.Device
+	mocks.writer.CreateOrUpdateDeviceFunc = func(ctx context.Context, d types.Device) error {
+		created = d
+		return nil
+	}
+
+	payload := `{"deviceID":"promoted-device","sensorProfile":{"decoder":"elsys"}}`
+	statusCode, body := do(t, http.MethodPost, baseUrl+"/api/v0/sensors/discovered-sensor/promote", strings.NewReader(payload), map[string]string{"Content-Type": "application/json", "Authorization": "Bearer admin-token"})
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", statusCode)
+	}
+
+	if created.DeviceID != "promoted-device" || created.SensorID != "discovered-sensor" || created.Tenant != "default" {
+		t.Fatalf("expected device for discovered sensor in tenant default, got %+v", created)
+	}
+	if !strings.Contains(string(body), `"deviceID":"promoted-device"`) {
+		t.Fatalf("expected response to contain device, got %s", string(body))
+	}
+}
+
+func testPromoteSensorNotAdmin(t *testing.T, baseUrl string, mocks deviceMocks) {
+	mocks.writer.CreateOrUpdateDeviceFunc = func(ctx context.Context, d types.Device) error {
+		t.Fatalf("expected no device to be created, got %+v", d)
+		return nil
+	}
+
+	payload := `{"deviceID":"promoted-device","sensorProfile":{"decoder":"elsys"}}`
+	statusCode, _ := do(t, http.MethodPost, baseUrl+"/api/v0/sensors/discovered-sensor/promote", strings.NewReader(payload), map[string]string{"Content-Type": "application/json"})
+	if statusCode != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d", statusCode)
+	}
+}
+
 func testCreateSensor(t *testing.T, baseUrl string, mocks sensorMocks) {
 	mocks.reader.GetFunc = func(ctx context.Context, sensorID string) (types.Sensor, bool, error) {
 		return types.Sensor{}, false, nil
@@ -1037,6 +2015,13 @@ func testPatchDevice(t *testing.T, baseUrl string, mocks deviceMocks) {
 		return nil
 	}
 
+	mocks.writer.AddDeviceLocationFunc = func(ctx context.Context, deviceID string, location types.Location, source string, observedAt time.Time) error {
+		if location != (types.Location{Latitude: 62.1, Longitude: 17.2}) || source != types.LocationSourceAPI {
+			t.Fatalf("expected the new location to be recorded from the api, got %+v from %q", location, source)
+		}
+		return nil
+	}
+
 	payload := `{"active":"true","interval":60,"latitude":"62.1","longitude":17.2,"types":["urn:test:1"]}`
 	statusCode, _ := do(t, http.MethodPatch, baseUrl+"/api/v0/devices/test-device-1", strings.NewReader(payload), map[string]string{"Content-Type": "application/json"})
 	if statusCode != http.StatusOK {
@@ -1169,6 +2154,261 @@ func createMultipartFileUpload(t *testing.T, fieldName, fileName, content string
 	return body, writer.FormDataContentType()
 }
 
+func testCreateView(t *testing.T, baseUrl string, vs *views.ViewServiceMock) {
+	vs.CreateFunc = func(ctx context.Context, v types.View, user string) (types.View, error) {
+		v.ID = "offline-pumps"
+		return v, nil
+	}
+
+	body := `{"name":"offline pumps","tenant":"default","filters":{"online":["false"],"metadata[kind]":["pump"]},"sortBy":"name","columns":["name","batteryLevel"]}`
+	statusCode, b := do(t, http.MethodPost, baseUrl+"/api/v0/views", strings.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", statusCode)
+	}
+	if !strings.Contains(string(b), `"id":"offline-pumps"`) {
+		t.Fatalf("expected the created view, got %s", string(b))
+	}
+
+	body = `{"name":"broken","tenant":"default","filters":{"online":["sometimes"]}}`
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/views", strings.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for invalid filters, got %d", statusCode)
+	}
+
+	body = `{"name":"other tenant","tenant":"other"}`
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/views", strings.NewReader(body), map[string]string{"Content-Type": "application/json"})
+	if statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", statusCode)
+	}
+
+	if len(vs.CreateCalls()) != 1 {
+		t.Fatalf("expected one view to be created, got %d", len(vs.CreateCalls()))
+	}
+}
+
+func testGetViewDevices(t *testing.T, baseUrl string, mocks deviceMocks, vs *views.ViewServiceMock) {
+	vs.ViewFunc = func(ctx context.Context, viewID, user string, tenants []string) (types.View, error) {
+		if viewID != "offline-pumps" {
+			return types.View{}, views.ErrViewNotFound
+		}
+		return types.View{ID: viewID, Name: "offline pumps", Tenant: "default", Filters: url.Values{"online": {"false"}, "profilename": {"pump"}}, SortBy: "name", SortDesc: true}, nil
+	}
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if query.Online == nil || *query.Online || !slices.Equal(query.ProfileNames, []string{"pump"}) {
+			t.Fatalf("expected the filters of the view, got %+v", query.Filters)
+		}
+		if query.SortBy != "name" || !query.SortDesc || query.Limit == nil || *query.Limit != 5 {
+			t.Fatalf("expected the sort order of the view and the limit of the request, got %+v", query.Filters)
+		}
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Limit: 5, Data: []types.Device{testDevice}}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/views/offline-pumps/devices?limit=5&online=true", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), testDevice.DeviceID) {
+		t.Fatalf("expected the devices of the view, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/views/unknown/devices", nil)
+	if statusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", statusCode)
+	}
+}
+
+func testSearch(t *testing.T, baseUrl string, ss *search.SearchServiceMock) {
+	ss.SearchFunc = func(ctx context.Context, query searchquery.Search) (types.Collection[types.SearchResult], error) {
+		if query.Text != "pump station" || *query.Limit != 5 || len(query.AllowedTenants) == 0 {
+			t.Fatalf("unexpected search %+v", query)
+		}
+		return types.Collection[types.SearchResult]{
+			Count:      2,
+			TotalCount: 2,
+			Limit:      5,
+			Data: []types.SearchResult{
+				{Kind: types.SearchKindDevice, ID: "pump-1", Name: "Pump station", Tenant: "default", Snippet: "<mark>Pump</mark> <mark>station</mark>", Rank: 0.9},
+				{Kind: types.SearchKindAlarm, ID: "pump-1", Name: "battery_level", Tenant: "default", Rank: 0.2},
+			},
+		}, nil
+	}
+
+	statusCode, body := do(t, http.MethodGet, baseUrl+"/api/v0/search?q=pump+station&limit=5", nil)
+	if statusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", statusCode)
+	}
+
+	var response struct {
+		Data []types.SearchResult `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("could not unmarshal response: %s", err.Error())
+	}
+	if len(response.Data) != 2 || response.Data[0].Kind != types.SearchKindDevice || response.Data[1].Kind != types.SearchKindAlarm {
+		t.Fatalf("expected devices and alarms together, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodGet, baseUrl+"/api/v0/search?q=+", nil)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400 without search text, got %d", statusCode)
+	}
+}
+
+func TestSubscribeToViewDevices(t *testing.T) {
+	viewPollInterval = 10 * time.Millisecond
+	defer func() { viewPollInterval = 30 * time.Second }()
+
+	policies := io.NopCloser(strings.NewReader(policiesMock))
+	defer policies.Close()
+
+	mocks := newDeviceMocks()
+	dm := devices.New(mocks.reader, mocks.writer, mocks.statusWriter, mocks.profiles, &messaging.MsgContextMock{}, &devices.Config{})
+	vs := &views.ViewServiceMock{
+		ViewFunc: func(ctx context.Context, viewID, user string, tenants []string) (types.View, error) {
+			return types.View{ID: viewID, Name: "offline", Tenant: "default", Filters: url.Values{"online": {"false"}}}, nil
+		},
+	}
+
+	app := application.New(dm, newNoopSensorAPIService(), &alarms.AlarmAPIServiceMock{}, &quarantine.QuarantineServiceMock{}, vs, &search.SearchServiceMock{}, true)
+
+	mux := http.NewServeMux()
+	RegisterHandlers(t.Context(), mux, policies, app)
+
+	// Close waits for the stream to end after the request is cancelled
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	polls := 0
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		if query.Cursor == nil {
+			t.Fatalf("expected the members to be paged by cursor")
+		}
+		polls++
+		members := []types.Device{{DeviceID: "device-a"}, {DeviceID: "device-b"}}
+		if polls > 1 {
+			members = []types.Device{{DeviceID: "device-b"}, {DeviceID: "device-c"}}
+		}
+		return types.Collection[types.Device]{Count: 2, TotalCount: 2, Data: members}, nil
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v0/views/offline/devices", nil)
+	req.Header.Set("Authorization", "Bearer mock-token")
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	changes := []types.ViewMembership{}
+	scanner := bufio.NewScanner(resp.Body)
+	for len(changes) < 2 && scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var m types.ViewMembership
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			t.Fatalf("could not unmarshal membership: %s", err.Error())
+		}
+		changes = append(changes, m)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected two membership events, got %d", len(changes))
+	}
+	if !slices.Equal(changes[0].Joined, []string{"device-a", "device-b"}) || len(changes[0].Left) != 0 {
+		t.Fatalf("expected all devices to join first, got %+v", changes[0])
+	}
+	if !slices.Equal(changes[1].Joined, []string{"device-c"}) || !slices.Equal(changes[1].Left, []string{"device-a"}) {
+		t.Fatalf("expected device-c to join and device-a to leave, got %+v", changes[1])
+	}
+}
+
+func testGeofences(t *testing.T, baseUrl string, mocks deviceMocks) {
+	located := testDevice
+	located.Location = types.Location{Latitude: 62.39, Longitude: 17.30}
+
+	mocks.reader.QueryFunc = func(ctx context.Context, query dmquery.DeviceFilters) (types.Collection[types.Device], error) {
+		return types.Collection[types.Device]{Count: 1, TotalCount: 1, Data: []types.Device{located}}, nil
+	}
+
+	stored := map[string]types.Geofence{}
+	mocks.reader.GetGeofencesFunc = func(ctx context.Context, query dmquery.GeofenceFilters) (types.Collection[types.Geofence], error) {
+		g, ok := stored[query.ID]
+		if !ok {
+			return types.Collection[types.Geofence]{}, nil
+		}
+		return types.Collection[types.Geofence]{Count: 1, TotalCount: 1, Data: []types.Geofence{g}}, nil
+	}
+	mocks.writer.CreateGeofenceFunc = func(ctx context.Context, g types.Geofence) error {
+		if _, ok := stored[g.ID]; ok {
+			return devices.ErrGeofenceAlreadyExist
+		}
+		stored[g.ID] = g
+		return nil
+	}
+	mocks.writer.UpdateGeofenceFunc = func(ctx context.Context, g types.Geofence) error {
+		stored[g.ID] = g
+		return nil
+	}
+	mocks.writer.DeleteGeofenceFunc = func(ctx context.Context, geofenceID string) error {
+		delete(stored, geofenceID)
+		return nil
+	}
+
+	jsonHeader := map[string]string{"Content-Type": "application/json"}
+
+	statusCode, body := do(t, http.MethodPost, baseUrl+"/api/v0/geofences", strings.NewReader(`{"id":"pier","tenant":"default","deviceID":"test-device-1","radius":50}`), jsonHeader)
+	if statusCode != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d", statusCode)
+	}
+	if !strings.Contains(string(body), `"center":{"latitude":62.39,"longitude":17.3},"radius":50`) {
+		t.Fatalf("expected the fence to be centered on the device, got %s", string(body))
+	}
+
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/geofences", strings.NewReader(`{"id":"pier","tenant":"default","deviceID":"test-device-1","radius":50}`), jsonHeader)
+	if statusCode != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/geofences", strings.NewReader(`{"tenant":"default","tag":"lifebuoy"}`), jsonHeader)
+	if statusCode != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodPost, baseUrl+"/api/v0/geofences", strings.NewReader(`{"tenant":"other","tag":"lifebuoy","center":{"latitude":62.39,"longitude":17.3},"radius":50}`), jsonHeader)
+	if statusCode != http.StatusUnauthorized {
+		t.Fatalf("expected status 401, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodPut, baseUrl+"/api/v0/geofences/pier", strings.NewReader(`{"tenant":"default","deviceID":"test-device-1","radius":100}`), jsonHeader)
+	if statusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", statusCode)
+	}
+	if stored["pier"].Radius != 100 {
+		t.Fatalf("expected the fence to be updated, got %+v", stored["pier"])
+	}
+
+	statusCode, _ = do(t, http.MethodDelete, baseUrl+"/api/v0/geofences/pier", nil)
+	if statusCode != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", statusCode)
+	}
+
+	statusCode, _ = do(t, http.MethodDelete, baseUrl+"/api/v0/geofences/pier", nil)
+	if statusCode != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", statusCode)
+	}
+}
+
 func do(t *testing.T, method, url string, body io.Reader, headers ...map[string]string) (int, []byte) {
 	req, _ := http.NewRequest(method, url, body)
 	req.Header.Set("Authorization", "Bearer mock-token")
@@ -1193,6 +2433,18 @@ func do(t *testing.T, method, url string, body io.Reader, headers ...map[string]
 	return resp.StatusCode, b
 }
 
+var discoveredTenant = "default"
+var discoveredFirstSeen = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var discoveredSensor = types.Sensor{
+	SensorID:      "discovered-sensor",
+	SensorProfile: &types.SensorProfile{Name: "unknown", Decoder: "unknown"},
+	Tenant:        &discoveredTenant,
+	Discovered:    true,
+	FirstSeen:     &discoveredFirstSeen,
+	LastSeen:      &discoveredFirstSeen,
+}
+
 var testDevice = types.Device{SensorID: "test-sensor-1", DeviceID: "test-device-1", Tenant: "default"}
 
 var testSensor = types.Sensor{
@@ -1219,7 +2471,9 @@ package example.authz
 default allow := false
 
 allow = response {
+	admin := input.token == "admin-token"
 	response := {
-		"tenants": ["default"]
+		"tenants": ["default"],
+		"admin": admin
 	}
 }`
@@ -20,6 +20,8 @@ type tenantsContextKey struct {
 }
 
 var allowedTenantsCtxKey = &tenantsContextKey{"allowed-tenants"}
+var userCtxKey = &tenantsContextKey{"user"}
+var adminCtxKey = &tenantsContextKey{"admin"}
 
 var tracer = otel.Tracer("iot-agent/authz")
 
@@ -115,6 +117,17 @@ func NewAuthenticator(ctx context.Context, policies io.Reader) (func(http.Handle
 				}
 
 				ctx := context.WithValue(r.Context(), allowedTenantsCtxKey, tenants)
+
+				// The policy may also identify the user, which is needed for private views
+				if user, ok := result["user"].(string); ok && user != "" {
+					ctx = WithUser(ctx, user)
+				}
+
+				// Only admins may see messages that do not belong to any tenant
+				if admin, ok := result["admin"].(bool); ok && admin {
+					ctx = WithAdmin(ctx, admin)
+				}
+
 				r = r.WithContext(ctx)
 			}
 
@@ -140,3 +153,23 @@ func GetAllowedTenantsFromContext(ctx context.Context) []string {
 func WithAllowedTenants(ctx context.Context, tenants []string) context.Context {
 	return context.WithValue(ctx, allowedTenantsCtxKey, tenants)
 }
+
+// GetUserFromContext returns the user identified by the authz policy, or an empty string
+func GetUserFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userCtxKey).(string)
+	return user
+}
+
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userCtxKey, user)
+}
+
+// IsAdminFromContext returns true if the authz policy identified the client as an admin
+func IsAdminFromContext(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminCtxKey).(bool)
+	return admin
+}
+
+func WithAdmin(ctx context.Context, admin bool) context.Context {
+	return context.WithValue(ctx, adminCtxKey, admin)
+}
@@ -65,6 +65,26 @@ func queryDevicesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 			return
 		}
 
+		// a search posts the area as a GeoJSON geometry, as it may be too large for a query string
+		if r.Method == http.MethodPost {
+			defer r.Body.Close()
+
+			b, readErr := io.ReadAll(r.Body)
+			if readErr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			within, parseErr := polygonsFromGeoJSON(b)
+			if parseErr != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(parseErr.Error()))
+				return
+			}
+
+			query.Within = append(query.Within, within...)
+		}
+
 		collection, err := svc.Query(ctx, query)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -79,10 +99,20 @@ func queryDevicesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 			Count:        collection.Count,
 		}
 
-		links := createLinks(r.URL, meta)
+		if len(query.Facets) > 0 {
+			stats, err := svc.Stats(ctx, query)
+			if err != nil {
+				logger.Error("could not count device facets", "err", err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			meta.Facets = stats.Facets
+		}
+
+		links := createPageLinks(r.URL, meta, query.Cursor, collection.Cursor)
 
 		if wantsGeoJSON(r) {
-			response, err := NewFeatureCollectionWithDevices(collection.Data)
+			response, err := NewFeatureCollectionWithDevices(collection.Data, query.Fields)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
@@ -107,7 +137,7 @@ func queryDevicesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 		if wantsTextCSV(r) {
 			w.Header().Set("Content-Type", "text/csv")
 
-			err := writeCsvWithDevices(w, collection.Data)
+			err := writeCsvWithDevices(w, collection.Data, query.Fields)
 			if err != nil {
 				w.WriteHeader(http.StatusInternalServerError)
 				return
@@ -116,9 +146,15 @@ func queryDevicesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 			return
 		}
 
+		data, err := devicesWithFields(collection.Data, query.Fields)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
 		response := ApiResponse{
 			Meta:  meta,
-			Data:  collection.Data,
+			Data:  data,
 			Links: links,
 		}
 
@@ -128,6 +164,144 @@ func queryDevicesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 	}
 }
 
+func getDeviceStatsHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-device-stats")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := deviceQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		stats, err := svc.Stats(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrMissingTenant) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			logger.Error("could not get device stats", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ApiResponse{Data: stats}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getDeviceClustersHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-device-clusters")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := clusterQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		clusters, err := svc.Clusters(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrInvalidClusters) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not fetch device clusters", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: uint64(len(clusters)), Count: uint64(len(clusters))}
+
+		if wantsGeoJSON(r) {
+			response, err := NewFeatureCollectionWithClusters(clusters)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			response.Meta = meta
+
+			b, err := json.Marshal(response)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/geo+json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(b)
+
+			return
+		}
+
+		response := ApiResponse{
+			Meta: meta,
+			Data: clusters,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getDeviceTileHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-device-tile")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, x, y, parseErr := tileQueryFromRequest(r, allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		clusters, err := svc.Clusters(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrInvalidClusters) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not fetch device clusters", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+		w.WriteHeader(http.StatusOK)
+		w.Write(NewVectorTileWithClusters(query.Zoom, x, y, clusters))
+	}
+}
+
 func getDeviceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var err error
@@ -146,7 +320,14 @@ func getDeviceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Handl
 
 		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("device_id", deviceID))
 
-		device, err := svc.Device(ctx, deviceID, allowedTenants)
+		include, parseErr := includeFromValues(r.URL.Query())
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		device, err := svc.Device(ctx, deviceID, allowedTenants, include...)
 		if err != nil {
 			if errors.Is(err, devices.ErrDeviceNotFound) {
 				w.WriteHeader(http.StatusNotFound)
@@ -191,6 +372,37 @@ func getDeviceStatusHandler(log *slog.Logger, svc devices.DeviceAPIService) http
 			return
 		}
 
+		if query.Bucket != "" {
+			var aggregates types.Collection[types.SensorStatusAggregate]
+			aggregates, err = svc.StatusAggregates(ctx, deviceID, query)
+			if err != nil {
+				if errors.Is(err, devices.ErrDeviceNotFound) {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				logger.Error("could not fetch device status aggregates", slog.String("device_id", deviceID), "err", err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			if wantsTextCSV(r) {
+				w.Header().Set("Content-Type", "text/csv")
+				err = writeCsvWithStatusAggregates(w, aggregates.Data)
+				return
+			}
+
+			response := ApiResponse{
+				Data: aggregates.Data,
+				Meta: &meta{TotalRecords: aggregates.TotalCount, Count: aggregates.Count, Offset: &aggregates.Offset, Limit: &aggregates.Limit},
+			}
+
+			w.Header().Add("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(response.Byte())
+			return
+		}
+
 		statuses, err := svc.Status(ctx, deviceID, query)
 		if err != nil {
 			if errors.Is(err, devices.ErrDeviceNotFound) {
@@ -203,6 +415,12 @@ func getDeviceStatusHandler(log *slog.Logger, svc devices.DeviceAPIService) http
 			return
 		}
 
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = writeCsvWithStatuses(w, statuses.Data)
+			return
+		}
+
 		response := ApiResponse{
 			Data: statuses.Data,
 			Meta: &meta{TotalRecords: statuses.TotalCount, Count: statuses.Count},
@@ -261,7 +479,7 @@ func getDeviceMeasurementsHandler(log *slog.Logger, svc devices.DeviceAPIService
 
 		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
 
-		ctx, span := tracer.Start(r.Context(), "get-device-status")
+		ctx, span := tracer.Start(r.Context(), "get-device-measurements")
 		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
 		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
 
@@ -286,12 +504,23 @@ func getDeviceMeasurementsHandler(log *slog.Logger, svc devices.DeviceAPIService
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, devices.ErrInvalidBucket) || errors.Is(err, devices.ErrInvalidAggregate) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
 
-			logger.Error("could not fetch device details", slog.String("device_id", deviceID), "err", err.Error())
+			logger.Error("could not fetch device measurements", slog.String("device_id", deviceID), "err", err.Error())
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = writeCsvWithMeasurements(w, result.Data)
+			return
+		}
+
 		response := ApiResponse{
 			Data: result.Data,
 			Meta: &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count},
@@ -303,6 +532,129 @@ func getDeviceMeasurementsHandler(log *slog.Logger, svc devices.DeviceAPIService
 	}
 }
 
+func getDeviceLocationsHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-device-locations")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		deviceID := r.PathValue("id")
+		if deviceID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("device_id", deviceID))
+
+		query, parseErr := locationsQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.Locations(ctx, deviceID, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrDeviceNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			logger.Error("could not fetch device locations", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+
+		if wantsGeoJSON(r) {
+			response := NewFeatureCollectionWithLocations(deviceID, result.Data, strings.EqualFold(r.URL.Query().Get("geometry"), "lineString"))
+			response.Meta = meta
+
+			b, err := json.Marshal(response)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/geo+json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(b)
+
+			return
+		}
+
+		response := ApiResponse{
+			Data: result.Data,
+			Meta: meta,
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getDeviceAvailabilityHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-device-availability")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		deviceID := r.PathValue("id")
+		if deviceID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("device_id", deviceID))
+
+		query, parseErr := availabilityQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		availability, err := svc.Availability(ctx, deviceID, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrDeviceNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, devices.ErrInvalidAvailability) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not compute device availability", slog.String("device_id", deviceID), "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = writeCsvWithAvailability(w, []types.Availability{availability})
+			return
+		}
+
+		response := ApiResponse{Data: availability}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
 func createDeviceHandler(log *slog.Logger, app application.Management) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var err error
@@ -363,6 +715,11 @@ func createDeviceHandler(log *slog.Logger, app application.Management) http.Hand
 					w.WriteHeader(http.StatusConflict)
 					return
 				}
+				if errors.Is(err, devices.ErrInvalidCalendar) {
+					logger.Error("invalid reporting calendar", "device_id", d.DeviceID, "err", err.Error())
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
 
 				logger.Error("unable to create device", "device_id", d.DeviceID, "err", err.Error())
 				w.WriteHeader(http.StatusInternalServerError)
@@ -430,6 +787,11 @@ func updateDeviceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.Ha
 				w.WriteHeader(http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, devices.ErrInvalidCalendar) {
+				logger.Error("invalid reporting calendar", "device_id", d.DeviceID, "err", err.Error())
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
 			logger.Error("unable to update device", "device_id", d.DeviceID, "err", err.Error())
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
+)
+
+func queryGeofencesHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "query-geofences")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := geofenceQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.Geofences(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrMissingTenant) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			logger.Error("could not query geofences", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getGeofenceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-geofence")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		geofenceID := r.PathValue("id")
+		if geofenceID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		g, err := svc.Geofence(ctx, geofenceID, allowedTenants)
+		if err != nil {
+			if errors.Is(err, devices.ErrGeofenceNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			logger.Error("could not fetch geofence", "geofence_id", geofenceID, "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ApiResponse{Data: g}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func createGeofenceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "create-geofence")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		g, ok := geofenceFromRequest(w, r, logger)
+		if !ok {
+			return
+		}
+
+		if !slices.Contains(allowedTenants, g.Tenant) {
+			logger.Error("not allowed to create geofence with current tenant", "tenant", g.Tenant)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		g, err = svc.CreateGeofence(ctx, g)
+		if err != nil {
+			writeGeofenceError(w, logger, err)
+			return
+		}
+
+		response := ApiResponse{Data: g}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(response.Byte())
+	}
+}
+
+func updateGeofenceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "update-geofence")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		g, ok := geofenceFromRequest(w, r, logger)
+		if !ok {
+			return
+		}
+
+		if !slices.Contains(allowedTenants, g.Tenant) {
+			logger.Error("not allowed to update geofence with current tenant", "geofence_id", g.ID, "tenant", g.Tenant)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		id := r.PathValue("id")
+		if g.ID == "" {
+			g.ID = id
+		}
+
+		if id != g.ID {
+			logger.Debug("payload contains mismatched geofence ID", "id", id, "geofenceID", g.ID)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = svc.UpdateGeofence(ctx, g, allowedTenants)
+		if err != nil {
+			writeGeofenceError(w, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func deleteGeofenceHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "delete-geofence")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		geofenceID := r.PathValue("id")
+		if geofenceID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("geofence_id", geofenceID))
+
+		err = svc.DeleteGeofence(ctx, geofenceID, allowedTenants)
+		if err != nil {
+			writeGeofenceError(w, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func geofenceFromRequest(w http.ResponseWriter, r *http.Request, logger *slog.Logger) (types.Geofence, bool) {
+	if !isApplicationJson(r) {
+		logger.Error("Unsupported MediaType")
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return types.Geofence{}, false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Error("unable to read body", "err", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return types.Geofence{}, false
+	}
+
+	var g types.Geofence
+	err = json.Unmarshal(body, &g)
+	if err != nil {
+		logger.Error("unable to unmarshal body", "body", string(body), "err", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return types.Geofence{}, false
+	}
+
+	return g, true
+}
+
+func writeGeofenceError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, devices.ErrGeofenceNotFound), errors.Is(err, devices.ErrDeviceNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, devices.ErrGeofenceAlreadyExist):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, devices.ErrInvalidGeofence):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	default:
+		logger.Error("unable to store geofence", "err", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
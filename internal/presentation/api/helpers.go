@@ -1,16 +1,21 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	alarmquery "github.com/diwise/iot-device-mgmt/internal/application/alarms/query"
 	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	searchquery "github.com/diwise/iot-device-mgmt/internal/application/search/query"
 	sensorquery "github.com/diwise/iot-device-mgmt/internal/application/sensors/query"
+	viewquery "github.com/diwise/iot-device-mgmt/internal/application/views/query"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 )
 
@@ -50,6 +55,54 @@ func createLinks(u *url.URL, m *meta) *links {
 	return links
 }
 
+// createPageLinks links pages by cursor when the request pages with one, and by offset otherwise.
+// A cursor has no previous or last page, and the next page is left out on the last page.
+func createPageLinks(u *url.URL, m *meta, cursor, next *types.Cursor) *links {
+	if cursor == nil {
+		return createLinks(u, m)
+	}
+
+	m.Offset = nil
+	query := u.Query()
+
+	newURL := func(c string) *string {
+		query.Set("cursor", c)
+		u.RawQuery = query.Encode()
+		urlValue := u.String()
+		return &urlValue
+	}
+
+	links := &links{
+		Self:  newURL(cursor.String()),
+		First: newURL(""),
+	}
+
+	if next != nil {
+		links.Next = newURL(next.String())
+	}
+
+	return links
+}
+
+// cursorFromValues reads the cursor to page by, or nil when paging by offset. An empty cursor
+// is the first page.
+func cursorFromValues(values url.Values) (*types.Cursor, error) {
+	if !values.Has("cursor") {
+		return nil, nil
+	}
+
+	if values.Has("offset") {
+		return nil, fmt.Errorf("cursor and offset can not be used together")
+	}
+
+	c, err := types.ParseCursor(values.Get("cursor"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
 func isMultipartFormData(r *http.Request) bool {
 	contentType := r.Header.Get("Content-Type")
 	return strings.Contains(contentType, "multipart/form-data")
@@ -70,6 +123,11 @@ func wantsTextCSV(r *http.Request) bool {
 	return strings.Contains(contentType, "text/csv")
 }
 
+func wantsEventStream(r *http.Request) bool {
+	contentType := r.Header.Get("Accept")
+	return strings.Contains(contentType, "text/event-stream")
+}
+
 func sensorQueryFromValues(values url.Values) (sensorquery.Sensors, error) {
 	query := sensorquery.Sensors{}
 
@@ -109,9 +167,43 @@ func sensorQueryFromValues(values url.Values) (sensorquery.Sensors, error) {
 			query.Types = append([]string(nil), value...)
 		case "search":
 			query.Search = value[0]
+		case "discovered":
+			parsed, err := strconv.ParseBool(value[0])
+			if err != nil {
+				return sensorquery.Sensors{}, fmt.Errorf("invalid discovered value: %w", err)
+			}
+			query.Discovered = &parsed
+		case "within":
+			polygons, err := polygonsFromGeoJSON([]byte(value[0]))
+			if err != nil {
+				return sensorquery.Sensors{}, err
+			}
+			query.Within = polygons
+		case "near":
+			near, err := locationFromValue(value[0])
+			if err != nil {
+				return sensorquery.Sensors{}, err
+			}
+			query.Near = near
+		case "radius":
+			radius, err := radiusFromValue(value[0])
+			if err != nil {
+				return sensorquery.Sensors{}, err
+			}
+			query.Radius = radius
 		}
 	}
 
+	if query.Radius > 0 && query.Near == nil {
+		return sensorquery.Sensors{}, fmt.Errorf("radius requires near")
+	}
+
+	cursor, err := cursorFromValues(values)
+	if err != nil {
+		return sensorquery.Sensors{}, err
+	}
+	query.Cursor = cursor
+
 	return query, nil
 }
 
@@ -144,6 +236,12 @@ func alarmQueryFromValues(values url.Values, allowedTenants []string) (alarmquer
 		}
 	}
 
+	cursor, err := cursorFromValues(values)
+	if err != nil {
+		return alarmquery.Alarms{}, err
+	}
+	query.Cursor = cursor
+
 	return query, nil
 }
 
@@ -153,19 +251,133 @@ func deviceQueryFromValues(values url.Values, allowedTenants []string) (dmquery.
 		return dmquery.DeviceFilters{}, err
 	}
 
+	include, err := includeFromValues(values)
+	if err != nil {
+		return dmquery.DeviceFilters{}, err
+	}
+
+	facets, err := facetsFromValues(values)
+	if err != nil {
+		return dmquery.DeviceFilters{}, err
+	}
+
+	fields, err := fieldsFromValues(values)
+	if err != nil {
+		return dmquery.DeviceFilters{}, err
+	}
+
+	filters.Cursor, err = cursorFromValues(values)
+	if err != nil {
+		return dmquery.DeviceFilters{}, err
+	}
+
 	return dmquery.DeviceFilters{
 		Filters: filters,
 		Urns:    append([]string(nil), values["urns"]...),
+		Include: include,
+		Facets:  facets,
+		Fields:  fields,
 	}, nil
 }
 
+// fieldsFromValues reads a comma separated list of the device fields to respond with.
+func fieldsFromValues(values url.Values) ([]string, error) {
+	var fields []string
+
+	for _, value := range values["fields"] {
+		for v := range strings.SplitSeq(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if !slices.Contains(dmquery.DeviceFields, v) {
+				return nil, fmt.Errorf("invalid fields value %q, must be one of %s", v, strings.Join(dmquery.DeviceFields, ", "))
+			}
+			if !slices.Contains(fields, v) {
+				fields = append(fields, v)
+			}
+		}
+	}
+
+	return fields, nil
+}
+
+// facetsFromValues reads a comma separated list of fields to count matching devices by.
+func facetsFromValues(values url.Values) ([]string, error) {
+	var facets []string
+
+	for _, value := range values["facets"] {
+		for v := range strings.SplitSeq(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if !slices.Contains(dmquery.Facets, v) {
+				return nil, fmt.Errorf("invalid facets value %q, must be one of %s", v, strings.Join(dmquery.Facets, ", "))
+			}
+			if !slices.Contains(facets, v) {
+				facets = append(facets, v)
+			}
+		}
+	}
+
+	return facets, nil
+}
+
+// includeFromValues reads a comma separated list of related data to embed in devices.
+func includeFromValues(values url.Values) ([]string, error) {
+	var include []string
+
+	for _, value := range values["include"] {
+		for v := range strings.SplitSeq(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			if !slices.Contains(dmquery.Includes, v) {
+				return nil, fmt.Errorf("invalid include value %q, must be one of %s", v, strings.Join(dmquery.Includes, ", "))
+			}
+			if !slices.Contains(include, v) {
+				include = append(include, v)
+			}
+		}
+	}
+
+	return include, nil
+}
+
 func deviceStatusQueryFromValues(values url.Values, allowedTenants []string) (dmquery.StatusFilters, error) {
 	filters, err := filtersFromValues(values, allowedTenants)
 	if err != nil {
 		return dmquery.StatusFilters{}, err
 	}
 
-	return dmquery.StatusFilters{Filters: filters}, nil
+	query := dmquery.StatusFilters{Filters: filters}
+
+	if v := values.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dmquery.StatusFilters{}, fmt.Errorf("invalid from value: %w", err)
+		}
+		query.From = &from
+	}
+
+	if v := values.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dmquery.StatusFilters{}, fmt.Errorf("invalid to value: %w", err)
+		}
+		query.To = &to
+	}
+
+	if v := values.Get("bucket"); v != "" {
+		if v != types.BucketHour && v != types.BucketDay {
+			return dmquery.StatusFilters{}, fmt.Errorf("invalid bucket value, must be %s or %s", types.BucketHour, types.BucketDay)
+		}
+		query.Bucket = v
+	}
+
+	return query, nil
 }
 
 func deviceMeasurementsQueryFromValues(values url.Values, allowedTenants []string) (dmquery.MeasurementFilters, error) {
@@ -174,7 +386,57 @@ func deviceMeasurementsQueryFromValues(values url.Values, allowedTenants []strin
 		return dmquery.MeasurementFilters{}, err
 	}
 
-	return dmquery.MeasurementFilters{Filters: filters}, nil
+	query := dmquery.MeasurementFilters{
+		Filters:       filters,
+		MeasurementID: values.Get("id"),
+	}
+
+	if v := values.Get("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("invalid from value: %w", err)
+		}
+		query.From = &from
+	}
+
+	if v := values.Get("to"); v != "" {
+		to, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("invalid to value: %w", err)
+		}
+		query.To = &to
+	}
+
+	if v := values.Get("latest"); v != "" {
+		latest, err := strconv.ParseBool(v)
+		if err != nil {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("invalid latest value: %w", err)
+		}
+		query.Latest = latest
+	}
+
+	if v := values.Get("bucket"); v != "" {
+		if v != types.BucketHour && v != types.BucketDay {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("invalid bucket value, must be %s or %s", types.BucketHour, types.BucketDay)
+		}
+		query.Bucket = v
+	}
+
+	if v := values.Get("aggregate"); v != "" {
+		if !slices.Contains(dmquery.MeasurementAggregates, v) {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("invalid aggregate value, must be one of %s", strings.Join(dmquery.MeasurementAggregates, ", "))
+		}
+		if query.Bucket == "" {
+			return dmquery.MeasurementFilters{}, fmt.Errorf("aggregate requires a bucket")
+		}
+		query.Aggregate = v
+	}
+
+	if query.Latest && query.Bucket != "" {
+		return dmquery.MeasurementFilters{}, fmt.Errorf("latest can not be combined with bucket")
+	}
+
+	return query, nil
 }
 
 func filtersFromValues(values url.Values, allowedTenants []string) (dmquery.Filters, error) {
@@ -245,6 +507,57 @@ func filtersFromValues(values url.Values, allowedTenants []string) (dmquery.Filt
 				return dmquery.Filters{}, err
 			}
 			filters.LastSeen = parsed
+		case "batterydepletesbefore":
+			parsed, err := parseDate(value[0])
+			if err != nil {
+				return dmquery.Filters{}, fmt.Errorf("invalid batteryDepletesBefore value: %w", err)
+			}
+			filters.BatteryDepletesBefore = parsed
+		case "within":
+			polygons, err := polygonsFromGeoJSON([]byte(value[0]))
+			if err != nil {
+				return dmquery.Filters{}, err
+			}
+			filters.Within = polygons
+		case "near":
+			near, err := locationFromValue(value[0])
+			if err != nil {
+				return dmquery.Filters{}, err
+			}
+			filters.Near = near
+		case "radius":
+			radius, err := radiusFromValue(value[0])
+			if err != nil {
+				return dmquery.Filters{}, err
+			}
+			filters.Radius = radius
+		case "locatedwithin":
+			polygons, err := polygonsFromGeoJSON([]byte(value[0]))
+			if err != nil {
+				return dmquery.Filters{}, err
+			}
+			filters.LocatedWithin = polygons
+		case "at":
+			at, err := parseDate(value[0])
+			if err != nil {
+				return dmquery.Filters{}, fmt.Errorf("invalid at value: %w", err)
+			}
+			filters.At = at
+		case "filter":
+			expr, err := dmquery.ParseFilter(value[0])
+			if err != nil {
+				return dmquery.Filters{}, err
+			}
+			filters.Filter = expr
+		case "linkquality":
+			for _, v := range value {
+				for class := range strings.SplitSeq(v, ",") {
+					if class != types.LinkQualityGood && class != types.LinkQualityMarginal && class != types.LinkQualityPoor {
+						return dmquery.Filters{}, fmt.Errorf("invalid linkQuality value %q", class)
+					}
+					filters.LinkQuality = append(filters.LinkQuality, class)
+				}
+			}
 		default:
 			if strings.HasPrefix(key, "metadata[") && strings.HasSuffix(key, "]") {
 				metadataKey := strings.TrimPrefix(strings.TrimSuffix(key, "]"), "metadata[")
@@ -257,6 +570,18 @@ func filtersFromValues(values url.Values, allowedTenants []string) (dmquery.Filt
 		filters.Metadata = metadata
 	}
 
+	if filters.Radius > 0 && filters.Near == nil {
+		return dmquery.Filters{}, fmt.Errorf("radius requires near")
+	}
+
+	if (len(filters.LocatedWithin) > 0) != (filters.At != nil) {
+		return dmquery.Filters{}, fmt.Errorf("locatedWithin and at must be used together")
+	}
+
+	if strings.EqualFold(filters.SortBy, "distance") && filters.Near == nil {
+		return dmquery.Filters{}, fmt.Errorf("sorting by distance requires near")
+	}
+
 	return filters, nil
 }
 
@@ -311,6 +636,312 @@ func boundsFromValue(value string) (*types.Bounds, error) {
 	return &types.Bounds{MinLat: minLat, MinLon: minLon, MaxLat: maxLat, MaxLon: maxLon}, nil
 }
 
+// polygonsFromGeoJSON accepts a GeoJSON Polygon or MultiPolygon geometry, or a Feature with one.
+func polygonsFromGeoJSON(b []byte) ([]types.Polygon, error) {
+	var g struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+		Geometry    json.RawMessage `json:"geometry"`
+	}
+
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, fmt.Errorf("invalid geometry: %w", err)
+	}
+
+	var polygons []types.Polygon
+
+	switch g.Type {
+	case "Feature":
+		return polygonsFromGeoJSON(g.Geometry)
+	case "Polygon":
+		var p types.Polygon
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, fmt.Errorf("invalid polygon: %w", err)
+		}
+		polygons = []types.Polygon{p}
+	case "MultiPolygon":
+		if err := json.Unmarshal(g.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid geometry type %q, must be Polygon or MultiPolygon", g.Type)
+	}
+
+	if len(polygons) == 0 {
+		return nil, fmt.Errorf("geometry has no polygons")
+	}
+
+	for _, p := range polygons {
+		if len(p) == 0 {
+			return nil, fmt.Errorf("polygon has no rings")
+		}
+		for _, ring := range p {
+			if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				return nil, fmt.Errorf("polygon rings must be closed and have at least four positions")
+			}
+			for _, pos := range ring {
+				if pos[0] < -180 || pos[0] > 180 || pos[1] < -90 || pos[1] > 90 {
+					return nil, fmt.Errorf("invalid position [%g,%g]", pos[0], pos[1])
+				}
+			}
+		}
+	}
+
+	return polygons, nil
+}
+
+// locationFromValue parses a lat,lon pair.
+func locationFromValue(value string) (*types.Location, error) {
+	lat, lon, ok := strings.Cut(value, ",")
+	if !ok {
+		return nil, fmt.Errorf("invalid near value, must be lat,lon")
+	}
+
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return nil, fmt.Errorf("invalid near latitude %q", lat)
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return nil, fmt.Errorf("invalid near longitude %q", lon)
+	}
+
+	return &types.Location{Latitude: latitude, Longitude: longitude}, nil
+}
+
+func radiusFromValue(value string) (float64, error) {
+	radius, err := strconv.ParseFloat(value, 64)
+	if err != nil || radius <= 0 {
+		return 0, fmt.Errorf("invalid radius value, must be a positive number of meters")
+	}
+	return radius, nil
+}
+
+// parseDate parses a date (2006-01-02), as midnight UTC, or a RFC 3339 timestamp.
+func parseDate(value string) (*time.Time, error) {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		parsed, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &parsed, nil
+}
+
+func batteryQueryFromValues(values url.Values, allowedTenants []string) (dmquery.BatteryFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "before"), allowedTenants)
+	if err != nil {
+		return dmquery.BatteryFilters{}, err
+	}
+
+	query := dmquery.BatteryFilters{Filters: filters}
+
+	if v := values.Get("before"); v != "" {
+		before, err := parseDate(v)
+		if err != nil {
+			return dmquery.BatteryFilters{}, fmt.Errorf("invalid before value: %w", err)
+		}
+		query.DepletesBefore = before
+	}
+
+	return query, nil
+}
+
+func coverageQueryFromValues(values url.Values, allowedTenants []string) (dmquery.CoverageFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "size"), allowedTenants)
+	if err != nil {
+		return dmquery.CoverageFilters{}, err
+	}
+
+	query := dmquery.CoverageFilters{Filters: filters, Size: 10}
+
+	if v := values.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return dmquery.CoverageFilters{}, fmt.Errorf("invalid size value: %w", err)
+		}
+		query.Size = size
+	}
+
+	return query, nil
+}
+
+func locationsQueryFromValues(values url.Values, allowedTenants []string) (dmquery.LocationFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "from", "to", "geometry"), allowedTenants)
+	if err != nil {
+		return dmquery.LocationFilters{}, err
+	}
+
+	query := dmquery.LocationFilters{Filters: filters}
+
+	if v := values.Get("from"); v != "" {
+		query.From, err = parseDate(v)
+		if err != nil {
+			return dmquery.LocationFilters{}, fmt.Errorf("invalid from value: %w", err)
+		}
+	}
+
+	if v := values.Get("to"); v != "" {
+		query.To, err = parseDate(v)
+		if err != nil {
+			return dmquery.LocationFilters{}, fmt.Errorf("invalid to value: %w", err)
+		}
+	}
+
+	return query, nil
+}
+
+func geofenceQueryFromValues(values url.Values, allowedTenants []string) (dmquery.GeofenceFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "tag"), allowedTenants)
+	if err != nil {
+		return dmquery.GeofenceFilters{}, err
+	}
+
+	return dmquery.GeofenceFilters{Filters: filters, Tag: values.Get("tag")}, nil
+}
+
+func viewQueryFromValues(values url.Values, user string, allowedTenants []string) (viewquery.Views, error) {
+	query := viewquery.Views{User: user, AllowedTenants: allowedTenants}
+
+	if values.Has("limit") {
+		limit, err := strconv.Atoi(values.Get("limit"))
+		if err != nil {
+			return viewquery.Views{}, fmt.Errorf("invalid limit value: %w", err)
+		}
+		query.Limit = &limit
+	}
+
+	if values.Has("offset") {
+		offset, err := strconv.Atoi(values.Get("offset"))
+		if err != nil {
+			return viewquery.Views{}, fmt.Errorf("invalid offset value: %w", err)
+		}
+		query.Offset = &offset
+	}
+
+	return query, nil
+}
+
+func searchQueryFromValues(values url.Values, allowedTenants []string) (searchquery.Search, error) {
+	query := searchquery.Search{Text: strings.TrimSpace(values.Get("q")), AllowedTenants: allowedTenants}
+
+	if query.Text == "" {
+		return searchquery.Search{}, errors.New("missing search text q")
+	}
+
+	if values.Has("limit") {
+		limit, err := strconv.Atoi(values.Get("limit"))
+		if err != nil {
+			return searchquery.Search{}, fmt.Errorf("invalid limit value: %w", err)
+		}
+		query.Limit = &limit
+	}
+
+	return query, nil
+}
+
+func clusterQueryFromValues(values url.Values, allowedTenants []string) (dmquery.ClusterFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "zoom"), allowedTenants)
+	if err != nil {
+		return dmquery.ClusterFilters{}, err
+	}
+
+	zoom, err := strconv.Atoi(values.Get("zoom"))
+	if err != nil {
+		return dmquery.ClusterFilters{}, fmt.Errorf("invalid zoom value: %w", err)
+	}
+
+	return dmquery.ClusterFilters{Filters: filters, Zoom: zoom}, nil
+}
+
+// tileQueryFromRequest reads the tile z/x/y, with an optional .mvt suffix, from the path and
+// limits the filters to the bounds of the tile.
+func tileQueryFromRequest(r *http.Request, allowedTenants []string) (dmquery.ClusterFilters, int, int, error) {
+	z, errZ := strconv.Atoi(r.PathValue("z"))
+	x, errX := strconv.Atoi(r.PathValue("x"))
+	y, errY := strconv.Atoi(strings.TrimSuffix(r.PathValue("y"), ".mvt"))
+	if err := errors.Join(errZ, errX, errY); err != nil {
+		return dmquery.ClusterFilters{}, 0, 0, fmt.Errorf("invalid tile: %w", err)
+	}
+
+	if z < 0 || z > 30 || x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return dmquery.ClusterFilters{}, 0, 0, fmt.Errorf("invalid tile %d/%d/%d", z, x, y)
+	}
+
+	filters, err := filtersFromValues(filterValuesWithoutKeys(r.URL.Query(), "bounds"), allowedTenants)
+	if err != nil {
+		return dmquery.ClusterFilters{}, 0, 0, err
+	}
+
+	bounds := tileBounds(z, x, y)
+	filters.Bounds = &bounds
+
+	return dmquery.ClusterFilters{Filters: filters, Zoom: z}, x, y, nil
+}
+
+// availabilityQueryFromValues defaults to the last seven days and gaps of three intervals.
+func availabilityQueryFromValues(values url.Values, allowedTenants []string) (dmquery.AvailabilityFilters, error) {
+	filters, err := filtersFromValues(filterValuesWithoutKeys(values, "from", "to", "gap"), allowedTenants)
+	if err != nil {
+		return dmquery.AvailabilityFilters{}, err
+	}
+
+	query := dmquery.AvailabilityFilters{Filters: filters, To: time.Now().UTC(), Gap: 3}
+
+	if v := values.Get("to"); v != "" {
+		to, err := parseDate(v)
+		if err != nil {
+			return dmquery.AvailabilityFilters{}, fmt.Errorf("invalid to value: %w", err)
+		}
+		query.To = *to
+	}
+
+	query.From = query.To.AddDate(0, 0, -7)
+
+	if v := values.Get("from"); v != "" {
+		from, err := parseDate(v)
+		if err != nil {
+			return dmquery.AvailabilityFilters{}, fmt.Errorf("invalid from value: %w", err)
+		}
+		query.From = *from
+	}
+
+	if v := values.Get("gap"); v != "" {
+		gap, err := strconv.Atoi(v)
+		if err != nil {
+			return dmquery.AvailabilityFilters{}, fmt.Errorf("invalid gap value: %w", err)
+		}
+		query.Gap = gap
+	}
+
+	return query, nil
+}
+
+// uptimeQueryFromValues requires a tenant and defaults to the previous month.
+func uptimeQueryFromValues(values url.Values, allowedTenants []string) (dmquery.UptimeFilters, error) {
+	now := time.Now().UTC()
+
+	query := dmquery.UptimeFilters{
+		Filters: dmquery.Filters{Tenant: values.Get("tenant"), AllowedTenants: allowedTenants},
+		Month:   time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0),
+	}
+
+	if query.Tenant == "" {
+		return dmquery.UptimeFilters{}, fmt.Errorf("tenant is required")
+	}
+
+	if v := values.Get("month"); v != "" {
+		month, err := time.Parse("2006-01", v)
+		if err != nil {
+			return dmquery.UptimeFilters{}, fmt.Errorf("invalid month value, must be formatted as YYYY-MM: %w", err)
+		}
+		query.Month = month
+	}
+
+	return query, nil
+}
+
 func parseLastSeen(value string) (*time.Time, error) {
 	layouts := []string{"2006-01-02T15:04", "2006-01-02T15:04:05", "2006-01-02T15:04Z"}
 
@@ -3,8 +3,11 @@ package api
 import (
 	"net/http"
 	"net/url"
+	"slices"
 	"testing"
 	"time"
+
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
 )
 
 func TestCreateLinks(t *testing.T) {
@@ -279,3 +282,81 @@ func TestDeviceStatusQueryFromValuesRejectsInvalidLastSeen(t *testing.T) {
 		t.Fatal("expected error for invalid lastseen")
 	}
 }
+
+func TestDeviceStatusQueryFromValuesParsesRange(t *testing.T) {
+	query, err := deviceStatusQueryFromValues(url.Values{"from": {"2025-01-01T00:00:00Z"}, "to": {"2025-04-01T00:00:00+02:00"}}, []string{"tenant-a"})
+	if err != nil {
+		t.Fatalf("expected valid range, got %v", err)
+	}
+	if query.From == nil || !query.From.Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected from to be parsed, got %v", query.From)
+	}
+	if query.To == nil || !query.To.Equal(time.Date(2025, time.March, 31, 22, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected to to be parsed, got %v", query.To)
+	}
+
+	_, err = deviceStatusQueryFromValues(url.Values{"from": {"2025-01-01"}}, []string{"tenant-a"})
+	if err == nil {
+		t.Fatal("expected error for invalid from")
+	}
+}
+
+func TestDeviceMeasurementsQueryFromValues(t *testing.T) {
+	query, err := deviceMeasurementsQueryFromValues(url.Values{"id": {"3303/5700"}, "urn": {"urn:oma:lwm2m:ext:3303"}, "latest": {"true"}}, []string{"tenant-a"})
+	if err != nil {
+		t.Fatalf("expected valid query, got %v", err)
+	}
+	if query.MeasurementID != "3303/5700" || query.Urn != "urn:oma:lwm2m:ext:3303" || !query.Latest {
+		t.Fatalf("unexpected query %+v", query)
+	}
+
+	query, err = deviceMeasurementsQueryFromValues(url.Values{"bucket": {"1d"}, "aggregate": {"last"}, "to": {"2025-01-01T00:00:00Z"}}, []string{"tenant-a"})
+	if err != nil {
+		t.Fatalf("expected valid query, got %v", err)
+	}
+	if query.Bucket != "1d" || query.Aggregate != "last" || query.To == nil {
+		t.Fatalf("unexpected query %+v", query)
+	}
+
+	for _, values := range []url.Values{
+		{"aggregate": {"avg"}},
+		{"bucket": {"1h"}, "aggregate": {"median"}},
+		{"bucket": {"1w"}},
+		{"bucket": {"1h"}, "latest": {"true"}},
+		{"latest": {"maybe"}},
+	} {
+		if _, err := deviceMeasurementsQueryFromValues(values, []string{"tenant-a"}); err == nil {
+			t.Fatalf("expected error for %v", values)
+		}
+	}
+}
+
+func TestDeviceQueryFromValuesParsesInclude(t *testing.T) {
+	query, err := deviceQueryFromValues(url.Values{"include": {"latestStatus, alarms", "alarms"}}, []string{"tenant-a"})
+	if err != nil {
+		t.Fatalf("expected valid include, got %v", err)
+	}
+	if !slices.Equal(query.Include, []string{dmquery.IncludeLatestStatus, dmquery.IncludeAlarms}) {
+		t.Fatalf("unexpected include %v", query.Include)
+	}
+
+	_, err = deviceQueryFromValues(url.Values{"include": {"measurements"}}, []string{"tenant-a"})
+	if err == nil {
+		t.Fatal("expected error for invalid include")
+	}
+}
+
+func TestDeviceQueryFromValuesParsesFields(t *testing.T) {
+	query, err := deviceQueryFromValues(url.Values{"fields": {"deviceID, location", "deviceState,location"}}, []string{"tenant-a"})
+	if err != nil {
+		t.Fatalf("expected valid fields, got %v", err)
+	}
+	if !slices.Equal(query.Fields, []string{"deviceID", "location", "deviceState"}) {
+		t.Fatalf("unexpected fields %v", query.Fields)
+	}
+
+	_, err = deviceQueryFromValues(url.Values{"fields": {"battery"}}, []string{"tenant-a"})
+	if err == nil {
+		t.Fatal("expected error for invalid fields")
+	}
+}
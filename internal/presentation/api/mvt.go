@@ -0,0 +1,166 @@
+package api
+
+import (
+	"math"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// mvtExtent is the width and height of a vector tile in tile coordinates.
+const mvtExtent = 4096
+
+// tileBounds returns the bounds of a web map tile. The tiles at the top and bottom of the map
+// end at about 85.05 degrees latitude.
+func tileBounds(z, x, y int) types.Bounds {
+	n := math.Exp2(float64(z))
+
+	lat := func(y float64) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+	}
+
+	return types.Bounds{
+		MinLon: float64(x)/n*360 - 180,
+		MaxLon: float64(x+1)/n*360 - 180,
+		MinLat: lat(float64(y + 1)),
+		MaxLat: lat(float64(y)),
+	}
+}
+
+// tileCoordinates projects a location onto the web mercator tile z/x/y.
+func tileCoordinates(z, x, y int, l types.Location) (int64, int64) {
+	n := math.Exp2(float64(z))
+	lat := l.Latitude * math.Pi / 180
+
+	tx := (l.Longitude + 180) / 360 * n
+	ty := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n
+
+	return int64(math.Floor((tx - float64(x)) * mvtExtent)), int64(math.Floor((ty - float64(y)) * mvtExtent))
+}
+
+// NewVectorTileWithClusters encodes the clusters as points of a "devices" layer in a Mapbox
+// Vector Tile. Clusters outside of the tile are left out.
+func NewVectorTileWithClusters(z, x, y int, clusters []types.DeviceCluster) []byte {
+	l := &mvtLayer{keys: map[string]uint64{}, values: map[any]uint64{}}
+
+	for _, c := range clusters {
+		px, py := tileCoordinates(z, x, y, c.Location)
+		if px < 0 || px >= mvtExtent || py < 0 || py >= mvtExtent {
+			continue
+		}
+
+		properties := []mvtProperty{
+			{"count", uint64(c.Count)},
+			{"online", uint64(c.Online)},
+			{"offline", uint64(c.Offline)},
+			{"stateUnknown", uint64(c.States.Unknown)},
+			{"stateOK", uint64(c.States.OK)},
+			{"stateWarning", uint64(c.States.Warning)},
+			{"stateError", uint64(c.States.Error)},
+			{"alarmsNone", uint64(c.Alarms.None)},
+			{"alarmsUnknown", uint64(c.Alarms.Unknown)},
+			{"alarmsLow", uint64(c.Alarms.Low)},
+			{"alarmsMedium", uint64(c.Alarms.Medium)},
+			{"alarmsHigh", uint64(c.Alarms.High)},
+		}
+
+		if c.DeviceID != "" {
+			properties = append(properties, mvtProperty{"deviceID", c.DeviceID})
+		}
+
+		l.addPoint(px, py, properties)
+	}
+
+	return protowire.AppendBytes(protowire.AppendTag(nil, 3, protowire.BytesType), l.encode("devices"))
+}
+
+type mvtProperty struct {
+	key   string
+	value any // string or uint64
+}
+
+// mvtLayer collects the features of a layer, and the keys and values that their tags refer to.
+type mvtLayer struct {
+	features  [][]byte
+	keys      map[string]uint64
+	keyList   []string
+	values    map[any]uint64
+	valueList []any
+}
+
+func (l *mvtLayer) addPoint(px, py int64, properties []mvtProperty) {
+	var tags []byte
+	for _, p := range properties {
+		k, ok := l.keys[p.key]
+		if !ok {
+			k = uint64(len(l.keyList))
+			l.keys[p.key] = k
+			l.keyList = append(l.keyList, p.key)
+		}
+
+		v, ok := l.values[p.value]
+		if !ok {
+			v = uint64(len(l.valueList))
+			l.values[p.value] = v
+			l.valueList = append(l.valueList, p.value)
+		}
+
+		tags = protowire.AppendVarint(tags, k)
+		tags = protowire.AppendVarint(tags, v)
+	}
+
+	// a single MoveTo command followed by the point
+	var geometry []byte
+	geometry = protowire.AppendVarint(geometry, 1|1<<3)
+	geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(px))
+	geometry = protowire.AppendVarint(geometry, protowire.EncodeZigZag(py))
+
+	var f []byte
+	f = protowire.AppendTag(f, 1, protowire.VarintType)
+	f = protowire.AppendVarint(f, uint64(len(l.features)+1))
+	f = protowire.AppendTag(f, 2, protowire.BytesType)
+	f = protowire.AppendBytes(f, tags)
+	f = protowire.AppendTag(f, 3, protowire.VarintType)
+	f = protowire.AppendVarint(f, 1) // POINT
+	f = protowire.AppendTag(f, 4, protowire.BytesType)
+	f = protowire.AppendBytes(f, geometry)
+
+	l.features = append(l.features, f)
+}
+
+func (l *mvtLayer) encode(name string) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 15, protowire.VarintType)
+	b = protowire.AppendVarint(b, 2)
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+
+	for _, f := range l.features {
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, f)
+	}
+
+	for _, k := range l.keyList {
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendString(b, k)
+	}
+
+	for _, v := range l.valueList {
+		var value []byte
+		switch v := v.(type) {
+		case string:
+			value = protowire.AppendTag(value, 1, protowire.BytesType)
+			value = protowire.AppendString(value, v)
+		case uint64:
+			value = protowire.AppendTag(value, 5, protowire.VarintType)
+			value = protowire.AppendVarint(value, v)
+		}
+		b = protowire.AppendTag(b, 4, protowire.BytesType)
+		b = protowire.AppendBytes(b, value)
+	}
+
+	b = protowire.AppendTag(b, 5, protowire.VarintType)
+	b = protowire.AppendVarint(b, mvtExtent)
+
+	return b
+}
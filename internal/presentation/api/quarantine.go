@@ -0,0 +1,74 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/quarantine"
+	quarantinequery "github.com/diwise/iot-device-mgmt/internal/application/quarantine/query"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
+)
+
+func getQuarantineHandler(log *slog.Logger, svc quarantine.QuarantineService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		admin := auth.IsAdminFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-quarantine")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, _ = o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		result, err := svc.Groups(ctx, quarantineQueryFromValues(r.URL.Query(), allowedTenants, admin))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func replayQuarantineHandler(log *slog.Logger, svc quarantine.QuarantineService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		admin := auth.IsAdminFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "replay-quarantine")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, _ = o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		result, err := svc.Replay(ctx, quarantineQueryFromValues(r.URL.Query(), allowedTenants, admin))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ApiResponse{Data: result}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func quarantineQueryFromValues(values url.Values, allowedTenants []string, admin bool) quarantinequery.Messages {
+	return quarantinequery.Messages{
+		DeviceID:          values.Get("deviceID"),
+		Tenant:            values.Get("tenant"),
+		Reason:            values.Get("reason"),
+		AllowedTenants:    allowedTenants,
+		IncludeUntenanted: admin,
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	"github.com/diwise/iot-device-mgmt/internal/application/uptime"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
+)
+
+func getBatteryReportHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-battery-report")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := batteryQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.BatteryForecasts(ctx, query)
+		if err != nil {
+			logger.Error("could not fetch battery forecasts", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = writeCsvWithBatteryForecasts(w, result.Data)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta, Links: createLinks(r.URL, meta)}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getCoverageReportHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-coverage-report")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := coverageQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		cells, err := svc.Coverage(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrInvalidCoverage) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not fetch coverage", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ApiResponse{
+			Data: cells,
+			Meta: &meta{TotalRecords: uint64(len(cells)), Count: uint64(len(cells))},
+		}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getDeliveryReportHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-delivery-report")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := availabilityQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.AvailabilityReport(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrInvalidAvailability) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not compute availability", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = writeCsvWithAvailability(w, result.Data)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta, Links: createLinks(r.URL, meta)}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getUptimeReportHandler(log *slog.Logger, svc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-uptime-report")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := uptimeQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		report, err := svc.UptimeReport(ctx, query)
+		if err != nil {
+			if errors.Is(err, devices.ErrInvalidUptime) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+
+			logger.Error("could not compute uptime report", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		if wantsTextCSV(r) {
+			w.Header().Set("Content-Type", "text/csv")
+			err = uptime.WriteCSV(w, report)
+			return
+		}
+
+		response := ApiResponse{Data: report}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
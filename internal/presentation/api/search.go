@@ -0,0 +1,50 @@
+package api
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/search"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
+)
+
+func searchHandler(log *slog.Logger, svc search.SearchService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "search")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := searchQueryFromValues(r.URL.Query(), allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.Search(ctx, query)
+		if err != nil {
+			if errors.Is(err, search.ErrMissingTenant) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			logger.Error("could not search", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/diwise/iot-device-mgmt/internal/application"
+	"github.com/diwise/iot-device-mgmt/internal/application/devices"
 	"github.com/diwise/iot-device-mgmt/internal/application/sensors"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
@@ -30,6 +33,8 @@ func querySensorsHandler(log *slog.Logger, svc sensors.SensorAPIService) http.Ha
 			return
 		}
 
+		query.AllowedTenants = auth.GetAllowedTenantsFromContext(r.Context())
+
 		result, err := svc.Query(ctx, query)
 		if err != nil {
 			logger.Error("could not query sensors", "err", err.Error())
@@ -47,7 +52,7 @@ func querySensorsHandler(log *slog.Logger, svc sensors.SensorAPIService) http.Ha
 		response := ApiResponse{
 			Data:  result.Data,
 			Meta:  meta,
-			Links: createLinks(r.URL, meta),
+			Links: createPageLinks(r.URL, meta, query.Cursor, result.Cursor),
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -216,3 +221,76 @@ func updateSensorHandler(log *slog.Logger, svc sensors.SensorAPIService) http.Ha
 		w.WriteHeader(http.StatusOK)
 	}
 }
+
+func promoteSensorHandler(log *slog.Logger, app application.Management) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "promote-sensor")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		// Promoting a sensor assigns it to a tenant, which only admins may do
+		if !auth.IsAdminFromContext(ctx) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		sensorID := r.PathValue("id")
+		if sensorID == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		logger = logger.With(slog.String("method", r.Method), slog.String("url", r.URL.String()), slog.String("sensor_id", sensorID))
+
+		var d types.Device
+
+		if r.ContentLength != 0 {
+			if !isApplicationJson(r) {
+				logger.Error("Unsupported MediaType")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				logger.Error("unable to read body", "err", err.Error())
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			err = json.Unmarshal(body, &d)
+			if err != nil {
+				logger.Error("unable to unmarshal body", "body", string(body), "err", err.Error())
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		device, err := app.PromoteSensor(ctx, sensorID, d, allowedTenants)
+		if err != nil {
+			switch {
+			case errors.Is(err, sensors.ErrSensorNotFound):
+				w.WriteHeader(http.StatusNotFound)
+			case errors.Is(err, devices.ErrSensorAlreadyAssigned), errors.Is(err, devices.ErrDeviceAlreadyExist):
+				w.WriteHeader(http.StatusConflict)
+			case errors.Is(err, application.ErrTenantNotAllowed):
+				w.WriteHeader(http.StatusUnauthorized)
+			case errors.Is(err, devices.ErrInvalidCalendar), errors.Is(err, devices.ErrSensorProfileRequired):
+				w.WriteHeader(http.StatusBadRequest)
+			default:
+				logger.Error("unable to promote sensor", "err", err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		}
+
+		response := ApiResponse{Data: device}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(response.Byte())
+	}
+}
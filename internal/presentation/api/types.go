@@ -4,16 +4,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 )
 
 type meta struct {
-	TotalRecords uint64  `json:"totalRecords"`
-	Offset       *uint64 `json:"offset,omitempty"`
-	Limit        *uint64 `json:"limit,omitempty"`
-	Count        uint64  `json:"count"`
+	TotalRecords uint64                   `json:"totalRecords"`
+	Offset       *uint64                  `json:"offset,omitempty"`
+	Limit        *uint64                  `json:"limit,omitempty"`
+	Count        uint64                   `json:"count"`
+	Facets       map[string][]types.Facet `json:"facets,omitempty"`
 }
 
 type links struct {
@@ -54,7 +59,9 @@ type GeoJSONFeature struct {
 	Properties map[string]any `json:"properties"`
 }
 
-func NewFeatureCollectionWithDevices(devices []types.Device) (*GeoJSONFeatureCollection, error) {
+// NewFeatureCollectionWithDevices returns the devices as points, with the fields of the devices,
+// or all of them when no fields are given, as properties.
+func NewFeatureCollectionWithDevices(devices []types.Device, fields []string) (*GeoJSONFeatureCollection, error) {
 	fc := NewFeatureCollection()
 
 	for _, d := range devices {
@@ -62,12 +69,116 @@ func NewFeatureCollectionWithDevices(devices []types.Device) (*GeoJSONFeatureCol
 		if err != nil {
 			return nil, err
 		}
+		if len(fields) > 0 {
+			maps.DeleteFunc(f.Properties, func(k string, _ any) bool {
+				return !slices.Contains(fields, k)
+			})
+		}
 		fc.Features = append(fc.Features, *f)
 	}
 
 	return fc, nil
 }
 
+// devicesWithFields returns the devices as objects with only the given fields, or the devices
+// as they are when no fields are given.
+func devicesWithFields(devices []types.Device, fields []string) (any, error) {
+	if len(fields) == 0 {
+		return devices, nil
+	}
+
+	result := make([]map[string]json.RawMessage, 0, len(devices))
+
+	for _, d := range devices {
+		b, err := json.Marshal(d)
+		if err != nil {
+			return nil, err
+		}
+
+		m := make(map[string]json.RawMessage)
+		err = json.Unmarshal(b, &m)
+		if err != nil {
+			return nil, err
+		}
+
+		maps.DeleteFunc(m, func(k string, _ json.RawMessage) bool {
+			return !slices.Contains(fields, k)
+		})
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+// NewFeatureCollectionWithLocations returns the locations of a device either as one point per
+// location or, with lineString, as a single line through the locations in time order.
+func NewFeatureCollectionWithLocations(deviceID string, locations []types.DeviceLocation, lineString bool) *GeoJSONFeatureCollection {
+	fc := NewFeatureCollection()
+	fc.Features = []GeoJSONFeature{}
+
+	if lineString {
+		if len(locations) == 0 {
+			return fc
+		}
+
+		coordinates := make([][]float64, 0, len(locations))
+		observedAt := make([]time.Time, 0, len(locations))
+		for _, l := range locations {
+			coordinates = append(coordinates, []float64{l.Location.Longitude, l.Location.Latitude})
+			observedAt = append(observedAt, l.ObservedAt)
+		}
+
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			ID:         deviceID,
+			Type:       "Feature",
+			Geometry:   CreateGeoJSONPropertyFromLineString(coordinates),
+			Properties: map[string]any{"deviceID": deviceID, "observedAt": observedAt},
+		})
+
+		return fc
+	}
+
+	for _, l := range locations {
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			ID:         fmt.Sprintf("%s:%s", deviceID, l.ObservedAt.Format(time.RFC3339)),
+			Type:       "Feature",
+			Geometry:   CreateGeoJSONPropertyFromWGS84(l.Location.Longitude, l.Location.Latitude),
+			Properties: map[string]any{"deviceID": deviceID, "source": l.Source, "observedAt": l.ObservedAt},
+		})
+	}
+
+	return fc
+}
+
+// NewFeatureCollectionWithClusters returns the clusters as points with the counts as properties.
+func NewFeatureCollectionWithClusters(clusters []types.DeviceCluster) (*GeoJSONFeatureCollection, error) {
+	fc := NewFeatureCollection()
+	fc.Features = []GeoJSONFeature{}
+
+	for _, c := range clusters {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, err
+		}
+
+		properties := map[string]any{}
+		if err := json.Unmarshal(b, &properties); err != nil {
+			return nil, err
+		}
+		delete(properties, "location")
+
+		fc.Features = append(fc.Features, GeoJSONFeature{
+			ID:         fmt.Sprintf("%d:%d", c.Row, c.Column),
+			Type:       "Feature",
+			Geometry:   CreateGeoJSONPropertyFromWGS84(c.Location.Longitude, c.Location.Latitude),
+			Properties: properties,
+		})
+	}
+
+	return fc, nil
+}
+
 func ConvertDevice(d types.Device) (*GeoJSONFeature, error) {
 	feature := &GeoJSONFeature{
 		ID:         d.DeviceID,
@@ -239,50 +350,187 @@ func CreateGeoJSONPropertyFromMultiPolygon(coordinates [][][][]float64) *GeoJSON
 	return p
 }
 
-func writeCsvWithDevices(w io.Writer, devices []types.Device) error {
-	header := []string{"devEUI", "internalID", "lat", "lon", "where", "types", "sensorType", "name", "description", "active", "tenant", "interval", "source", "metadata"}
-	rows := [][]string{header}
+// deviceCsvColumn is a column of the CSV export of devices and the device field it is read from.
+type deviceCsvColumn struct {
+	header string
+	field  string
+	value  func(d types.Device) string
+}
 
-	meta := func(d types.Device) string {
+var deviceCsvColumns = []deviceCsvColumn{
+	{"devEUI", "sensorID", func(d types.Device) string { return d.SensorID }},
+	{"internalID", "deviceID", func(d types.Device) string { return d.DeviceID }},
+	{"lat", "location", func(d types.Device) string { return fmt.Sprintf("%f", d.Location.Latitude) }},
+	{"lon", "location", func(d types.Device) string { return fmt.Sprintf("%f", d.Location.Longitude) }},
+	{"where", "environment", func(d types.Device) string { return d.Environment }},
+	{"types", "types", func(d types.Device) string {
+		urn := []string{}
+		for _, t := range d.Lwm2mTypes {
+			urn = append(urn, t.Urn)
+		}
+		return strings.Join(urn, ",")
+	}},
+	{"sensorType", "sensorProfile", func(d types.Device) string { return d.SensorProfile.Decoder }},
+	{"name", "name", func(d types.Device) string { return d.Name }},
+	{"description", "description", func(d types.Device) string { return d.Description }},
+	{"active", "active", func(d types.Device) string { return fmt.Sprintf("%t", d.Active) }},
+	{"tenant", "tenant", func(d types.Device) string { return d.Tenant }},
+	{"interval", "sensorProfile", func(d types.Device) string { return fmt.Sprintf("%d", d.SensorProfile.Interval) }},
+	{"source", "source", func(d types.Device) string { return d.Source }},
+	{"metadata", "metadata", func(d types.Device) string {
 		result := []string{}
-
 		for _, m := range d.Metadata {
 			result = append(result, fmt.Sprintf("%s=%s", m.Key, m.Value))
 		}
-
 		return strings.Join(result, ",")
+	}},
+}
+
+// writeCsvWithDevices writes the columns of the given device fields, or all columns when no
+// fields are given. Fields without a column are left out.
+func writeCsvWithDevices(w io.Writer, devices []types.Device, fields []string) error {
+	columns := []deviceCsvColumn{}
+	for _, c := range deviceCsvColumns {
+		if len(fields) == 0 || slices.Contains(fields, c.field) {
+			columns = append(columns, c)
+		}
 	}
 
-	lwm2mTypes := func(d types.Device) string {
-		urn := []string{}
+	header := []string{}
+	for _, c := range columns {
+		header = append(header, c.header)
+	}
+	rows := [][]string{header}
 
-		for _, t := range d.Lwm2mTypes {
-			urn = append(urn, t.Urn)
+	for _, d := range devices {
+		row := []string{}
+		for _, c := range columns {
+			row = append(row, c.value(d))
 		}
+		rows = append(rows, row)
+	}
 
-		return strings.Join(urn, ",")
+	for _, row := range rows {
+		_, err := fmt.Fprintln(w, strings.Join(row, ";"))
+		if err != nil {
+			return err
+		}
 	}
 
-	for _, d := range devices {
-		row := []string{
-			d.SensorID,
-			d.DeviceID,
-			fmt.Sprintf("%f", d.Location.Latitude),
-			fmt.Sprintf("%f", d.Location.Longitude),
-			d.Environment,
-			lwm2mTypes(d),
-			d.SensorProfile.Decoder,
-			d.Name,
-			d.Description,
-			fmt.Sprintf("%t", d.Active),
-			d.Tenant,
-			fmt.Sprintf("%d", d.SensorProfile.Interval),
-			d.Source,
-			meta(d),
+	return nil
+}
+
+func writeCsvWithStatuses(w io.Writer, statuses []types.SensorStatus) error {
+	rows := [][]string{{"observedAt", "batteryLevel", "rssi", "loRaSNR", "frequency", "spreadingFactor", "dr"}}
+
+	for _, s := range statuses {
+		rows = append(rows, []string{
+			s.ObservedAt.UTC().Format(time.RFC3339),
+			strconv.Itoa(s.BatteryLevel),
+			formatFloat(s.RSSI),
+			formatFloat(s.LoRaSNR),
+			formatInt(s.Frequency),
+			formatFloat(s.SpreadingFactor),
+			formatInt(s.DR),
+		})
+	}
+
+	return writeCsvRows(w, rows)
+}
+
+func writeCsvWithMeasurements(w io.Writer, measurements []types.Measurement) error {
+	rows := [][]string{{"id", "urn", "name", "value", "unit", "timestamp"}}
+
+	for _, m := range measurements {
+		value := ""
+		switch v := m.Value.(type) {
+		case nil:
+		case float64:
+			value = formatFloat(&v)
+		default:
+			value = fmt.Sprint(v)
+		}
+		rows = append(rows, []string{
+			m.ID,
+			m.Urn,
+			formatString(m.Name),
+			value,
+			formatString(m.Unit),
+			m.Timestamp.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return writeCsvRows(w, rows)
+}
+
+func writeCsvWithBatteryForecasts(w io.Writer, forecasts []types.BatteryForecast) error {
+	rows := [][]string{{"sensorID", "deviceID", "tenant", "level", "rate", "threshold", "depletesAt", "replacedAt", "samples", "computedAt"}}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	for _, f := range forecasts {
+		rows = append(rows, []string{
+			f.SensorID,
+			f.DeviceID,
+			f.Tenant,
+			formatFloat(&f.Level),
+			formatFloat(&f.Rate),
+			formatFloat(&f.Threshold),
+			formatTime(f.DepletesAt),
+			formatTime(f.ReplacedAt),
+			strconv.Itoa(f.Samples),
+			formatTime(&f.ComputedAt),
+		})
+	}
+
+	return writeCsvRows(w, rows)
+}
+
+// writeCsvWithAvailability writes one row per device and day.
+func writeCsvWithAvailability(w io.Writer, availability []types.Availability) error {
+	rows := [][]string{{"deviceID", "tenant", "date", "interval", "expected", "received", "deliveryRatio"}}
+
+	for _, a := range availability {
+		for _, d := range a.Days {
+			rows = append(rows, []string{
+				a.DeviceID,
+				a.Tenant,
+				d.Date.UTC().Format(time.DateOnly),
+				strconv.Itoa(a.Interval),
+				strconv.Itoa(d.Expected),
+				strconv.Itoa(d.Received),
+				formatFloat(d.DeliveryRatio),
+			})
+		}
+	}
+
+	return writeCsvRows(w, rows)
+}
+
+func writeCsvWithStatusAggregates(w io.Writer, aggregates []types.SensorStatusAggregate) error {
+	header := []string{"bucket", "samples"}
+	for _, name := range []string{"batteryLevel", "rssi", "loRaSNR", "spreadingFactor", "dr"} {
+		header = append(header, name+"Min", name+"Max", name+"Avg")
+	}
+	rows := [][]string{header}
+
+	for _, a := range aggregates {
+		row := []string{a.Bucket.UTC().Format(time.RFC3339), strconv.Itoa(a.Samples)}
+		for _, v := range []types.Aggregate{a.BatteryLevel, a.RSSI, a.LoRaSNR, a.SpreadingFactor, a.DR} {
+			row = append(row, formatFloat(v.Min), formatFloat(v.Max), formatFloat(v.Avg))
 		}
 		rows = append(rows, row)
 	}
 
+	return writeCsvRows(w, rows)
+}
+
+func writeCsvRows(w io.Writer, rows [][]string) error {
 	for _, row := range rows {
 		_, err := fmt.Fprintln(w, strings.Join(row, ";"))
 		if err != nil {
@@ -292,3 +540,24 @@ func writeCsvWithDevices(w io.Writer, devices []types.Device) error {
 
 	return nil
 }
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatInt[T int | int64](i *T) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.FormatInt(int64(*i), 10)
+}
@@ -0,0 +1,459 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/diwise/iot-device-mgmt/internal/application/devices"
+	dmquery "github.com/diwise/iot-device-mgmt/internal/application/devices/query"
+	"github.com/diwise/iot-device-mgmt/internal/application/views"
+	"github.com/diwise/iot-device-mgmt/internal/presentation/api/auth"
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
+)
+
+// viewPollInterval is how often the membership of a subscribed view is checked for changes.
+var viewPollInterval = 30 * time.Second
+
+const viewMembershipPageSize int = 1000
+
+func queryViewsHandler(log *slog.Logger, svc views.ViewService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "query-views")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		query, parseErr := viewQueryFromValues(r.URL.Query(), user, allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		result, err := svc.Views(ctx, query)
+		if err != nil {
+			if errors.Is(err, views.ErrMissingTenant) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			logger.Error("could not query views", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{TotalRecords: result.TotalCount, Offset: &result.Offset, Limit: &result.Limit, Count: result.Count}
+		response := ApiResponse{Data: result.Data, Meta: meta, Links: createLinks(r.URL, meta)}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func getViewHandler(log *slog.Logger, svc views.ViewService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-view")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		viewID := r.PathValue("id")
+
+		v, err := svc.View(ctx, viewID, user, allowedTenants)
+		if err != nil {
+			writeViewError(w, logger, err)
+			return
+		}
+
+		response := ApiResponse{Data: v}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+func createViewHandler(log *slog.Logger, svc views.ViewService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "create-view")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		v, ok := viewFromRequest(w, r, logger, allowedTenants)
+		if !ok {
+			return
+		}
+
+		v, err = svc.Create(ctx, v, user)
+		if err != nil {
+			writeViewError(w, logger, err)
+			return
+		}
+
+		response := ApiResponse{Data: v}
+
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(response.Byte())
+	}
+}
+
+func updateViewHandler(log *slog.Logger, svc views.ViewService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "update-view")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		v, ok := viewFromRequest(w, r, logger, allowedTenants)
+		if !ok {
+			return
+		}
+
+		id := r.PathValue("id")
+		if v.ID == "" {
+			v.ID = id
+		}
+
+		if id != v.ID {
+			logger.Debug("payload contains mismatched view ID", "id", id, "viewID", v.ID)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		err = svc.Update(ctx, v, user, allowedTenants)
+		if err != nil {
+			writeViewError(w, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func deleteViewHandler(log *slog.Logger, svc views.ViewService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "delete-view")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		viewID := r.PathValue("id")
+		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("view_id", viewID))
+
+		err = svc.Delete(ctx, viewID, user, allowedTenants)
+		if err != nil {
+			writeViewError(w, logger, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// getViewDevicesHandler queries devices by the filters and sort order of a view. Paging and
+// other filters are taken from the request. With Accept: text/event-stream the response is
+// a stream of the devices that join or leave the view instead.
+func getViewDevicesHandler(log *slog.Logger, svc views.ViewService, deviceSvc devices.DeviceAPIService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		allowedTenants := auth.GetAllowedTenantsFromContext(r.Context())
+		user := auth.GetUserFromContext(r.Context())
+
+		ctx, span := tracer.Start(r.Context(), "get-view-devices")
+		defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+		_, ctx, logger := o11y.AddTraceIDToLoggerAndStoreInContext(span, log, ctx)
+
+		viewID := r.PathValue("id")
+		ctx = logging.NewContextWithLogger(ctx, logger, slog.String("view_id", viewID))
+
+		v, err := svc.View(ctx, viewID, user, allowedTenants)
+		if err != nil {
+			writeViewError(w, logger, err)
+			return
+		}
+
+		values := viewValues(v, r.URL.Query())
+
+		if wantsEventStream(r) {
+			err = streamViewMembership(ctx, w, v, values, allowedTenants, deviceSvc)
+			if err != nil && ctx.Err() == nil {
+				logger.Error("could not stream view membership", "err", err.Error())
+			}
+			return
+		}
+
+		query, parseErr := deviceQueryFromValues(values, allowedTenants)
+		if parseErr != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(parseErr.Error()))
+			return
+		}
+
+		collection, err := deviceSvc.Query(ctx, query)
+		if err != nil {
+			logger.Error("could not query view devices", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		meta := &meta{
+			TotalRecords: collection.TotalCount,
+			Offset:       &collection.Offset,
+			Limit:        &collection.Limit,
+			Count:        collection.Count,
+		}
+
+		if len(query.Facets) > 0 {
+			stats, err := deviceSvc.Stats(ctx, query)
+			if err != nil {
+				logger.Error("could not count view device facets", "err", err.Error())
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			meta.Facets = stats.Facets
+		}
+
+		data, err := devicesWithFields(collection.Data, query.Fields)
+		if err != nil {
+			logger.Error("could not limit view devices to fields", "err", err.Error())
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := ApiResponse{
+			Meta:  meta,
+			Data:  data,
+			Links: createPageLinks(r.URL, meta, query.Cursor, collection.Cursor),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(response.Byte())
+	}
+}
+
+// streamViewMembership sends the devices of a view as server-sent events, first all of them
+// as joined and then the devices that joined or left each time the membership changes.
+func streamViewMembership(ctx context.Context, w http.ResponseWriter, v types.View, values url.Values, allowedTenants []string, svc devices.DeviceAPIService) error {
+	values = filterValuesWithoutKeys(values, "limit", "offset", "cursor", "sortBy", "sortOrder", "include", "fields")
+	values.Set("limit", fmt.Sprint(viewMembershipPageSize))
+	values.Set("cursor", "")
+
+	query, err := deviceQueryFromValues(values, allowedTenants)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return nil
+	}
+
+	// membership only needs the device ids
+	query.Fields = []string{"deviceID"}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	ticker := time.NewTicker(viewPollInterval)
+	defer ticker.Stop()
+
+	var members []string
+
+	for {
+		current, err := viewMembers(ctx, svc, query)
+		if err != nil {
+			return err
+		}
+
+		joined, left := membershipChanges(members, current)
+		if members == nil || len(joined) > 0 || len(left) > 0 {
+			b, _ := json.Marshal(types.ViewMembership{ViewID: v.ID, Joined: joined, Left: left, Timestamp: time.Now().UTC()})
+			fmt.Fprintf(w, "event: membership\ndata: %s\n\n", b)
+
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+
+		members = current
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// viewMembers returns the sorted ids of all devices that match the query, paging by cursor.
+func viewMembers(ctx context.Context, svc devices.DeviceAPIService, query dmquery.DeviceFilters) ([]string, error) {
+	members := []string{}
+
+	for {
+		collection, err := svc.Query(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range collection.Data {
+			members = append(members, d.DeviceID)
+		}
+
+		if collection.Cursor == nil {
+			break
+		}
+
+		query.Cursor = collection.Cursor
+	}
+
+	slices.Sort(members)
+
+	return members, nil
+}
+
+// membershipChanges compares two sorted lists of device ids.
+func membershipChanges(before, after []string) (joined, left []string) {
+	joined, left = []string{}, []string{}
+
+	for _, id := range after {
+		if _, found := slices.BinarySearch(before, id); !found {
+			joined = append(joined, id)
+		}
+	}
+
+	for _, id := range before {
+		if _, found := slices.BinarySearch(after, id); !found {
+			left = append(left, id)
+		}
+	}
+
+	return joined, left
+}
+
+// viewValues combines the filters and sort order of a view with the query of a request. The
+// filters of the view take precedence over filters with the same name in the request.
+func viewValues(v types.View, requested url.Values) url.Values {
+	values := url.Values{}
+
+	for key, value := range v.Filters {
+		values[key] = slices.Clone(value)
+	}
+
+	for key, value := range requested {
+		if !hasKey(values, key) {
+			values[key] = slices.Clone(value)
+		}
+	}
+
+	if v.SortBy != "" && !hasKey(values, "sortBy") {
+		values.Set("sortBy", v.SortBy)
+		if v.SortDesc {
+			values.Set("sortOrder", "desc")
+		}
+	}
+
+	return values
+}
+
+func hasKey(values url.Values, key string) bool {
+	for k := range values {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func viewFromRequest(w http.ResponseWriter, r *http.Request, logger *slog.Logger, allowedTenants []string) (types.View, bool) {
+	if !isApplicationJson(r) {
+		logger.Error("Unsupported MediaType")
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return types.View{}, false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Error("unable to read body", "err", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return types.View{}, false
+	}
+
+	var v types.View
+	err = json.Unmarshal(body, &v)
+	if err != nil {
+		logger.Error("unable to unmarshal body", "body", string(body), "err", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return types.View{}, false
+	}
+
+	if !slices.Contains(allowedTenants, v.Tenant) {
+		logger.Error("not allowed to store view with current tenant", "view_id", v.ID, "tenant", v.Tenant)
+		w.WriteHeader(http.StatusUnauthorized)
+		return types.View{}, false
+	}
+
+	// the filters are validated as a device query so that the view can be executed later
+	if _, err := deviceQueryFromValues(v.Filters, allowedTenants); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return types.View{}, false
+	}
+
+	return v, true
+}
+
+func writeViewError(w http.ResponseWriter, logger *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, views.ErrViewNotFound):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, views.ErrViewAlreadyExist):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, views.ErrViewNotAllowed):
+		w.WriteHeader(http.StatusForbidden)
+	case errors.Is(err, views.ErrMissingTenant):
+		w.WriteHeader(http.StatusUnauthorized)
+	case errors.Is(err, views.ErrInvalidView):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	default:
+		logger.Error("unable to handle view", "err", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+
+	"github.com/diwise/iot-device-mgmt/pkg/types"
+)
+
+// Alarms pages through the active alarms of all devices, latest first, with a cursor.
+func (dmc *devManagementClient) Alarms(ctx context.Context, query types.AlarmsQuery) iter.Seq2[types.Alarms, error] {
+	params := url.Values{}
+	if query.Limit != nil {
+		params.Set("limit", strconv.Itoa(*query.Limit))
+	}
+	if query.AlarmType != "" {
+		params.Set("alarmType", query.AlarmType)
+	}
+
+	return paginate[types.Alarms](ctx, dmc, "/api/v0/alarms", params)
+}
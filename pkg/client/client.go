@@ -8,8 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
 	"net/http"
 	"net/http/httputil"
+	"net/url"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -17,6 +20,7 @@ import (
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/env"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
+	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/tracing"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"golang.org/x/oauth2"
@@ -30,6 +34,7 @@ type DeviceManagementClient interface {
 	CreateDevice(ctx context.Context, device types.Device) error
 	FindDeviceFromDevEUI(ctx context.Context, devEUI string) (Device, error)
 	FindDeviceFromInternalID(ctx context.Context, deviceID string) (Device, error)
+	GetDeviceStatusAggregates(ctx context.Context, deviceID string, query types.DeviceStatusQuery) ([]types.SensorStatusAggregate, error)
 	CreateSensor(ctx context.Context, sensor types.SensorInputModel) error
 	UpdateSensor(ctx context.Context, sensor types.SensorInputModel) error
 	GetSensor(ctx context.Context, sensorID string) (Sensor, error)
@@ -39,6 +44,9 @@ type DeviceManagementClient interface {
 	GetTenants(ctx context.Context) ([]string, error)
 	GetDeviceProfiles(ctx context.Context) ([]types.SensorProfile, error)
 	GetDeviceProfile(ctx context.Context, deviceProfileID string) (*types.SensorProfile, error)
+	Devices(ctx context.Context, query types.DevicesQuery) iter.Seq2[Device, error]
+	Sensors(ctx context.Context, query types.SensorsQuery) iter.Seq2[Sensor, error]
+	Alarms(ctx context.Context, query types.AlarmsQuery) iter.Seq2[types.Alarms, error]
 	Client() *http.Client
 }
 
@@ -236,3 +244,92 @@ func newJsonRequest(ctx context.Context, method, url string, body any) (*http.Re
 
 	return req, nil
 }
+
+// paginate pages through the results of path with a cursor and yields one item at a time until
+// the last page, the first error or until the caller stops.
+func paginate[T any](ctx context.Context, dmc *devManagementClient, path string, params url.Values) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		query := maps.Clone(params)
+		query.Set("cursor", "")
+
+		for {
+			page, next, err := getPage[T](ctx, dmc, dmc.baseUrl+path+"?"+query.Encode())
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			if next == "" {
+				return
+			}
+
+			query.Set("cursor", next)
+		}
+	}
+}
+
+// getPage returns the items of a page and the cursor of the next page, empty on the last page.
+func getPage[T any](ctx context.Context, dmc *devManagementClient, requestURL string) ([]T, string, error) {
+	var err error
+	ctx, span := tracer.Start(ctx, "get-page")
+	defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+
+	req, err := newJsonRequest(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := dmc.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to get page: %w", err)
+		return nil, "", err
+	}
+	defer drainAndCloseResponseBody(resp)
+
+	dmc.dumpRequestResponseIfNon200AndDebugEnabled(ctx, req, resp)
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, "", ErrUnauthorized
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("request failed with status code %d", resp.StatusCode)
+		return nil, "", err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to read response body: %w", err)
+		return nil, "", err
+	}
+
+	response := struct {
+		Data  []T `json:"data"`
+		Links struct {
+			Next *string `json:"next"`
+		} `json:"links"`
+	}{}
+	if err = json.Unmarshal(body, &response); err != nil {
+		err = fmt.Errorf("failed to unmarshal response body: %w", err)
+		return nil, "", err
+	}
+
+	if response.Links.Next == nil {
+		return response.Data, "", nil
+	}
+
+	next, err := url.Parse(*response.Links.Next)
+	if err != nil {
+		err = fmt.Errorf("failed to parse next link: %w", err)
+		return nil, "", err
+	}
+
+	return response.Data, next.Query().Get("cursor"), nil
+}
@@ -3,7 +3,10 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	test "github.com/diwise/service-chassis/pkg/test/http"
@@ -204,6 +207,41 @@ func TestListSensors(t *testing.T) {
 	client.Close(ctx)
 }
 
+func TestGetDeviceStatusAggregates(t *testing.T) {
+	is := is.New(t)
+
+	resBody := `{"data":[{"bucket":"2025-01-01T00:00:00Z","samples":24,"batteryLevel":{"min":80,"max":82,"avg":81},"rssi":{},"loRaSNR":{},"spreadingFactor":{},"dr":{}}]}`
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockedService := test.NewMockServiceThat(
+		test.Expects(is,
+			expects.RequestPath("/api/v0/devices/device-1/status"),
+			expects.QueryParamContains("bucket", "1d"),
+			expects.QueryParamContains("from", "2025-01-01T00:00:00Z"),
+			expects.RequestMethod("GET"),
+		),
+		test.Returns(response.Code(200), response.Body([]byte(resBody))),
+	)
+
+	mockOAuth := test.NewMockServiceThat(
+		test.Expects(is, expects.RequestPath("/token")),
+		test.Returns(response.ContentType("application/json"), response.Code(200), response.Body([]byte(TokenResponse))),
+	)
+	defer mockOAuth.Close()
+
+	ctx := context.Background()
+	client, err := New(ctx, mockedService.URL(), mockOAuth.URL()+"/token", false, "", "")
+	is.NoErr(err)
+
+	aggregates, err := client.GetDeviceStatusAggregates(ctx, "device-1", types.DeviceStatusQuery{From: &from, Bucket: types.BucketDay})
+	is.NoErr(err)
+	is.Equal(len(aggregates), 1)
+	is.Equal(aggregates[0].Samples, 24)
+	is.Equal(*aggregates[0].BatteryLevel.Avg, 81.0)
+	is.Equal(aggregates[0].RSSI.Avg, nil)
+
+	client.Close(ctx)
+}
+
 func TestCreateSensorIncludesNameAndLocation(t *testing.T) {
 	is := is.New(t)
 	name := "Outdoor sensor"
@@ -409,3 +447,52 @@ func TestMe(t *testing.T) {
 	}
 */
 const TokenResponse string = `{"access_token":"testtoken","expires_in":300,"refresh_expires_in":0,"token_type":"Bearer","not-before-policy":0,"scope":"email profile"}`
+
+func TestDevicesPagesWithCursor(t *testing.T) {
+	is := is.New(t)
+
+	next := types.Cursor{Key: "device-2", ID: "device-2"}.String()
+	cursors := []string{}
+
+	mockedService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.URL.Path, "/api/v0/devices")
+		is.Equal(r.URL.Query().Get("limit"), "2")
+
+		cursor := r.URL.Query().Get("cursor")
+		cursors = append(cursors, cursor)
+
+		if cursor == "" {
+			w.Write([]byte(`{"data":[{"deviceID":"device-1"},{"deviceID":"device-2"}],"links":{"next":"/api/v0/devices?cursor=` + next + `&limit=2"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"deviceID":"device-3"}],"links":{"first":"/api/v0/devices?cursor=&limit=2"}}`))
+	}))
+	defer mockedService.Close()
+
+	mockOAuth := test.NewMockServiceThat(
+		test.Expects(is, expects.RequestPath("/token")),
+		test.Returns(response.ContentType("application/json"), response.Code(200), response.Body([]byte(TokenResponse))),
+	)
+	defer mockOAuth.Close()
+
+	ctx := context.Background()
+	client, err := New(ctx, mockedService.URL, mockOAuth.URL()+"/token", false, "", "")
+	is.NoErr(err)
+	defer client.Close(ctx)
+
+	limit := 2
+	ids := []string{}
+	for d, err := range client.Devices(ctx, types.DevicesQuery{Limit: &limit}) {
+		is.NoErr(err)
+		ids = append(ids, d.ID())
+	}
+
+	is.Equal(ids, []string{"device-1", "device-2", "device-3"})
+	is.Equal(cursors, []string{"", next})
+
+	// stopping early does not request more pages
+	for range client.Devices(ctx, types.DevicesQuery{Limit: &limit}) {
+		break
+	}
+	is.Equal(len(cursors), 3)
+}
@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"github.com/diwise/service-chassis/pkg/infrastructure/o11y/logging"
@@ -170,8 +174,112 @@ func (dmc *devManagementClient) findDeviceFromInternalID(ctx context.Context, de
 	return &deviceWrapper{&impl.Data}, nil
 }
 
+func (dmc *devManagementClient) GetDeviceStatusAggregates(ctx context.Context, deviceID string, query types.DeviceStatusQuery) ([]types.SensorStatusAggregate, error) {
+	var err error
+	ctx, span := tracer.Start(ctx, "get-device-status-aggregates")
+	defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
+
+	params := url.Values{}
+	params.Set("bucket", query.Bucket)
+	if query.From != nil {
+		params.Set("from", query.From.UTC().Format(time.RFC3339))
+	}
+	if query.To != nil {
+		params.Set("to", query.To.UTC().Format(time.RFC3339))
+	}
+	if query.Offset != nil {
+		params.Set("offset", strconv.Itoa(*query.Offset))
+	}
+	if query.Limit != nil {
+		params.Set("limit", strconv.Itoa(*query.Limit))
+	}
+
+	requestURL := dmc.baseUrl + "/api/v0/devices/" + url.PathEscape(deviceID) + "/status?" + params.Encode()
+
+	req, err := newJsonRequest(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := dmc.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to retrieve device status: %w", err)
+		return nil, err
+	}
+	defer drainAndCloseResponseBody(resp)
+
+	dmc.dumpRequestResponseIfNon200AndDebugEnabled(ctx, req, resp)
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("request failed with status code %d", resp.StatusCode)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to read response body: %w", err)
+		return nil, err
+	}
+
+	response := struct {
+		Data []types.SensorStatusAggregate `json:"data"`
+	}{}
+
+	err = json.Unmarshal(respBody, &response)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal response body: %w", err)
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
 //go:generate moq -rm -out ../test/device_mock.go . Device
 
+// Devices pages through all devices matching the query with a cursor.
+func (dmc *devManagementClient) Devices(ctx context.Context, query types.DevicesQuery) iter.Seq2[Device, error] {
+	params := url.Values{}
+	if query.Limit != nil {
+		params.Set("limit", strconv.Itoa(*query.Limit))
+	}
+	if query.Active != nil {
+		params.Set("active", strconv.FormatBool(*query.Active))
+	}
+	if len(query.Types) > 0 {
+		params["types"] = append([]string(nil), query.Types...)
+	}
+	if query.Tenant != "" {
+		params.Set("tenant", query.Tenant)
+	}
+	if query.SortBy != "" {
+		params.Set("sortBy", query.SortBy)
+	}
+	if query.SortDesc {
+		params.Set("sortOrder", "desc")
+	}
+
+	return func(yield func(Device, error) bool) {
+		for d, err := range paginate[types.Device](ctx, dmc, "/api/v0/devices", params) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(&deviceWrapper{&d}, nil) {
+				return
+			}
+		}
+	}
+}
+
 // you need to modify the generated ../test/device_mock.go
 // import "github.com/diwise/iot-device-mgmt/pkg/client"
 // change "var _ Device = &DeviceMock{}" to "var _ client.Device = &DeviceMock{}"
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -113,25 +114,10 @@ func (dmc *devManagementClient) ListSensors(ctx context.Context, query types.Sen
 	ctx, span := tracer.Start(ctx, "list-sensors")
 	defer func() { tracing.RecordAnyErrorAndEndSpan(err, span) }()
 
-	params := url.Values{}
+	params := sensorsQueryParams(query)
 	if query.Offset != nil {
 		params.Set("offset", strconv.Itoa(*query.Offset))
 	}
-	if query.Limit != nil {
-		params.Set("limit", strconv.Itoa(*query.Limit))
-	}
-	if query.Assigned != nil {
-		params.Set("assigned", strconv.FormatBool(*query.Assigned))
-	}
-	if query.HasProfile != nil {
-		params.Set("hasProfile", strconv.FormatBool(*query.HasProfile))
-	}
-	if profileName := strings.TrimSpace(query.ProfileName); profileName != "" {
-		params.Set("profileName", profileName)
-	}
-	if len(query.Types) > 0 {
-		params["types"] = append([]string(nil), query.Types...)
-	}
 
 	requestURL := dmc.baseUrl + "/api/v0/sensors"
 	if encoded := params.Encode(); encoded != "" {
@@ -229,3 +215,40 @@ func (dmc *devManagementClient) writeSensor(ctx context.Context, method, request
 		return fmt.Errorf("request failed with status code %d", resp.StatusCode)
 	}
 }
+
+// Sensors pages through all sensors matching the query with a cursor. The Offset of the query is
+// not used.
+func (dmc *devManagementClient) Sensors(ctx context.Context, query types.SensorsQuery) iter.Seq2[Sensor, error] {
+	return func(yield func(Sensor, error) bool) {
+		for s, err := range paginate[types.Sensor](ctx, dmc, "/api/v0/sensors", sensorsQueryParams(query)) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			if !yield(&sensorWrapper{impl: &s}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func sensorsQueryParams(query types.SensorsQuery) url.Values {
+	params := url.Values{}
+	if query.Limit != nil {
+		params.Set("limit", strconv.Itoa(*query.Limit))
+	}
+	if query.Assigned != nil {
+		params.Set("assigned", strconv.FormatBool(*query.Assigned))
+	}
+	if query.HasProfile != nil {
+		params.Set("hasProfile", strconv.FormatBool(*query.HasProfile))
+	}
+	if profileName := strings.TrimSpace(query.ProfileName); profileName != "" {
+		params.Set("profileName", profileName)
+	}
+	if len(query.Types) > 0 {
+		params["types"] = append([]string(nil), query.Types...)
+	}
+	return params
+}
@@ -5,6 +5,7 @@ package client
 
 import (
 	"context"
+	"iter"
 	"github.com/diwise/iot-device-mgmt/pkg/types"
 	"net/http"
 	"sync"
@@ -21,6 +22,9 @@ var _ client.DeviceManagementClient = &DeviceManagementClientMock{}
 //
 //		// make and configure a mocked DeviceManagementClient
 //		mockedDeviceManagementClient := &DeviceManagementClientMock{
+//			AlarmsFunc: func(ctx context.Context, query types.AlarmsQuery) iter.Seq2[types.Alarms, error] {
+//				panic("mock out the Alarms method")
+//			},
 //			AttachSensorToDeviceFunc: func(ctx context.Context, deviceID string, sensorID string) error {
 //				panic("mock out the AttachSensorToDevice method")
 //			},
@@ -39,6 +43,9 @@ var _ client.DeviceManagementClient = &DeviceManagementClientMock{}
 //			DetachSensorFromDeviceFunc: func(ctx context.Context, deviceID string) error {
 //				panic("mock out the DetachSensorFromDevice method")
 //			},
+//			DevicesFunc: func(ctx context.Context, query types.DevicesQuery) iter.Seq2[Device, error] {
+//				panic("mock out the Devices method")
+//			},
 //			FindDeviceFromDevEUIFunc: func(ctx context.Context, devEUI string) (Device, error) {
 //				panic("mock out the FindDeviceFromDevEUI method")
 //			},
@@ -51,6 +58,9 @@ var _ client.DeviceManagementClient = &DeviceManagementClientMock{}
 //			GetDeviceProfilesFunc: func(ctx context.Context) ([]types.SensorProfile, error) {
 //				panic("mock out the GetDeviceProfiles method")
 //			},
+//			GetDeviceStatusAggregatesFunc: func(ctx context.Context, deviceID string, query types.DeviceStatusQuery) ([]types.SensorStatusAggregate, error) {
+//				panic("mock out the GetDeviceStatusAggregates method")
+//			},
 //			GetSensorFunc: func(ctx context.Context, sensorID string) (Sensor, error) {
 //				panic("mock out the GetSensor method")
 //			},
@@ -60,6 +70,9 @@ var _ client.DeviceManagementClient = &DeviceManagementClientMock{}
 //			ListSensorsFunc: func(ctx context.Context, query types.SensorsQuery) ([]Sensor, error) {
 //				panic("mock out the ListSensors method")
 //			},
+//			SensorsFunc: func(ctx context.Context, query types.SensorsQuery) iter.Seq2[Sensor, error] {
+//				panic("mock out the Sensors method")
+//			},
 //			UpdateSensorFunc: func(ctx context.Context, sensor types.SensorInputModel) error {
 //				panic("mock out the UpdateSensor method")
 //			},
@@ -70,6 +83,9 @@ var _ client.DeviceManagementClient = &DeviceManagementClientMock{}
 //
 //	}
 type DeviceManagementClientMock struct {
+	// AlarmsFunc mocks the Alarms method.
+	AlarmsFunc func(ctx context.Context, query types.AlarmsQuery) iter.Seq2[types.Alarms, error]
+
 	// AttachSensorToDeviceFunc mocks the AttachSensorToDevice method.
 	AttachSensorToDeviceFunc func(ctx context.Context, deviceID string, sensorID string) error
 
@@ -88,6 +104,9 @@ type DeviceManagementClientMock struct {
 	// DetachSensorFromDeviceFunc mocks the DetachSensorFromDevice method.
 	DetachSensorFromDeviceFunc func(ctx context.Context, deviceID string) error
 
+	// DevicesFunc mocks the Devices method.
+	DevicesFunc func(ctx context.Context, query types.DevicesQuery) iter.Seq2[client.Device, error]
+
 	// FindDeviceFromDevEUIFunc mocks the FindDeviceFromDevEUI method.
 	FindDeviceFromDevEUIFunc func(ctx context.Context, devEUI string) (client.Device, error)
 
@@ -100,6 +119,9 @@ type DeviceManagementClientMock struct {
 	// GetDeviceProfilesFunc mocks the GetDeviceProfiles method.
 	GetDeviceProfilesFunc func(ctx context.Context) ([]types.SensorProfile, error)
 
+	// GetDeviceStatusAggregatesFunc mocks the GetDeviceStatusAggregates method.
+	GetDeviceStatusAggregatesFunc func(ctx context.Context, deviceID string, query types.DeviceStatusQuery) ([]types.SensorStatusAggregate, error)
+
 	// GetSensorFunc mocks the GetSensor method.
 	GetSensorFunc func(ctx context.Context, sensorID string) (client.Sensor, error)
 
@@ -109,11 +131,21 @@ type DeviceManagementClientMock struct {
 	// ListSensorsFunc mocks the ListSensors method.
 	ListSensorsFunc func(ctx context.Context, query types.SensorsQuery) ([]client.Sensor, error)
 
+	// SensorsFunc mocks the Sensors method.
+	SensorsFunc func(ctx context.Context, query types.SensorsQuery) iter.Seq2[client.Sensor, error]
+
 	// UpdateSensorFunc mocks the UpdateSensor method.
 	UpdateSensorFunc func(ctx context.Context, sensor types.SensorInputModel) error
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// Alarms holds details about calls to the Alarms method.
+		Alarms []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query types.AlarmsQuery
+		}
 		// AttachSensorToDevice holds details about calls to the AttachSensorToDevice method.
 		AttachSensorToDevice []struct {
 			// Ctx is the ctx argument value.
@@ -152,6 +184,13 @@ type DeviceManagementClientMock struct {
 			// DeviceID is the deviceID argument value.
 			DeviceID string
 		}
+		// Devices holds details about calls to the Devices method.
+		Devices []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query types.DevicesQuery
+		}
 		// FindDeviceFromDevEUI holds details about calls to the FindDeviceFromDevEUI method.
 		FindDeviceFromDevEUI []struct {
 			// Ctx is the ctx argument value.
@@ -178,6 +217,15 @@ type DeviceManagementClientMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// GetDeviceStatusAggregates holds details about calls to the GetDeviceStatusAggregates method.
+		GetDeviceStatusAggregates []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeviceID is the deviceID argument value.
+			DeviceID string
+			// Query is the query argument value.
+			Query types.DeviceStatusQuery
+		}
 		// GetSensor holds details about calls to the GetSensor method.
 		GetSensor []struct {
 			// Ctx is the ctx argument value.
@@ -197,6 +245,13 @@ type DeviceManagementClientMock struct {
 			// Query is the query argument value.
 			Query types.SensorsQuery
 		}
+		// Sensors holds details about calls to the Sensors method.
+		Sensors []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query types.SensorsQuery
+		}
 		// UpdateSensor holds details about calls to the UpdateSensor method.
 		UpdateSensor []struct {
 			// Ctx is the ctx argument value.
@@ -205,20 +260,60 @@ type DeviceManagementClientMock struct {
 			Sensor types.SensorInputModel
 		}
 	}
-	lockAttachSensorToDevice     sync.RWMutex
-	lockClient                   sync.RWMutex
-	lockClose                    sync.RWMutex
-	lockCreateDevice             sync.RWMutex
-	lockCreateSensor             sync.RWMutex
-	lockDetachSensorFromDevice   sync.RWMutex
-	lockFindDeviceFromDevEUI     sync.RWMutex
+	lockAlarms sync.RWMutex
+	lockAttachSensorToDevice sync.RWMutex
+	lockClient sync.RWMutex
+	lockClose sync.RWMutex
+	lockCreateDevice sync.RWMutex
+	lockCreateSensor sync.RWMutex
+	lockDetachSensorFromDevice sync.RWMutex
+	lockDevices sync.RWMutex
+	lockFindDeviceFromDevEUI sync.RWMutex
 	lockFindDeviceFromInternalID sync.RWMutex
-	lockGetDeviceProfile         sync.RWMutex
-	lockGetDeviceProfiles        sync.RWMutex
-	lockGetSensor                sync.RWMutex
-	lockGetTenants               sync.RWMutex
-	lockListSensors              sync.RWMutex
-	lockUpdateSensor             sync.RWMutex
+	lockGetDeviceProfile sync.RWMutex
+	lockGetDeviceProfiles sync.RWMutex
+	lockGetDeviceStatusAggregates sync.RWMutex
+	lockGetSensor sync.RWMutex
+	lockGetTenants sync.RWMutex
+	lockListSensors sync.RWMutex
+	lockSensors sync.RWMutex
+	lockUpdateSensor sync.RWMutex
+}
+
+// Alarms calls AlarmsFunc.
+func (mock *DeviceManagementClientMock) Alarms(ctx context.Context, query types.AlarmsQuery) iter.Seq2[types.Alarms, error] {
+	if mock.AlarmsFunc == nil {
+		panic("DeviceManagementClientMock.AlarmsFunc: method is nil but DeviceManagementClient.Alarms was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Query types.AlarmsQuery
+	}{
+		Ctx: ctx,
+		Query: query,
+	}
+	mock.lockAlarms.Lock()
+	mock.calls.Alarms = append(mock.calls.Alarms, callInfo)
+	mock.lockAlarms.Unlock()
+	return mock.AlarmsFunc(ctx, query)
+}
+
+// AlarmsCalls gets all the calls that were made to Alarms.
+// Check the length with:
+//
+//	len(mockedDeviceManagementClient.AlarmsCalls())
+func (mock *DeviceManagementClientMock) AlarmsCalls() []struct {
+		Ctx context.Context
+		Query types.AlarmsQuery
+} {
+	var calls []struct {
+		Ctx context.Context
+		Query types.AlarmsQuery
+	}
+	mock.lockAlarms.RLock()
+	calls = mock.calls.Alarms
+	mock.lockAlarms.RUnlock()
+	return calls
 }
 
 // AttachSensorToDevice calls AttachSensorToDeviceFunc.
@@ -428,6 +523,42 @@ func (mock *DeviceManagementClientMock) DetachSensorFromDeviceCalls() []struct {
 	return calls
 }
 
+// Devices calls DevicesFunc.
+func (mock *DeviceManagementClientMock) Devices(ctx context.Context, query types.DevicesQuery) iter.Seq2[client.Device, error] {
+	if mock.DevicesFunc == nil {
+		panic("DeviceManagementClientMock.DevicesFunc: method is nil but DeviceManagementClient.Devices was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Query types.DevicesQuery
+	}{
+		Ctx: ctx,
+		Query: query,
+	}
+	mock.lockDevices.Lock()
+	mock.calls.Devices = append(mock.calls.Devices, callInfo)
+	mock.lockDevices.Unlock()
+	return mock.DevicesFunc(ctx, query)
+}
+
+// DevicesCalls gets all the calls that were made to Devices.
+// Check the length with:
+//
+//	len(mockedDeviceManagementClient.DevicesCalls())
+func (mock *DeviceManagementClientMock) DevicesCalls() []struct {
+		Ctx context.Context
+		Query types.DevicesQuery
+} {
+	var calls []struct {
+		Ctx context.Context
+		Query types.DevicesQuery
+	}
+	mock.lockDevices.RLock()
+	calls = mock.calls.Devices
+	mock.lockDevices.RUnlock()
+	return calls
+}
+
 // FindDeviceFromDevEUI calls FindDeviceFromDevEUIFunc.
 func (mock *DeviceManagementClientMock) FindDeviceFromDevEUI(ctx context.Context, devEUI string) (client.Device, error) {
 	if mock.FindDeviceFromDevEUIFunc == nil {
@@ -568,6 +699,46 @@ func (mock *DeviceManagementClientMock) GetDeviceProfilesCalls() []struct {
 	return calls
 }
 
+// GetDeviceStatusAggregates calls GetDeviceStatusAggregatesFunc.
+func (mock *DeviceManagementClientMock) GetDeviceStatusAggregates(ctx context.Context, deviceID string, query types.DeviceStatusQuery) ([]types.SensorStatusAggregate, error) {
+	if mock.GetDeviceStatusAggregatesFunc == nil {
+		panic("DeviceManagementClientMock.GetDeviceStatusAggregatesFunc: method is nil but DeviceManagementClient.GetDeviceStatusAggregates was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		DeviceID string
+		Query types.DeviceStatusQuery
+	}{
+		Ctx: ctx,
+		DeviceID: deviceID,
+		Query: query,
+	}
+	mock.lockGetDeviceStatusAggregates.Lock()
+	mock.calls.GetDeviceStatusAggregates = append(mock.calls.GetDeviceStatusAggregates, callInfo)
+	mock.lockGetDeviceStatusAggregates.Unlock()
+	return mock.GetDeviceStatusAggregatesFunc(ctx, deviceID, query)
+}
+
+// GetDeviceStatusAggregatesCalls gets all the calls that were made to GetDeviceStatusAggregates.
+// Check the length with:
+//
+//	len(mockedDeviceManagementClient.GetDeviceStatusAggregatesCalls())
+func (mock *DeviceManagementClientMock) GetDeviceStatusAggregatesCalls() []struct {
+		Ctx context.Context
+		DeviceID string
+		Query types.DeviceStatusQuery
+} {
+	var calls []struct {
+		Ctx context.Context
+		DeviceID string
+		Query types.DeviceStatusQuery
+	}
+	mock.lockGetDeviceStatusAggregates.RLock()
+	calls = mock.calls.GetDeviceStatusAggregates
+	mock.lockGetDeviceStatusAggregates.RUnlock()
+	return calls
+}
+
 // GetSensor calls GetSensorFunc.
 func (mock *DeviceManagementClientMock) GetSensor(ctx context.Context, sensorID string) (client.Sensor, error) {
 	if mock.GetSensorFunc == nil {
@@ -672,6 +843,42 @@ func (mock *DeviceManagementClientMock) ListSensorsCalls() []struct {
 	return calls
 }
 
+// Sensors calls SensorsFunc.
+func (mock *DeviceManagementClientMock) Sensors(ctx context.Context, query types.SensorsQuery) iter.Seq2[client.Sensor, error] {
+	if mock.SensorsFunc == nil {
+		panic("DeviceManagementClientMock.SensorsFunc: method is nil but DeviceManagementClient.Sensors was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Query types.SensorsQuery
+	}{
+		Ctx: ctx,
+		Query: query,
+	}
+	mock.lockSensors.Lock()
+	mock.calls.Sensors = append(mock.calls.Sensors, callInfo)
+	mock.lockSensors.Unlock()
+	return mock.SensorsFunc(ctx, query)
+}
+
+// SensorsCalls gets all the calls that were made to Sensors.
+// Check the length with:
+//
+//	len(mockedDeviceManagementClient.SensorsCalls())
+func (mock *DeviceManagementClientMock) SensorsCalls() []struct {
+		Ctx context.Context
+		Query types.SensorsQuery
+} {
+	var calls []struct {
+		Ctx context.Context
+		Query types.SensorsQuery
+	}
+	mock.lockSensors.RLock()
+	calls = mock.calls.Sensors
+	mock.lockSensors.RUnlock()
+	return calls
+}
+
 // UpdateSensor calls UpdateSensorFunc.
 func (mock *DeviceManagementClientMock) UpdateSensor(ctx context.Context, sensor types.SensorInputModel) error {
 	if mock.UpdateSensorFunc == nil {
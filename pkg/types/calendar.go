@@ -0,0 +1,225 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCalendar = errors.New("invalid reporting calendar")
+
+// ReportingCalendar describes when a sensor is expected to report. Time that falls
+// outside of the calendar is not counted as silence when looking for stale devices.
+type ReportingCalendar struct {
+	// Timezone is an IANA time zone name, the local time zone is used if empty.
+	Timezone string `json:"timezone,omitempty" yaml:"timezone"`
+	// Hours limits reporting to a time of day, e.g. 06:00-22:00. A range where
+	// To is before From wraps past midnight.
+	Hours *TimeOfDayRange `json:"hours,omitempty" yaml:"hours"`
+	// Weekdays limits reporting to the given days (mon, tue, wed, thu, fri, sat, sun).
+	Weekdays []string `json:"weekdays,omitempty" yaml:"weekdays"`
+	// Seasons limits reporting to date ranges within the year, e.g. 05-01 to 09-30.
+	Seasons []DateRange `json:"seasons,omitempty" yaml:"seasons"`
+	// EventDriven sensors only report when something happens and are never stale.
+	EventDriven bool `json:"eventDriven,omitempty" yaml:"eventdriven"`
+}
+
+type TimeOfDayRange struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+type DateRange struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (c ReportingCalendar) Validate() error {
+	if _, err := c.location(); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidCalendar, c.Timezone)
+	}
+
+	if c.Hours != nil {
+		if _, err := parseTimeOfDay(c.Hours.From); err != nil {
+			return err
+		}
+		if _, err := parseTimeOfDay(c.Hours.To); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range c.Weekdays {
+		if _, ok := parseWeekday(d); !ok {
+			return fmt.Errorf("%w: unknown weekday %q", ErrInvalidCalendar, d)
+		}
+	}
+
+	for _, s := range c.Seasons {
+		if _, err := parseDayOfYear(s.From); err != nil {
+			return err
+		}
+		if _, err := parseDayOfYear(s.To); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExpectedDuration returns how much of the period between from and to falls
+// within expected reporting time.
+func (c ReportingCalendar) ExpectedDuration(from, to time.Time) time.Duration {
+	if c.EventDriven || !to.After(from) {
+		return 0
+	}
+
+	loc, err := c.location()
+	if err != nil {
+		loc = time.Local
+	}
+
+	from = from.In(loc)
+	to = to.In(loc)
+
+	var expected time.Duration
+
+	for day := startOfDay(from); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !c.activeDay(day) {
+			continue
+		}
+
+		for _, window := range c.windows(day) {
+			start := maxTime(window[0], from)
+			end := minTime(window[1], to)
+			if end.After(start) {
+				expected += end.Sub(start)
+			}
+		}
+	}
+
+	return expected
+}
+
+func (c ReportingCalendar) location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.Timezone)
+}
+
+func (c ReportingCalendar) activeDay(day time.Time) bool {
+	if len(c.Weekdays) > 0 {
+		found := slices.ContainsFunc(c.Weekdays, func(d string) bool {
+			wd, ok := parseWeekday(d)
+			return ok && wd == day.Weekday()
+		})
+		if !found {
+			return false
+		}
+	}
+
+	if len(c.Seasons) > 0 {
+		current := int(day.Month())*100 + day.Day()
+
+		return slices.ContainsFunc(c.Seasons, func(s DateRange) bool {
+			from, err1 := parseDayOfYear(s.From)
+			to, err2 := parseDayOfYear(s.To)
+			if err1 != nil || err2 != nil {
+				return false
+			}
+			if from <= to {
+				return current >= from && current <= to
+			}
+			return current >= from || current <= to
+		})
+	}
+
+	return true
+}
+
+// windows returns the expected reporting windows for the given day
+func (c ReportingCalendar) windows(day time.Time) [][2]time.Time {
+	next := day.AddDate(0, 0, 1)
+
+	if c.Hours == nil {
+		return [][2]time.Time{{day, next}}
+	}
+
+	from, err1 := parseTimeOfDay(c.Hours.From)
+	to, err2 := parseTimeOfDay(c.Hours.To)
+	if err1 != nil || err2 != nil {
+		return [][2]time.Time{{day, next}}
+	}
+
+	start := atTimeOfDay(day, from)
+	end := atTimeOfDay(day, to)
+
+	if from < to {
+		return [][2]time.Time{{start, end}}
+	}
+
+	return [][2]time.Time{{day, end}, {start, next}}
+}
+
+// parseWeekday accepts both short (mon) and long (monday) weekday names
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	wd, ok := weekdays[s[:3]]
+	return wd, ok
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%w: time of day must be formatted as HH:MM, got %q", ErrInvalidCalendar, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func parseDayOfYear(s string) (int, error) {
+	t, err := time.Parse("01-02", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%w: date must be formatted as MM-DD, got %q", ErrInvalidCalendar, s)
+	}
+	return int(t.Month())*100 + t.Day(), nil
+}
+
+// atTimeOfDay returns the wall clock time of day on the given day, which is not the same
+// as adding it to the start of the day when daylight saving time begins or ends that day.
+func atTimeOfDay(day time.Time, tod time.Duration) time.Time {
+	h, m := int(tod/time.Hour), int(tod%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, 0, 0, day.Location())
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
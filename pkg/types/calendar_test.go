@@ -0,0 +1,110 @@
+package types
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestExpectedDurationWithoutRestrictions(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{Timezone: "UTC"}
+	from := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	is.Equal(c.ExpectedDuration(from, from.Add(26*time.Hour)), 26*time.Hour)
+}
+
+func TestExpectedDurationOnlyCountsActiveHours(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{
+		Timezone: "UTC",
+		Hours:    &TimeOfDayRange{From: "06:00", To: "22:00"},
+	}
+	from := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 2, 8, 0, 0, 0, time.UTC)
+
+	is.Equal(c.ExpectedDuration(from, to), 4*time.Hour)
+}
+
+func TestExpectedDurationWithHoursPastMidnight(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{
+		Timezone: "UTC",
+		Hours:    &TimeOfDayRange{From: "22:00", To: "02:00"},
+	}
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	is.Equal(c.ExpectedDuration(from, from.Add(24*time.Hour)), 4*time.Hour)
+}
+
+func TestExpectedDurationOnDaylightSavingTimeDays(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{
+		Timezone: "Europe/Stockholm",
+		Hours:    &TimeOfDayRange{From: "06:00", To: "22:00"},
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	is.NoErr(err)
+
+	// the clocks are set forward at 02:00 on 30 March and back at 03:00 on 26 October
+	for _, day := range []time.Time{time.Date(2025, 3, 30, 0, 0, 0, 0, loc), time.Date(2025, 10, 26, 0, 0, 0, 0, loc)} {
+		windows := c.windows(day)
+		is.Equal(windows[0][0].Hour(), 6)
+		is.Equal(windows[0][1].Hour(), 22)
+		is.Equal(c.ExpectedDuration(day, day.AddDate(0, 0, 1)), 16*time.Hour)
+	}
+}
+
+func TestExpectedDurationOnlyCountsWeekdays(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{
+		Timezone: "UTC",
+		Weekdays: []string{"mon", "Tuesday"},
+	}
+	// 2025-06-01 is a sunday
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	is.Equal(c.ExpectedDuration(from, from.AddDate(0, 0, 7)), 48*time.Hour)
+}
+
+func TestExpectedDurationOnlyCountsSeasons(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{
+		Timezone: "UTC",
+		Seasons:  []DateRange{{From: "05-01", To: "09-30"}},
+	}
+
+	winter := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	is.Equal(c.ExpectedDuration(winter, winter.AddDate(0, 1, 0)), time.Duration(0))
+
+	autumn := time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC)
+	is.Equal(c.ExpectedDuration(autumn, autumn.AddDate(0, 0, 5)), 48*time.Hour)
+}
+
+func TestExpectedDurationForEventDrivenSensors(t *testing.T) {
+	is := is.New(t)
+
+	c := ReportingCalendar{EventDriven: true}
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	is.Equal(c.ExpectedDuration(from, from.AddDate(0, 1, 0)), time.Duration(0))
+}
+
+func TestValidateCalendar(t *testing.T) {
+	is := is.New(t)
+
+	is.NoErr(ReportingCalendar{Timezone: "Europe/Stockholm", Hours: &TimeOfDayRange{From: "06:00", To: "22:00"}, Weekdays: []string{"mon"}, Seasons: []DateRange{{From: "05-01", To: "09-30"}}}.Validate())
+
+	is.True(errors.Is(ReportingCalendar{Timezone: "Nowhere/Special"}.Validate(), ErrInvalidCalendar))
+	is.True(errors.Is(ReportingCalendar{Hours: &TimeOfDayRange{From: "6", To: "22:00"}}.Validate(), ErrInvalidCalendar))
+	is.True(errors.Is(ReportingCalendar{Weekdays: []string{"someday"}}.Validate(), ErrInvalidCalendar))
+	is.True(errors.Is(ReportingCalendar{Seasons: []DateRange{{From: "13-01", To: "09-30"}}}.Validate(), ErrInvalidCalendar))
+}
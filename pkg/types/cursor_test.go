@@ -0,0 +1,26 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	is := is.New(t)
+
+	c := Cursor{Key: "2026-01-01 10:00:00+00", ID: "device-1"}
+
+	parsed, err := ParseCursor(c.String())
+	is.NoErr(err)
+	is.Equal(parsed, c)
+
+	first, err := ParseCursor("")
+	is.NoErr(err)
+	is.Equal(first, Cursor{})
+	is.Equal(first.String(), "")
+
+	_, err = ParseCursor("not a cursor")
+	is.True(errors.Is(err, ErrInvalidCursor))
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrInvalidGeofence = errors.New("invalid geofence")
+
+// Geofence is the area that a device, or every device with a tag, is expected to stay inside.
+// The area is either a polygon or a radius in meters around a center.
+type Geofence struct {
+	ID       string    `json:"id"`
+	Tenant   string    `json:"tenant"`
+	Name     string    `json:"name,omitzero"`
+	DeviceID string    `json:"deviceID,omitzero"`
+	Tag      string    `json:"tag,omitzero"`
+	Polygon  Polygon   `json:"polygon,omitzero"`
+	Center   *Location `json:"center,omitempty"`
+	Radius   float64   `json:"radius,omitzero"`
+}
+
+const earthRadius float64 = 6371008.8
+
+func (g Geofence) Validate() error {
+	if (g.DeviceID == "") == (g.Tag == "") {
+		return fmt.Errorf("%w: exactly one of deviceID and tag must be set", ErrInvalidGeofence)
+	}
+
+	if (len(g.Polygon) == 0) == (g.Radius <= 0) {
+		return fmt.Errorf("%w: exactly one of polygon and radius must be set", ErrInvalidGeofence)
+	}
+
+	for _, ring := range g.Polygon {
+		if len(ring) < 4 {
+			return fmt.Errorf("%w: a polygon ring needs at least four positions", ErrInvalidGeofence)
+		}
+	}
+
+	if g.Radius > 0 && g.Center == nil {
+		return fmt.Errorf("%w: a radius needs a center", ErrInvalidGeofence)
+	}
+
+	return nil
+}
+
+// Contains reports whether the location is inside the fence. Locations inside a hole of the
+// polygon are outside.
+func (g Geofence) Contains(l Location) bool {
+	if g.Radius > 0 && g.Center != nil {
+		return distance(*g.Center, l) <= g.Radius
+	}
+
+	if len(g.Polygon) == 0 || !inRing(g.Polygon[0], l) {
+		return false
+	}
+
+	for _, hole := range g.Polygon[1:] {
+		if inRing(hole, l) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// inRing casts a ray from the location and counts how many edges of the ring it crosses.
+func inRing(ring [][2]float64, l Location) bool {
+	inside := false
+
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+
+		if (yi > l.Latitude) != (yj > l.Latitude) && l.Longitude < (xj-xi)*(l.Latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// distance is the great circle distance in meters between two locations.
+func distance(a, b Location) float64 {
+	lat1, lat2 := a.Latitude*math.Pi/180, b.Latitude*math.Pi/180
+	dlat := lat2 - lat1
+	dlon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dlat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dlon/2), 2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
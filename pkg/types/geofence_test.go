@@ -0,0 +1,56 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestGeofenceContainsWithPolygon(t *testing.T) {
+	is := is.New(t)
+
+	g := Geofence{
+		DeviceID: "lifebuoy-1",
+		Polygon: Polygon{
+			{{17.0, 62.0}, {18.0, 62.0}, {18.0, 63.0}, {17.0, 63.0}, {17.0, 62.0}},
+			{{17.4, 62.4}, {17.6, 62.4}, {17.6, 62.6}, {17.4, 62.6}, {17.4, 62.4}},
+		},
+	}
+
+	is.True(g.Contains(Location{Latitude: 62.2, Longitude: 17.2}))
+	is.True(!g.Contains(Location{Latitude: 62.5, Longitude: 17.5})) // inside the hole
+	is.True(!g.Contains(Location{Latitude: 64.0, Longitude: 17.5}))
+}
+
+func TestGeofenceContainsWithRadius(t *testing.T) {
+	is := is.New(t)
+
+	g := Geofence{Tag: "lifebuoy", Center: &Location{Latitude: 62.39, Longitude: 17.30}, Radius: 100}
+
+	is.True(g.Contains(Location{Latitude: 62.3905, Longitude: 17.30}))  // ~56m north
+	is.True(!g.Contains(Location{Latitude: 62.3910, Longitude: 17.30})) // ~111m north
+}
+
+func TestGeofenceValidate(t *testing.T) {
+	is := is.New(t)
+
+	center := &Location{Latitude: 62.39, Longitude: 17.30}
+	square := Polygon{{{17.0, 62.0}, {18.0, 62.0}, {18.0, 63.0}, {17.0, 62.0}}}
+
+	is.NoErr(Geofence{DeviceID: "lifebuoy-1", Center: center, Radius: 100}.Validate())
+	is.NoErr(Geofence{Tag: "lifebuoy", Polygon: square}.Validate())
+
+	invalid := []Geofence{
+		{Center: center, Radius: 100},
+		{DeviceID: "lifebuoy-1", Tag: "lifebuoy", Center: center, Radius: 100},
+		{DeviceID: "lifebuoy-1"},
+		{DeviceID: "lifebuoy-1", Polygon: square, Center: center, Radius: 100},
+		{DeviceID: "lifebuoy-1", Radius: 100},
+		{DeviceID: "lifebuoy-1", Polygon: Polygon{{{17.0, 62.0}, {18.0, 62.0}, {17.0, 62.0}}}},
+	}
+
+	for _, g := range invalid {
+		is.True(errors.Is(g.Validate(), ErrInvalidGeofence))
+	}
+}
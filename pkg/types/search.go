@@ -0,0 +1,19 @@
+package types
+
+const (
+	SearchKindDevice = "device"
+	SearchKindSensor = "sensor"
+	SearchKindAlarm  = "alarm"
+)
+
+// SearchResult is a device, sensor or alarm that matches a search, by relevance. For an alarm
+// ID is the device id and Name the alarm type. Snippet is HTML escaped text with the matching
+// words in <mark> elements.
+type SearchResult struct {
+	Kind    string  `json:"kind"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name,omitzero"`
+	Tenant  string  `json:"tenant,omitzero"`
+	Snippet string  `json:"snippet,omitzero"`
+	Rank    float64 `json:"rank"`
+}
@@ -16,7 +16,8 @@ type Device struct {
 	Source      string   `json:"source,omitzero"`
 	Tenant      string   `json:"tenant"`
 
-	Interval int `json:"interval,omitzero"`
+	Interval int                `json:"interval,omitzero"`
+	Calendar *ReportingCalendar `json:"calendar,omitempty"`
 
 	Lwm2mTypes []Lwm2mType `json:"types"`
 	Tags       []Tag       `json:"tags,omitzero"`
@@ -28,6 +29,13 @@ type Device struct {
 	SensorStatus  SensorStatus  `json:"sensorStatus"`
 
 	Alarms []string `json:"alarms,omitzero"`
+
+	BatteryForecast *BatteryForecast `json:"batteryForecast,omitempty"`
+	LinkQuality     *LinkQuality     `json:"linkQuality,omitempty"`
+
+	// LatestMeasurements and AlarmDetails are only set when requested with include.
+	LatestMeasurements []Measurement  `json:"latestMeasurements,omitzero"`
+	AlarmDetails       []AlarmDetails `json:"alarmDetails,omitzero"`
 }
 
 type Metadata struct {
@@ -45,10 +53,11 @@ type Tag struct {
 }
 
 type SensorProfile struct {
-	Name     string   `json:"name" yaml:"name"`
-	Decoder  string   `json:"decoder" yaml:"decoder"`
-	Interval int      `json:"interval" yaml:"interval"`
-	Types    []string `json:"types,omitempty" yaml:"types"`
+	Name     string             `json:"name" yaml:"name"`
+	Decoder  string             `json:"decoder" yaml:"decoder"`
+	Interval int                `json:"interval" yaml:"interval"`
+	Types    []string           `json:"types,omitempty" yaml:"types"`
+	Calendar *ReportingCalendar `json:"calendar,omitempty" yaml:"calendar"`
 }
 
 type Lwm2mType struct {
@@ -66,6 +75,232 @@ type SensorStatus struct {
 	ObservedAt      time.Time `json:"observedAt"`
 }
 
+// BatteryForecast is the fitted battery trend of a sensor since its battery was last replaced,
+// and the time when the battery level is expected to reach the threshold.
+type BatteryForecast struct {
+	SensorID string `json:"sensorID"`
+	DeviceID string `json:"deviceID,omitzero"`
+	Tenant   string `json:"tenant,omitzero"`
+	// Level is the fitted battery level at the latest reading.
+	Level float64 `json:"level"`
+	// Rate is the change in battery level per day, negative while the battery depletes.
+	Rate       float64    `json:"rate"`
+	Threshold  float64    `json:"threshold"`
+	DepletesAt *time.Time `json:"depletesAt,omitempty"`
+	ReplacedAt *time.Time `json:"replacedAt,omitempty"`
+	Samples    int        `json:"samples"`
+	ComputedAt time.Time  `json:"computedAt"`
+}
+
+const (
+	LinkQualityGood     = "good"
+	LinkQualityMarginal = "marginal"
+	LinkQualityPoor     = "poor"
+)
+
+// Percentiles holds the 10th, 50th and 90th percentile of a value.
+type Percentiles struct {
+	P10 *float64 `json:"p10,omitempty"`
+	P50 *float64 `json:"p50,omitempty"`
+	P90 *float64 `json:"p90,omitempty"`
+}
+
+// LinkQuality holds statistics of the radio link of a sensor within a window, and
+// the class, good, marginal or poor, the link is considered to be.
+type LinkQuality struct {
+	SensorID string      `json:"sensorID,omitzero"`
+	Class    string      `json:"class"`
+	Samples  int         `json:"samples"`
+	RSSI     Percentiles `json:"rssi"`
+	LoRaSNR  Percentiles `json:"loRaSNR"`
+	// SpreadingFactors and DataRates hold the number of messages per SF and DR.
+	SpreadingFactors map[string]int `json:"spreadingFactors,omitempty"`
+	DataRates        map[string]int `json:"dataRates,omitempty"`
+	// RSSITrend is the change of RSSI, in dB per day, within the window.
+	RSSITrend  *float64  `json:"rssiTrend,omitempty"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	ComputedAt time.Time `json:"computedAt"`
+}
+
+// CoverageCell aggregates the link quality of the devices within a grid cell.
+type CoverageCell struct {
+	Row      int      `json:"row"`
+	Column   int      `json:"column"`
+	Bounds   Bounds   `json:"bounds"`
+	Devices  int      `json:"devices"`
+	Good     int      `json:"good"`
+	Marginal int      `json:"marginal"`
+	Poor     int      `json:"poor"`
+	RSSI     *float64 `json:"rssi,omitempty"`
+	LoRaSNR  *float64 `json:"loRaSNR,omitempty"`
+}
+
+const (
+	LocationSourceAPI    = "api"
+	LocationSourceImport = "import"
+	LocationSourceStatus = "status"
+)
+
+// DeviceLocation is where a device was from the time it was observed there until its next
+// location, and whether the change came from the API, an import or a status message.
+type DeviceLocation struct {
+	DeviceID   string    `json:"deviceID"`
+	Location   Location  `json:"location"`
+	Source     string    `json:"source"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// DeviceCluster aggregates the devices within a cell of a map grid. The location is the
+// average location of the devices and a cluster of a single device carries its id.
+type DeviceCluster struct {
+	Row      int            `json:"row"`
+	Column   int            `json:"column"`
+	Location Location       `json:"location"`
+	Bounds   Bounds         `json:"bounds"`
+	Count    int            `json:"count"`
+	DeviceID string         `json:"deviceID,omitempty"`
+	Online   int            `json:"online"`
+	Offline  int            `json:"offline"`
+	States   StateCounts    `json:"states"`
+	Alarms   SeverityCounts `json:"alarms"`
+}
+
+// StateCounts counts devices by device state.
+type StateCounts struct {
+	Unknown int `json:"unknown"`
+	OK      int `json:"ok"`
+	Warning int `json:"warning"`
+	Error   int `json:"error"`
+}
+
+// SeverityCounts counts devices by the highest severity of their active alarms.
+type SeverityCounts struct {
+	None    int `json:"none"`
+	Unknown int `json:"unknown"`
+	Low     int `json:"low"`
+	Medium  int `json:"medium"`
+	High    int `json:"high"`
+}
+
+// DeviceStats counts the devices that match a query, in total and by the values of each facet.
+type DeviceStats struct {
+	Total  uint64             `json:"total"`
+	Facets map[string][]Facet `json:"facets"`
+}
+
+// Facet is the number of devices with a value. Devices with several values, such as alarm
+// types, are counted once for each value.
+type Facet struct {
+	Value string `json:"value"`
+	Count uint64 `json:"count"`
+}
+
+// Availability compares the number of messages expected from a device, given its effective
+// interval and reporting calendar, with the number of messages received within a period.
+type Availability struct {
+	DeviceID string `json:"deviceID"`
+	Tenant   string `json:"tenant"`
+	// Interval is the effective reporting interval in seconds.
+	Interval int       `json:"interval"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	Expected int       `json:"expected"`
+	Received int       `json:"received"`
+	// DeliveryRatio is received divided by expected, at most 1, and is left out when no
+	// messages are expected.
+	DeliveryRatio *float64            `json:"deliveryRatio,omitempty"`
+	Days          []DailyAvailability `json:"days"`
+	Gaps          []Gap               `json:"gaps"`
+}
+
+// DailyAvailability holds the expected and received messages of a UTC day.
+type DailyAvailability struct {
+	Date          time.Time `json:"date"`
+	Expected      int       `json:"expected"`
+	Received      int       `json:"received"`
+	DeliveryRatio *float64  `json:"deliveryRatio,omitempty"`
+}
+
+// Gap is a period without messages that is longer than the allowed number of intervals.
+type Gap struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Intervals is the number of whole intervals of expected reporting time within the gap.
+	Intervals int `json:"intervals"`
+}
+
+// StateChange is when a device went online or offline.
+type StateChange struct {
+	Online     bool      `json:"online"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// AlarmPeriod is when an alarm of a device was active, To is nil while it still is.
+type AlarmPeriod struct {
+	Type string     `json:"type"`
+	From time.Time  `json:"from"`
+	To   *time.Time `json:"to,omitempty"`
+}
+
+// DeviceHistory holds the state changes and alarms of a device within a period. States
+// starts with the last change before the period, if there is one.
+type DeviceHistory struct {
+	DeviceID string        `json:"deviceID"`
+	Name     string        `json:"name,omitzero"`
+	States   []StateChange `json:"states"`
+	Alarms   []AlarmPeriod `json:"alarms"`
+}
+
+// UptimeReport holds how much of a calendar month the devices of a tenant were online.
+// Durations are in seconds and uptime is a percentage of the monitored time.
+type UptimeReport struct {
+	Tenant      string         `json:"tenant"`
+	Month       string         `json:"month"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Monitored   int64          `json:"monitored"`
+	Online      int64          `json:"online"`
+	Uptime      *float64       `json:"uptime,omitempty"`
+	Devices     []DeviceUptime `json:"devices"`
+	GeneratedAt time.Time      `json:"generatedAt"`
+}
+
+// DeviceUptime holds the time a device was monitored, i.e. had a known state, the time it
+// was online and not reported as not observed, and the number and total time of its alarms.
+type DeviceUptime struct {
+	DeviceID  string   `json:"deviceID"`
+	Name      string   `json:"name,omitzero"`
+	Monitored int64    `json:"monitored"`
+	Online    int64    `json:"online"`
+	Uptime    *float64 `json:"uptime,omitempty"`
+	Alarms    int      `json:"alarms"`
+	AlarmTime int64    `json:"alarmTime"`
+}
+
+const (
+	BucketHour = "1h"
+	BucketDay  = "1d"
+)
+
+// Aggregate holds the min, max and average of a status value within a bucket.
+type Aggregate struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	Avg *float64 `json:"avg,omitempty"`
+}
+
+// SensorStatusAggregate summarises the status messages of a sensor within an hour or a day.
+type SensorStatusAggregate struct {
+	Bucket          time.Time `json:"bucket"`
+	Samples         int       `json:"samples"`
+	BatteryLevel    Aggregate `json:"batteryLevel"`
+	RSSI            Aggregate `json:"rssi"`
+	LoRaSNR         Aggregate `json:"loRaSNR"`
+	SpreadingFactor Aggregate `json:"spreadingFactor"`
+	DR              Aggregate `json:"dr"`
+}
+
 const (
 	DeviceStateUnknown = -1
 	DeviceStateOK      = 1
@@ -74,9 +309,10 @@ const (
 )
 
 type DeviceState struct {
-	Online     bool      `json:"online"`
-	State      int       `json:"state"`
-	ObservedAt time.Time `json:"observedAt"`
+	Online        bool       `json:"online"`
+	State         int        `json:"state"`
+	ObservedAt    time.Time  `json:"observedAt"`
+	DegradedUntil *time.Time `json:"degradedUntil,omitempty"`
 }
 
 const (
@@ -119,15 +355,21 @@ type Collection[T any] struct {
 	Offset     uint64
 	Limit      uint64
 	TotalCount uint64
+	// Cursor continues after the last item when paging with a cursor, it is nil on the last page.
+	Cursor *Cursor
 }
 
 type Bounds struct {
-	MinLon float64
-	MaxLon float64
-	MinLat float64
-	MaxLat float64
+	MinLon float64 `json:"minLon"`
+	MaxLon float64 `json:"maxLon"`
+	MinLat float64 `json:"minLat"`
+	MaxLat float64 `json:"maxLat"`
 }
 
+// Polygon is a list of linear rings of [longitude, latitude] positions, as in GeoJSON. The
+// first ring is the exterior and any following rings are holes.
+type Polygon [][][2]float64
+
 type StatusMessage struct {
 	DeviceID string `json:"deviceID"`
 
@@ -142,10 +384,38 @@ type StatusMessage struct {
 	SpreadingFactor *float64 `json:"spreadingFactor,omitempty"`
 	DR              *int     `json:"dr,omitempty"`
 
+	// Location is reported by mobile devices that know where they are.
+	Location *Location `json:"location,omitempty"`
+
 	Tenant    string    `json:"tenant"`
 	Timestamp time.Time `json:"timestamp"`
 }
 
+type QuarantinedMessage struct {
+	ID         int64     `json:"id"`
+	DeviceID   string    `json:"deviceID,omitempty"`
+	Tenant     string    `json:"tenant,omitempty"`
+	Reason     string    `json:"reason"`
+	Error      string    `json:"error,omitempty"`
+	Body       string    `json:"body"`
+	Attempts   int       `json:"attempts"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+type QuarantineGroup struct {
+	DeviceID      string    `json:"deviceID"`
+	Tenant        string    `json:"tenant"`
+	Reason        string    `json:"reason"`
+	Count         uint64    `json:"count"`
+	FirstReceived time.Time `json:"firstReceived"`
+	LastReceived  time.Time `json:"lastReceived"`
+}
+
+type QuarantineReplay struct {
+	Replayed int `json:"replayed"`
+	Failed   int `json:"failed"`
+}
+
 type Measurement struct {
 	ID        string    `json:"id,omitzero"`
 	Urn       string    `json:"urn,omitzero"`
@@ -162,6 +432,10 @@ type Sensor struct {
 	Name          *string        `json:"name,omitempty"`
 	Location      *Location      `json:"location,omitempty"`
 	SensorStatus  *SensorStatus  `json:"sensorStatus,omitempty"`
+	Tenant        *string        `json:"tenant,omitempty"`
+	Discovered    bool           `json:"discovered,omitempty"`
+	FirstSeen     *time.Time     `json:"firstSeen,omitempty"`
+	LastSeen      *time.Time     `json:"lastSeen,omitempty"`
 }
 
 type SensorInputModel struct {
@@ -179,3 +453,29 @@ type SensorsQuery struct {
 	ProfileName string   `json:"profileName,omitempty"`
 	Types       []string `json:"types,omitempty"`
 }
+
+// DevicesQuery filters the devices listed by the client. Devices are paged by cursor, Limit
+// is the number of devices per page.
+type DevicesQuery struct {
+	Limit    *int     `json:"limit,omitempty"`
+	Active   *bool    `json:"active,omitempty"`
+	Types    []string `json:"types,omitempty"`
+	Tenant   string   `json:"tenant,omitempty"`
+	SortBy   string   `json:"sortBy,omitempty"`
+	SortDesc bool     `json:"sortDesc,omitempty"`
+}
+
+// AlarmsQuery filters the alarms listed by the client. Alarms are paged by cursor, Limit is
+// the number of devices with alarms per page.
+type AlarmsQuery struct {
+	Limit     *int   `json:"limit,omitempty"`
+	AlarmType string `json:"alarmType,omitempty"`
+}
+
+type DeviceStatusQuery struct {
+	From   *time.Time `json:"from,omitempty"`
+	To     *time.Time `json:"to,omitempty"`
+	Bucket string     `json:"bucket,omitempty"`
+	Offset *int       `json:"offset,omitempty"`
+	Limit  *int       `json:"limit,omitempty"`
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrInvalidView = errors.New("invalid view")
+
+// View is a named set of device filters, with sort order and columns, that is private to its
+// owner or shared with everyone in the tenant. Filters holds the query parameters of
+// GET /devices, such as online or metadata[key].
+type View struct {
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Tenant   string     `json:"tenant"`
+	Owner    string     `json:"owner,omitempty"`
+	Shared   bool       `json:"shared"`
+	Filters  url.Values `json:"filters,omitempty"`
+	SortBy   string     `json:"sortBy,omitempty"`
+	SortDesc bool       `json:"sortDesc,omitempty"`
+	Columns  []string   `json:"columns,omitempty"`
+}
+
+// viewPageParameters are set by the request that executes a view and can not be saved.
+var viewPageParameters = []string{"limit", "offset", "cursor", "sortby", "sortorder", "export"}
+
+func (v View) Validate() error {
+	if strings.TrimSpace(v.Name) == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidView)
+	}
+
+	for key := range v.Filters {
+		for _, p := range viewPageParameters {
+			if strings.EqualFold(key, p) {
+				return fmt.Errorf("%w: %s can not be used as a filter", ErrInvalidView, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ViewMembership lists the devices that joined or left a view since the last change. The
+// first membership of a subscription lists all devices as joined.
+type ViewMembership struct {
+	ViewID    string    `json:"viewID"`
+	Joined    []string  `json:"joined"`
+	Left      []string  `json:"left"`
+	Timestamp time.Time `json:"timestamp"`
+}